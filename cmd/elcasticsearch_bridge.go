@@ -6,19 +6,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"os/signal"
 	"sort"
-	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/boltdb/bolt"
 	common "github.com/cloudtrust/common-healthcheck"
 	fb_flaki "github.com/cloudtrust/elasticsearch-bridge/api/fb"
 	elasticsearch_bridge "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
+	"github.com/cloudtrust/elasticsearch-bridge/internal/flaki"
+	"github.com/cloudtrust/elasticsearch-bridge/internal/loglevel"
 	"github.com/cloudtrust/elasticsearch-bridge/pkg/health"
 	health_job "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
 	"github.com/cloudtrust/go-jobs"
@@ -30,7 +33,7 @@ import (
 	sentry "github.com/getsentry/raven-go"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
-	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/log/level"
 	gokit_influx "github.com/go-kit/kit/metrics/influx"
 	flatbuffers "github.com/google/flatbuffers/go"
 	"github.com/gorilla/mux"
@@ -41,6 +44,7 @@ import (
 	"github.com/spf13/viper"
 	jaeger "github.com/uber/jaeger-client-go/config"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var (
@@ -63,6 +67,7 @@ const (
 	sentryKey        = "sentry"
 	flakiKey         = "flaki"
 	elasticsearchKey = "elasticsearch"
+	cockroachKey     = "cockroach"
 )
 
 func main() {
@@ -72,16 +77,34 @@ func main() {
 	{
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
 	}
-	defer logger.Log("msg", "goodbye")
+	defer level.Info(logger).Log("msg", "goodbye")
 
 	// Configurations.
 	var c = config(log.With(logger, "unit", "config"))
 	var (
 		// Component
-		httpAddr = c.GetString("component-http-host-port")
+		httpAddr       = c.GetString("component-http-host-port")
+		grpcHealthAddr = c.GetString("component-grpc-health-host-port")
 
 		// Flaki
-		flakiAddr = c.GetString("flaki-host-port")
+		flakiConnConfig = flaki.ConnConfig{
+			Addrs:            splitAddrs(c.GetString("flaki-host-port")),
+			TLS:              c.GetBool("flaki-tls"),
+			CAFile:           c.GetString("flaki-tls-ca-file"),
+			CertFile:         c.GetString("flaki-tls-cert-file"),
+			KeyFile:          c.GetString("flaki-tls-key-file"),
+			ServerName:       c.GetString("flaki-tls-server-name"),
+			KeepaliveTime:    c.GetDuration("flaki-keepalive-time"),
+			KeepaliveTimeout: c.GetDuration("flaki-keepalive-timeout"),
+		}
+		flakiFallbackNodeID = c.GetInt("flaki-fallback-node-id")
+		flakiProbeInterval  = c.GetDuration("flaki-probe-interval")
+		flakiConfig         = flaki.ClientConfig{
+			PoolSize:     c.GetInt("flaki-pool-size"),
+			Timeout:      c.GetDuration("flaki-timeout"),
+			Retries:      c.GetInt("flaki-retries"),
+			RetryBackoff: c.GetDuration("flaki-retry-backoff"),
+		}
 
 		// Elasticsearch
 		elasticsearchConfig = elasticsearch_bridge.Config{
@@ -89,6 +112,20 @@ func main() {
 		}
 		elasticsearchIndexCleanInterval = c.GetDuration("elasticsearch-index-clean-interval")
 		elasticsearchIndexExpiration    = c.GetDuration("elasticsearch-index-expiration")
+		elasticsearchHealthIndex        = c.GetString("elasticsearch-health-index")
+		elasticsearchProbeCleanInterval = c.GetDuration("elasticsearch-probe-index-clean-interval")
+		elasticsearchThresholds         = health.ElasticsearchThresholds{
+			JVMHeapDegraded:     c.GetInt("elasticsearch-health-jvm-heap-degraded"),
+			JVMHeapKO:           c.GetInt("elasticsearch-health-jvm-heap-ko"),
+			PendingTasks:        c.GetInt("elasticsearch-health-pending-tasks"),
+			PendingTasksMaxWait: c.GetDuration("elasticsearch-health-pending-tasks-max-wait"),
+			ShardsPerNodeRatio:  c.GetFloat64("elasticsearch-health-shards-per-node-ratio"),
+			ExpectedDataNodes:   c.GetInt("elasticsearch-health-expected-data-nodes"),
+
+			SearchVisibilityTimeout: c.GetDuration("elasticsearch-health-search-visibility-timeout"),
+			ProbeIndexMaxAge:        c.GetDuration("elasticsearch-probe-index-max-age"),
+			CheckTimeout:            c.GetDuration("elasticsearch-health-check-timeout"),
+		}
 
 		// Enabled units
 		cockroachEnabled  = c.GetBool("cockroach")
@@ -112,9 +149,15 @@ func main() {
 		}
 		influxWriteInterval = c.GetDuration("influx-write-interval")
 
-		// Jaeger
-		jaegerConfig = jaeger.Configuration{
-			Disabled: !jaegerEnabled,
+		// Prometheus
+		prometheusEnabled      = c.GetBool("prometheus")
+		prometheusNamespace    = c.GetString("prometheus-namespace")
+		prometheusScrapeMaxAge = c.GetDuration("prometheus-scrape-max-age")
+
+		// Tracing
+		tracingConfig = elasticsearch_bridge.TracingConfig{
+			Enabled:  jaegerEnabled,
+			Exporter: c.GetString("tracing-exporter"),
 			Sampler: &jaeger.SamplerConfig{
 				Type:              c.GetString("jaeger-sampler-type"),
 				Param:             c.GetFloat64("jaeger-sampler-param"),
@@ -124,17 +167,70 @@ func main() {
 				LogSpans:            c.GetBool("jaeger-reporter-logspan"),
 				BufferFlushInterval: c.GetDuration("jaeger-write-interval"),
 			},
+			OTLP: elasticsearch_bridge.OTLPConfig{
+				Protocol:   c.GetString("otlp-protocol"),
+				Endpoint:   c.GetString("otlp-endpoint"),
+				Timeout:    c.GetDuration("otlp-timeout"),
+				BatchSize:  c.GetInt("otlp-batch-size"),
+				TLS:        c.GetBool("otlp-tls"),
+				CAFile:     c.GetString("otlp-tls-ca-file"),
+				CertFile:   c.GetString("otlp-tls-cert-file"),
+				KeyFile:    c.GetString("otlp-tls-key-file"),
+				ServerName: c.GetString("otlp-tls-server-name"),
+			},
+			ResourceAttributes: c.GetStringMapString("tracing-resource-attributes"),
 		}
 		jaegerCollectorHealthcheckURL = c.GetString("jaeger-collector-healthcheck-host-port")
 
 		// Sentry
-		sentryDSN = c.GetString("sentry-dsn")
+		sentryDSN                = c.GetString("sentry-dsn")
+		sentryDedupWindow        = c.GetDuration("sentry-dedup-window")
+		sentryMaxErrorsPerMinute = c.GetInt("sentry-max-errors-per-minute")
 
 		// Redis
 		redisURL           = c.GetString("redis-host-port")
 		redisPassword      = c.GetString("redis-password")
 		redisDatabase      = c.GetInt("redis-database")
-		redisWriteInterval = c.GetDuration("redis-write-interval")
+		redisShipperConfig = elasticsearch_bridge.RedisShipperConfig{
+			Key:           ComponentName,
+			QueueSize:     c.GetInt("redis-queue-size"),
+			BatchSize:     c.GetInt("redis-batch-size"),
+			FlushInterval: c.GetDuration("redis-write-interval"),
+			DropPolicy:    c.GetString("redis-drop-policy"),
+			MinBackoff:    c.GetDuration("redis-reconnect-min-backoff"),
+			MaxBackoff:    c.GetDuration("redis-reconnect-max-backoff"),
+		}
+
+		// Log levels
+		logLevel             = c.GetString("log-level")
+		logUnitLevels        = c.GetStringMapString("log-unit-levels")
+		logSvcLevels         = c.GetStringMapString("log-svc-levels")
+		logLevelTTL          = c.GetDuration("log-level-ttl")
+		logLevelRouteEnabled = c.GetBool("log-level-route-enabled")
+
+		// Log formats
+		logStdoutFormat = c.GetString("log-stdout-format")
+		logRedisFormat  = c.GetString("log-redis-format")
+
+		// Elasticsearch log sink
+		logElasticsearchEnabled = c.GetBool("log-elasticsearch")
+		logElasticsearchFormat  = c.GetString("log-elasticsearch-format")
+		logElasticsearchConfig  = elasticsearch_bridge.ElasticsearchSinkConfig{
+			IndexPrefix:   c.GetString("log-elasticsearch-index-prefix"),
+			QueueSize:     c.GetInt("log-elasticsearch-queue-size"),
+			BatchSize:     c.GetInt("log-elasticsearch-batch-size"),
+			FlushInterval: c.GetDuration("log-elasticsearch-write-interval"),
+			Timeout:       c.GetDuration("log-elasticsearch-timeout"),
+			MinBackoff:    c.GetDuration("log-elasticsearch-retry-min-backoff"),
+			MaxBackoff:    c.GetDuration("log-elasticsearch-retry-max-backoff"),
+		}
+
+		// Logstash
+		logstashFieldMapping = elasticsearch_bridge.LogstashFieldMapping{
+			Timestamp: c.GetString("logstash-timestamp-key"),
+			Message:   c.GetString("logstash-message-key"),
+			Fields:    c.GetStringMapString("logstash-field-mapping"),
+		}
 
 		// Cockroach
 		cockroachHostPort      = c.GetString("cockroach-host-port")
@@ -143,6 +239,12 @@ func main() {
 		cockroachHealthDB      = c.GetString("cockroach-health-database")
 		cockroachJobsDB        = c.GetString("cockroach-jobs-database")
 		cockroachCleanInterval = c.GetDuration("cockroach-clean-interval")
+		cockroachHealthLatency = c.GetDuration("cockroach-health-latency-degraded")
+
+		// Health storage. The embedded storage is used when cockroach is disabled.
+		healthStorage         = c.GetString("health-storage")
+		healthStorageBoltPath = c.GetString("health-storage-bolt-path")
+		healthGoneRetention   = c.GetDuration("health-gone-retention")
 
 		// Jobs
 		healthChecksValidity = map[string]time.Duration{
@@ -152,72 +254,180 @@ func main() {
 			sentryKey:        c.GetDuration("job-sentry-health-validity"),
 			flakiKey:         c.GetDuration("job-flaki-health-validity"),
 			elasticsearchKey: c.GetDuration("job-elasticsearch-health-validity"),
+			cockroachKey:     c.GetDuration("job-cockroach-health-validity"),
+		}
+		healthChecksTimeout = map[string]time.Duration{
+			influxKey:        c.GetDuration("health-influx-timeout"),
+			jaegerKey:        c.GetDuration("health-jaeger-timeout"),
+			redisKey:         c.GetDuration("health-redis-timeout"),
+			sentryKey:        c.GetDuration("health-sentry-timeout"),
+			flakiKey:         c.GetDuration("health-flaki-timeout"),
+			elasticsearchKey: c.GetDuration("health-elasticsearch-timeout"),
+			cockroachKey:     c.GetDuration("health-cockroach-timeout"),
+		}
+		healthDeepTimeout      = c.GetDuration("health-deep-timeout")
+		healthChecksHysteresis = map[string]health.HysteresisConfig{
+			influxKey:        hysteresisConfig(c, influxKey),
+			jaegerKey:        hysteresisConfig(c, jaegerKey),
+			redisKey:         hysteresisConfig(c, redisKey),
+			sentryKey:        hysteresisConfig(c, sentryKey),
+			flakiKey:         hysteresisConfig(c, flakiKey),
+			elasticsearchKey: hysteresisConfig(c, elasticsearchKey),
+			cockroachKey:     hysteresisConfig(c, cockroachKey),
 		}
 	)
 
 	// Redis.
 	type Redis interface {
-		Close() error
 		Do(commandName string, args ...interface{}) (reply interface{}, err error)
-		Send(commandName string, args ...interface{}) error
-		Flush() error
 	}
 
 	var redisClient Redis = &elasticsearch_bridge.NoopRedis{}
+	var redisPool *redis.Pool
 	if redisEnabled {
+		redisPool = elasticsearch_bridge.NewRedisPool(redisURL, redisPassword, redisDatabase)
+		defer redisPool.Close()
+		redisClient = elasticsearch_bridge.NewPooledRedis(redisPool)
+	}
+
+	// Log levels, per unit and svc tags.
+	var logLevels *loglevel.Levels
+	{
 		var err error
-		redisClient, err = redis.Dial("tcp", redisURL, redis.DialDatabase(redisDatabase), redis.DialPassword(redisPassword))
+		logLevels, err = loglevel.New(logLevel, logUnitLevels, logSvcLevels)
+		if err != nil {
+			level.Error(logger).Log("msg", "could not create log levels", "error", err)
+			return
+		}
+	}
+
+	// Create logger that writes logs to stdout and duplicates them to Redis and to
+	// Elasticsearch, in the configured log formats. The logs are shipped to Redis by the
+	// Redis shipper and indexed into Elasticsearch by the Elasticsearch sink.
+	var redisShipper *elasticsearch_bridge.RedisShipper
+	var elasticsearchSink *elasticsearch_bridge.ElasticsearchSink
+	{
+		var stdoutEncoder, err = elasticsearch_bridge.NewLogEncoder(logStdoutFormat, logstashFieldMapping)
 		if err != nil {
-			logger.Log("msg", "could not create redis client", "error", err)
+			level.Error(logger).Log("msg", "could not create stdout log encoder", "error", err)
 			return
 		}
-		defer redisClient.Close()
+		var stdout = elasticsearch_bridge.NewEncodingWriter(os.Stdout, stdoutEncoder)
+		var w io.Writer = stdout
 
-		// Create logger that duplicates logs to stdout and Redis.
-		logger = log.NewJSONLogger(io.MultiWriter(os.Stdout, elasticsearch_bridge.NewLogstashRedisWriter(redisClient, ComponentName)))
+		if redisEnabled {
+			var redisEncoder elasticsearch_bridge.LogEncoder
+			redisEncoder, err = elasticsearch_bridge.NewLogEncoder(logRedisFormat, logstashFieldMapping)
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create Redis log encoder", "error", err)
+				return
+			}
+			redisShipper, err = elasticsearch_bridge.NewRedisShipper(redisPool, redisEncoder, redisShipperConfig)
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create Redis log shipper", "error", err)
+				return
+			}
+			w = io.MultiWriter(w, redisShipper)
+		}
+
+		if logElasticsearchEnabled {
+			var sinkEncoder elasticsearch_bridge.LogEncoder
+			sinkEncoder, err = elasticsearch_bridge.NewLogEncoder(logElasticsearchFormat, logstashFieldMapping)
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create Elasticsearch log encoder", "error", err)
+				return
+			}
+
+			// The sink has its own client, without the logging middleware, and logs its
+			// errors to stdout only, so the sink never logs into itself.
+			var sinkClient *elasticsearch_bridge.Client
+			sinkClient, err = elasticsearch_bridge.New(elasticsearch_bridge.Config{Addr: elasticsearchConfig.Addr, Timeout: logElasticsearchConfig.Timeout})
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create Elasticsearch log sink client", "error", err)
+				return
+			}
+			var sinkLogger = log.With(log.NewJSONLogger(stdout), "ts", log.DefaultTimestampUTC, "unit", "elasticsearch-log-sink")
+			elasticsearchSink, err = elasticsearch_bridge.NewElasticsearchSink(sinkClient, sinkEncoder, logElasticsearchConfig, sinkLogger)
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create Elasticsearch log sink", "error", err)
+				return
+			}
+			w = io.MultiWriter(w, elasticsearchSink)
+		}
+
+		logger = logLevels.Logger(log.NewJSONLogger(w))
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
 	}
 
-	// Flaki.
-	var flakiClient fb_flaki.FlakiClient
+	// Flaki. The client prefetches the IDs in the background, with deadlines and retries.
+	var flakiConn *grpc.ClientConn
+	var flakiClient *flaki.Client
 	{
-		// Set up a connection to the flaki-service.
-		var conn *grpc.ClientConn
+		// Set up a connection to the flaki-service, balanced across its addresses.
 		{
 			var err error
-			conn, err = grpc.Dial(flakiAddr, grpc.WithInsecure(), grpc.WithCodec(flatbuffers.FlatbuffersCodec{}))
+			flakiConn, err = flaki.Dial(flakiConnConfig, grpc.WithCodec(flatbuffers.FlatbuffersCodec{}))
 			if err != nil {
-				logger.Log("msg", "could not connect to flaki-service", "error", err)
+				level.Error(logger).Log("msg", "could not connect to flaki-service", "error", err)
 				return
 			}
-			defer conn.Close()
+			defer flakiConn.Close()
 		}
 
-		flakiClient = fb_flaki.NewFlakiClient(conn)
+		var err error
+		flakiClient, err = flaki.NewClient(fb_flaki.NewFlakiClient(flakiConn), flakiConfig)
+		if err != nil {
+			level.Error(logger).Log("msg", "could not create flaki client", "error", err)
+			return
+		}
 	}
 
-	// Get unique ID for this component
-	var ComponentID string
+	// Flaki fallback. When Flaki is unreachable, the IDs are generated by an embedded
+	// generator with the Flaki layout. The node ID (0 to 31) of the generator is derived
+	// from the host name if it is not configured.
+	var idClient *flaki.FallbackClient
 	{
-		var b = flatbuffers.NewBuilder(0)
-		fb_flaki.FlakiRequestStart(b)
-		b.Finish(fb_flaki.FlakiRequestEnd(b))
-
-		var reply, err = flakiClient.NextValidID(context.Background(), b)
+		var componentID, nodeID uint64
+		switch {
+		case flakiFallbackNodeID < 0:
+			var hostname, err = os.Hostname()
+			if err != nil {
+				level.Error(logger).Log("msg", "could not get host name", "error", err)
+				return
+			}
+			componentID, nodeID = flaki.LocalNodeIDs(hostname)
+		default:
+			componentID, nodeID = uint64(flakiFallbackNodeID)>>3, uint64(flakiFallbackNodeID)&7
+		}
 
+		var generator, err = flaki.NewGenerator(componentID, nodeID)
+		if err != nil {
+			level.Error(logger).Log("msg", "could not create flaki fallback generator", "error", err)
+			return
+		}
+		idClient, err = flaki.NewFallbackClient(flakiClient, generator, flakiProbeInterval)
 		if err != nil {
-			logger.Log("msg", "cannot get ID from flaki-service", "error", err)
+			level.Error(logger).Log("msg", "could not create flaki fallback client", "error", err)
 			return
 		}
+		defer idClient.Close()
+	}
 
-		ComponentID = string(reply.Id())
+	// Get unique ID for this component
+	var ComponentID string
+	{
+		ComponentID = idClient.NextValidID(context.Background())
+
+		if degraded, _, err := idClient.Degraded(); degraded {
+			level.Warn(logger).Log("msg", "flaki-service unreachable, component ID generated by the fallback generator", "error", err)
+		}
 	}
 
 	// Add component name, component ID and version to the logger tags.
 	logger = log.With(logger, "component_name", ComponentName, "component_id", ComponentID, "component_version", Version)
 
 	// Log component version infos.
-	logger.Log("environment", Environment, "git_commit", GitCommit)
+	level.Info(logger).Log("environment", Environment, "git_commit", GitCommit)
 
 	// Critical errors channel.
 	var errc = make(chan error)
@@ -240,28 +450,24 @@ func main() {
 		var err error
 		sentryClient, err = sentry.New(sentryDSN)
 		if err != nil {
-			logger.Log("msg", "could not create Sentry client", "error", err)
+			level.Error(logger).Log("msg", "could not create Sentry client", "error", err)
 			return
 		}
 		defer sentryClient.Close()
 	}
 
-	// Influx client.
-	type Metrics interface {
-		NewCounter(name string) metrics.Counter
-		NewGauge(name string) metrics.Gauge
-		NewHistogram(name string) metrics.Histogram
-		WriteLoop(c <-chan time.Time)
-		Ping(timeout time.Duration) (time.Duration, string, error)
-	}
+	// Errors of the endpoints, the health component and the jobs are captured in Sentry,
+	// deduplicated and rate limited.
+	var errorTracker = elasticsearch_bridge.NewErrorTracker(sentryClient, map[string]string{"component_id": ComponentID}, sentryDedupWindow, sentryMaxErrorsPerMinute)
 
-	var influxMetrics Metrics = &elasticsearch_bridge.NoopMetrics{}
+	// Influx client.
+	var influxMetrics elasticsearch_bridge.Metrics = &elasticsearch_bridge.NoopMetrics{}
 	if influxEnabled {
 		var logger = log.With(logger, "unit", "influx")
 
 		var influxClient, err = influx.NewHTTPClient(influxHTTPConfig)
 		if err != nil {
-			logger.Log("msg", "could not create Influx client", "error", err)
+			level.Error(logger).Log("msg", "could not create Influx client", "error", err)
 			return
 		}
 		defer influxClient.Close()
@@ -275,16 +481,64 @@ func main() {
 		influxMetrics = elasticsearch_bridge.NewMetrics(influxClient, gokitInflux)
 	}
 
-	// Jaeger client.
+	// Prometheus metrics, pulled by Prometheus on the /metrics route.
+	var prometheusMetrics *elasticsearch_bridge.PrometheusMetrics
+	if prometheusEnabled {
+		prometheusMetrics = elasticsearch_bridge.NewPrometheusMetrics(prometheusNamespace, prometheusScrapeMaxAge)
+	}
+
+	// Metrics backends. The enabled backends receive all the metrics.
+	var metricsBackend elasticsearch_bridge.Metrics = &elasticsearch_bridge.NoopMetrics{}
+	{
+		var backends = []elasticsearch_bridge.Metrics{}
+		if influxEnabled {
+			backends = append(backends, influxMetrics)
+		}
+		if prometheusEnabled {
+			backends = append(backends, prometheusMetrics)
+		}
+		if len(backends) > 0 {
+			metricsBackend = elasticsearch_bridge.NewMultiMetrics(backends...)
+		}
+	}
+
+	// Instrumenting metrics, labeled by component ID.
+	var (
+		endpointCounter   = metricsBackend.NewCounter("health_endpoint_calls", "unit", "component_id").With("component_id", ComponentID)
+		endpointHistogram = metricsBackend.NewHistogram("health_endpoint_duration_seconds", "unit", "component_id").With("component_id", ComponentID)
+
+		componentCounter   = metricsBackend.NewCounter("health_component_calls", "unit", "component_id").With("component_id", ComponentID)
+		componentHistogram = metricsBackend.NewHistogram("health_component_duration_seconds", "unit", "component_id").With("component_id", ComponentID)
+
+		// The status gauge of each unit is OK=0, KO=1, Degraded=2, Deactivated=3, Unknown=4.
+		moduleHistogram = metricsBackend.NewHistogram("health_checks_duration_seconds", "unit", "component_id").With("component_id", ComponentID)
+		unitStatusGauge = metricsBackend.NewGauge("health_unit_status", "unit", "component_id").With("component_id", ComponentID)
+
+		jobCounter   = metricsBackend.NewCounter("job_executions", "unit", "status", "component_id").With("component_id", ComponentID)
+		jobHistogram = metricsBackend.NewHistogram("job_duration_seconds", "unit", "component_id").With("component_id", ComponentID)
+	)
+
+	// Tracer. The spans are exported to Jaeger or to an OTLP collector.
 	var tracer opentracing.Tracer
+	var otlpTransport *elasticsearch_bridge.OTLPTransport
 	{
 		var logger = log.With(logger, "unit", "jaeger")
 		var closer io.Closer
 		var err error
 
-		tracer, closer, err = jaegerConfig.New(ComponentName)
+		var attributes = map[string]string{
+			"service.instance.id":    ComponentID,
+			"service.version":        Version,
+			"deployment.environment": Environment,
+		}
+		for k, v := range tracingConfig.ResourceAttributes {
+			attributes[k] = v
+		}
+		tracingConfig.ResourceAttributes = attributes
+
+		tracer, closer, otlpTransport, err = elasticsearch_bridge.NewTracer(ComponentName, tracingConfig)
 		if err != nil {
-			logger.Log("msg", "could not create Jaeger tracer", "error", err)
+			level.Error(logger).Log("msg", "could not create tracer", "exporter", tracingConfig.Exporter, "error", err)
 			return
 		}
 		defer closer.Close()
@@ -296,7 +550,7 @@ func main() {
 		var err error
 		systemDConn, err = dbus.New()
 		if err != nil {
-			logger.Log("msg", "could not create systemd D-Bus connection", "error", err)
+			level.Error(logger).Log("msg", "could not create systemd D-Bus connection", "error", err)
 			return
 		}
 	}
@@ -307,18 +561,31 @@ func main() {
 		var err error
 		var logger = log.With(logger, "unit", "elasticsearch")
 
-		elasticsearchClient, err = elasticsearch_bridge.New(elasticsearchConfig)
+		// The requests are logged with their correlation ID, traced in child spans of the
+		// endpoint or job span, and measured.
+		var latency = metricsBackend.NewHistogram("elasticsearch_request_duration_seconds", "operation", "component_id").With("component_id", ComponentID)
+		var errorCounter = metricsBackend.NewCounter("elasticsearch_request_errors", "operation", "status", "error_type", "component_id").With("component_id", ComponentID)
+
+		elasticsearchClient, err = elasticsearch_bridge.New(elasticsearchConfig,
+			elasticsearch_bridge.MakeElasticsearchLoggingMW(logger),
+			elasticsearch_bridge.MakeElasticsearchTracingMW(tracer),
+			elasticsearch_bridge.MakeElasticsearchInstrumentingMW(latency, errorCounter),
+		)
 		if err != nil {
-			logger.Log("msg", "could not create elasticsearch client", "error", err)
+			level.Error(logger).Log("msg", "could not create elasticsearch client", "error", err)
 			return
 		}
 	}
 
 	// Cockroach DB.
 	type Cockroach interface {
+		Ping() error
 		Exec(query string, args ...interface{}) (sql.Result, error)
 		Query(query string, args ...interface{}) (*sql.Rows, error)
 		QueryRow(query string, args ...interface{}) *sql.Row
+		PingContext(ctx context.Context) error
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	}
 
 	var cHealthDB Cockroach
@@ -327,12 +594,12 @@ func main() {
 		var err error
 		cHealthDB, err = sql.Open("postgres", fmt.Sprintf("postgresql://%s:%s@%s/%s?sslmode=disable", cockroachUsername, cockroachPassword, cockroachHostPort, cockroachHealthDB))
 		if err != nil {
-			logger.Log("msg", "could not create cockroach DB connection for health DB", "error", err)
+			level.Error(logger).Log("msg", "could not create cockroach DB connection for health DB", "error", err)
 			return
 		}
 		cJobsDB, err = sql.Open("postgres", fmt.Sprintf("postgresql://%s:%s@%s/%s?sslmode=disable", cockroachUsername, cockroachPassword, cockroachHostPort, cockroachJobsDB))
 		if err != nil {
-			logger.Log("msg", "could not create cockroach DB connection for health DB", "error", err)
+			level.Error(logger).Log("msg", "could not create cockroach DB connection for health DB", "error", err)
 			return
 		}
 	}
@@ -340,124 +607,231 @@ func main() {
 	// Health service.
 	var healthLogger = log.With(logger, "svc", "health")
 
-	var cockroachModule *health.StorageModule
+	// Without cockroach, the health checks results, the jobs status and the jobs locks are stored
+	// in memory or in an embedded BoltDB file.
+	var storageModule health.StoreModule
+	var jobsStore health_job.Store
+	switch {
+	case cockroachEnabled:
+		storageModule = health.NewStorageModule(ComponentName, ComponentID, healthGoneRetention, cHealthDB)
+	case healthStorage == "bolt":
+		var db, err = bolt.Open(healthStorageBoltPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+		if err != nil {
+			level.Error(logger).Log("msg", "could not open BoltDB health storage", "path", healthStorageBoltPath, "error", err)
+			return
+		}
+		defer db.Close()
+
+		storageModule, err = health.NewBoltStorageModule(ComponentName, ComponentID, healthGoneRetention, db)
+		if err != nil {
+			level.Error(logger).Log("msg", "could not create BoltDB health storage", "error", err)
+			return
+		}
+		jobsStore = health_job.NewBoltStore(db)
+	default:
+		storageModule = health.NewMemoryStorageModule(ComponentName, ComponentID, healthGoneRetention)
+		jobsStore = health_job.NewMemoryStore()
+	}
+
+	// The watch module notifies the gRPC health watchers of the stored reports updates.
+	var watchModule = health.NewWatchModule(storageModule)
+
+	// The health checks results go through the hysteresis module before being stored.
+	var hysteresisModule *health.HysteresisModule
 	{
-		cockroachModule = health.NewStorageModule(ComponentName, ComponentID, cHealthDB)
+		hysteresisModule = health.NewHysteresisModule(watchModule, healthChecksHysteresis)
 	}
 
 	var influxHM health.InfluxHealthChecker
 	{
 		influxHM = common.NewInfluxModule(influxMetrics, influxEnabled)
 		influxHM = common.MakeInfluxModuleLoggingMW(log.With(healthLogger, "mw", "module"))(influxHM)
+		influxHM = health.MakeInfluxModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(influxHM)
 	}
 	var jaegerHM health.JaegerHealthChecker
 	{
-		jaegerHM = common.NewJaegerModule(systemDConn, http.DefaultClient, jaegerCollectorHealthcheckURL, jaegerEnabled)
+		if tracingConfig.Exporter == elasticsearch_bridge.OTLPExporter {
+			jaegerHM = health.NewOTLPModule(otlpTransport, jaegerEnabled)
+		} else {
+			jaegerHM = common.NewJaegerModule(systemDConn, http.DefaultClient, jaegerCollectorHealthcheckURL, jaegerEnabled)
+		}
 		jaegerHM = common.MakeJaegerModuleLoggingMW(log.With(healthLogger, "mw", "module"))(jaegerHM)
+		jaegerHM = health.MakeJaegerModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(jaegerHM)
 	}
 	var redisHM health.RedisHealthChecker
 	{
 		redisHM = common.NewRedisModule(redisClient, redisEnabled)
 		redisHM = common.MakeRedisModuleLoggingMW(log.With(healthLogger, "mw", "module"))(redisHM)
+		redisHM = health.MakeRedisModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(redisHM)
 	}
 	var sentryHM health.SentryHealthChecker
 	{
 		sentryHM = common.NewSentryModule(sentryClient, http.DefaultClient, sentryEnabled)
 		sentryHM = common.MakeSentryModuleLoggingMW(log.With(healthLogger, "mw", "module"))(sentryHM)
+		sentryHM = health.MakeSentryModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(sentryHM)
 	}
 	var flakiHM health.FlakiHealthChecker
 	{
 		flakiHM = common.NewFlakiModule(elasticsearch_bridge.NewFlakiLightClient(flakiClient))
+		flakiHM = health.MakeFlakiModuleFallbackMW(idClient)(flakiHM)
+		flakiHM = health.MakeFlakiModuleConnectivityMW(flakiConn)(flakiHM)
 		flakiHM = common.MakeFlakiModuleLoggingMW(log.With(healthLogger, "mw", "module"))(flakiHM)
+		flakiHM = health.MakeFlakiModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(flakiHM)
 	}
 	var elasticsearchHM health.ElasticsearchHealthChecker
 	{
-		elasticsearchHM = health.NewElasticsearchModule(elasticsearchClient)
+		elasticsearchHM = health.NewElasticsearchModule(elasticsearchClient, elasticsearchHealthIndex, elasticsearchThresholds)
 		elasticsearchHM = health.MakeElasticsearchModuleLoggingMW(log.With(healthLogger, "mw", "module"))(elasticsearchHM)
+		elasticsearchHM = health.MakeElasticsearchModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(elasticsearchHM)
+	}
+	var cockroachHM health.CockroachHealthChecker
+	{
+		cockroachHM = health.NewCockroachModule(cHealthDB, cJobsDB, ComponentName, ComponentID, cockroachHealthLatency, cockroachEnabled)
+		cockroachHM = health.MakeCockroachModuleLoggingMW(log.With(healthLogger, "mw", "module"))(cockroachHM)
+		cockroachHM = health.MakeCockroachModuleInstrumentingMW(moduleHistogram, unitStatusGauge)(cockroachHM)
 	}
 	var healthComponent health.HealthChecker
 	{
-		healthComponent = health.NewComponent(influxHM, jaegerHM, redisHM, sentryHM, flakiHM, elasticsearchHM, cockroachModule, healthChecksValidity)
+		healthComponent = health.NewComponent(influxHM, jaegerHM, redisHM, sentryHM, flakiHM, elasticsearchHM, cockroachHM, health.MakeStoreModuleErrorTrackingMW(errorTracker)(hysteresisModule), healthChecksValidity, healthChecksTimeout, healthDeepTimeout)
 		healthComponent = health.MakeComponentLoggingMW(log.With(healthLogger, "mw", "component"))(healthComponent)
+		healthComponent = health.MakeComponentInstrumentingMW(componentCounter, componentHistogram)(healthComponent)
 	}
 
 	var influxExecHealthEndpoint endpoint.Endpoint
 	{
 		influxExecHealthEndpoint = health.MakeExecInfluxHealthCheckEndpoint(healthComponent)
 		influxExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecInfluxHealthCheck"))(influxExecHealthEndpoint)
-		influxExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(influxExecHealthEndpoint)
+		influxExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecInfluxHealthCheck"), endpointHistogram.With("unit", "ExecInfluxHealthCheck"))(influxExecHealthEndpoint)
+		influxExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecInfluxHealthCheck")(influxExecHealthEndpoint)
+		influxExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(influxExecHealthEndpoint)
 	}
 	var influxReadHealthEndpoint endpoint.Endpoint
 	{
 		influxReadHealthEndpoint = health.MakeReadInfluxHealthCheckEndpoint(healthComponent)
 		influxReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadInfluxHealthCheck"))(influxReadHealthEndpoint)
-		influxReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(influxReadHealthEndpoint)
+		influxReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadInfluxHealthCheck"), endpointHistogram.With("unit", "ReadInfluxHealthCheck"))(influxReadHealthEndpoint)
+		influxReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadInfluxHealthCheck")(influxReadHealthEndpoint)
+		influxReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(influxReadHealthEndpoint)
 	}
 	var jaegerExecHealthEndpoint endpoint.Endpoint
 	{
 		jaegerExecHealthEndpoint = health.MakeExecJaegerHealthCheckEndpoint(healthComponent)
 		jaegerExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecJaegerHealthCheck"))(jaegerExecHealthEndpoint)
-		jaegerExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(jaegerExecHealthEndpoint)
+		jaegerExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecJaegerHealthCheck"), endpointHistogram.With("unit", "ExecJaegerHealthCheck"))(jaegerExecHealthEndpoint)
+		jaegerExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecJaegerHealthCheck")(jaegerExecHealthEndpoint)
+		jaegerExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(jaegerExecHealthEndpoint)
 	}
 	var jaegerReadHealthEndpoint endpoint.Endpoint
 	{
 		jaegerReadHealthEndpoint = health.MakeReadJaegerHealthCheckEndpoint(healthComponent)
 		jaegerReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadJaegerHealthCheck"))(jaegerReadHealthEndpoint)
-		jaegerReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(jaegerReadHealthEndpoint)
+		jaegerReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadJaegerHealthCheck"), endpointHistogram.With("unit", "ReadJaegerHealthCheck"))(jaegerReadHealthEndpoint)
+		jaegerReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadJaegerHealthCheck")(jaegerReadHealthEndpoint)
+		jaegerReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(jaegerReadHealthEndpoint)
 	}
 	var redisExecHealthEndpoint endpoint.Endpoint
 	{
 		redisExecHealthEndpoint = health.MakeExecRedisHealthCheckEndpoint(healthComponent)
 		redisExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecRedisHealthCheck"))(redisExecHealthEndpoint)
-		redisExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(redisExecHealthEndpoint)
+		redisExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecRedisHealthCheck"), endpointHistogram.With("unit", "ExecRedisHealthCheck"))(redisExecHealthEndpoint)
+		redisExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecRedisHealthCheck")(redisExecHealthEndpoint)
+		redisExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(redisExecHealthEndpoint)
 	}
 	var redisReadHealthEndpoint endpoint.Endpoint
 	{
 		redisReadHealthEndpoint = health.MakeReadRedisHealthCheckEndpoint(healthComponent)
 		redisReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadRedisHealthCheck"))(redisReadHealthEndpoint)
-		redisReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(redisReadHealthEndpoint)
+		redisReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadRedisHealthCheck"), endpointHistogram.With("unit", "ReadRedisHealthCheck"))(redisReadHealthEndpoint)
+		redisReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadRedisHealthCheck")(redisReadHealthEndpoint)
+		redisReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(redisReadHealthEndpoint)
 	}
 	var sentryExecHealthEndpoint endpoint.Endpoint
 	{
 		sentryExecHealthEndpoint = health.MakeExecSentryHealthCheckEndpoint(healthComponent)
 		sentryExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecSentryHealthCheck"))(sentryExecHealthEndpoint)
-		sentryExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(sentryExecHealthEndpoint)
+		sentryExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecSentryHealthCheck"), endpointHistogram.With("unit", "ExecSentryHealthCheck"))(sentryExecHealthEndpoint)
+		sentryExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecSentryHealthCheck")(sentryExecHealthEndpoint)
+		sentryExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(sentryExecHealthEndpoint)
 	}
 	var sentryReadHealthEndpoint endpoint.Endpoint
 	{
 		sentryReadHealthEndpoint = health.MakeReadSentryHealthCheckEndpoint(healthComponent)
 		sentryReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadSentryHealthCheck"))(sentryReadHealthEndpoint)
-		sentryReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(sentryReadHealthEndpoint)
+		sentryReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadSentryHealthCheck"), endpointHistogram.With("unit", "ReadSentryHealthCheck"))(sentryReadHealthEndpoint)
+		sentryReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadSentryHealthCheck")(sentryReadHealthEndpoint)
+		sentryReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(sentryReadHealthEndpoint)
 	}
 	var flakiExecHealthEndpoint endpoint.Endpoint
 	{
 		flakiExecHealthEndpoint = health.MakeExecFlakiHealthCheckEndpoint(healthComponent)
 		flakiExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecFlakiHealthCheck"))(flakiExecHealthEndpoint)
-		flakiExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(flakiExecHealthEndpoint)
+		flakiExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecFlakiHealthCheck"), endpointHistogram.With("unit", "ExecFlakiHealthCheck"))(flakiExecHealthEndpoint)
+		flakiExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecFlakiHealthCheck")(flakiExecHealthEndpoint)
+		flakiExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(flakiExecHealthEndpoint)
 	}
 	var flakiReadHealthEndpoint endpoint.Endpoint
 	{
 		flakiReadHealthEndpoint = health.MakeReadFlakiHealthCheckEndpoint(healthComponent)
 		flakiReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadFlakiHealthCheck"))(flakiReadHealthEndpoint)
-		flakiReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(flakiReadHealthEndpoint)
+		flakiReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadFlakiHealthCheck"), endpointHistogram.With("unit", "ReadFlakiHealthCheck"))(flakiReadHealthEndpoint)
+		flakiReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadFlakiHealthCheck")(flakiReadHealthEndpoint)
+		flakiReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(flakiReadHealthEndpoint)
 	}
 	var elasticsearchExecHealthEndpoint endpoint.Endpoint
 	{
 		elasticsearchExecHealthEndpoint = health.MakeExecElasticsearchHealthCheckEndpoint(healthComponent)
 		elasticsearchExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecElasticsearchHealthCheck"))(elasticsearchExecHealthEndpoint)
-		elasticsearchExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(elasticsearchExecHealthEndpoint)
+		elasticsearchExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecElasticsearchHealthCheck"), endpointHistogram.With("unit", "ExecElasticsearchHealthCheck"))(elasticsearchExecHealthEndpoint)
+		elasticsearchExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecElasticsearchHealthCheck")(elasticsearchExecHealthEndpoint)
+		elasticsearchExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(elasticsearchExecHealthEndpoint)
 	}
 	var elasticsearchReadHealthEndpoint endpoint.Endpoint
 	{
 		elasticsearchReadHealthEndpoint = health.MakeReadElasticsearchHealthCheckEndpoint(healthComponent)
 		elasticsearchReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadElasticsearchHealthCheck"))(elasticsearchReadHealthEndpoint)
-		elasticsearchReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(elasticsearchReadHealthEndpoint)
+		elasticsearchReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadElasticsearchHealthCheck"), endpointHistogram.With("unit", "ReadElasticsearchHealthCheck"))(elasticsearchReadHealthEndpoint)
+		elasticsearchReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadElasticsearchHealthCheck")(elasticsearchReadHealthEndpoint)
+		elasticsearchReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(elasticsearchReadHealthEndpoint)
+	}
+	var cockroachExecHealthEndpoint endpoint.Endpoint
+	{
+		cockroachExecHealthEndpoint = health.MakeExecCockroachHealthCheckEndpoint(healthComponent)
+		cockroachExecHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ExecCockroachHealthCheck"))(cockroachExecHealthEndpoint)
+		cockroachExecHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ExecCockroachHealthCheck"), endpointHistogram.With("unit", "ExecCockroachHealthCheck"))(cockroachExecHealthEndpoint)
+		cockroachExecHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ExecCockroachHealthCheck")(cockroachExecHealthEndpoint)
+		cockroachExecHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(cockroachExecHealthEndpoint)
+	}
+	var cockroachReadHealthEndpoint endpoint.Endpoint
+	{
+		cockroachReadHealthEndpoint = health.MakeReadCockroachHealthCheckEndpoint(healthComponent)
+		cockroachReadHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "ReadCockroachHealthCheck"))(cockroachReadHealthEndpoint)
+		cockroachReadHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "ReadCockroachHealthCheck"), endpointHistogram.With("unit", "ReadCockroachHealthCheck"))(cockroachReadHealthEndpoint)
+		cockroachReadHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "ReadCockroachHealthCheck")(cockroachReadHealthEndpoint)
+		cockroachReadHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(cockroachReadHealthEndpoint)
 	}
 	var allHealthEndpoint endpoint.Endpoint
 	{
 		allHealthEndpoint = health.MakeAllHealthChecksEndpoint(healthComponent)
 		allHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "AllHealthCheck"))(allHealthEndpoint)
-		allHealthEndpoint = health.MakeEndpointCorrelationIDMW(flakiClient, tracer)(allHealthEndpoint)
+		allHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "AllHealthCheck"), endpointHistogram.With("unit", "AllHealthCheck"))(allHealthEndpoint)
+		allHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "AllHealthCheck")(allHealthEndpoint)
+		allHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(allHealthEndpoint)
+	}
+	var deepHealthEndpoint endpoint.Endpoint
+	{
+		deepHealthEndpoint = health.MakeDeepHealthChecksEndpoint(healthComponent)
+		deepHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "DeepHealthCheck"))(deepHealthEndpoint)
+		deepHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "DeepHealthCheck"), endpointHistogram.With("unit", "DeepHealthCheck"))(deepHealthEndpoint)
+		deepHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "DeepHealthCheck")(deepHealthEndpoint)
+		deepHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(deepHealthEndpoint)
+	}
+	var fleetHealthEndpoint endpoint.Endpoint
+	{
+		fleetHealthEndpoint = health.MakeFleetHealthChecksEndpoint(healthComponent)
+		fleetHealthEndpoint = health.MakeEndpointLoggingMW(log.With(healthLogger, "mw", "endpoint", "unit", "FleetHealthCheck"))(fleetHealthEndpoint)
+		fleetHealthEndpoint = health.MakeEndpointInstrumentingMW(endpointCounter.With("unit", "FleetHealthCheck"), endpointHistogram.With("unit", "FleetHealthCheck"))(fleetHealthEndpoint)
+		fleetHealthEndpoint = health.MakeEndpointErrorTrackingMW(errorTracker, "FleetHealthCheck")(fleetHealthEndpoint)
+		fleetHealthEndpoint = health.MakeEndpointCorrelationIDMW(idClient, tracer)(fleetHealthEndpoint)
 	}
 
 	var healthEndpoints = health.Endpoints{
@@ -473,19 +847,36 @@ func main() {
 		FlakiReadHealthCheck:         flakiReadHealthEndpoint,
 		ElasticsearchExecHealthCheck: elasticsearchExecHealthEndpoint,
 		ElasticsearchReadHealthCheck: elasticsearchReadHealthEndpoint,
+		CockroachExecHealthCheck:     cockroachExecHealthEndpoint,
+		CockroachReadHealthCheck:     cockroachReadHealthEndpoint,
 		AllHealthChecks:              allHealthEndpoint,
+		DeepHealthChecks:             deepHealthEndpoint,
+		FleetHealthChecks:            fleetHealthEndpoint,
+	}
+
+	// The jobs executions are traced and measured.
+	var jobTracingMW = health_job.MakeJobTracingMW(tracer, ComponentID)
+	var jobInstrumentingMW = health_job.MakeJobInstrumentingMW(jobCounter, jobHistogram)
+	var jobErrorTrackingMW = health_job.MakeJobErrorTrackingMW(errorTracker)
+
+	// The jobs status is stored in cockroach, or in the embedded store when cockroach is disabled.
+	var jobsOptions = []controller.Option{}
+	if cockroachEnabled {
+		jobsOptions = append(jobsOptions, controller.EnableStatusStorage(job_status.New(cJobsDB)))
+	} else {
+		jobsOptions = append(jobsOptions, controller.EnableStatusStorage(health_job.NewStatusStorage(jobsStore)))
 	}
 
 	// Local Jobs
 	{
-		var localCtrl = controller.NewController(ComponentName, ComponentID, &idGenerator{flakiClient}, &job_lock.NoopLocker{}, controller.EnableStatusStorage(job_status.New(cJobsDB)))
+		var localCtrl = controller.NewController(ComponentName, ComponentID, &idGenerator{idClient}, &job_lock.NoopLocker{}, jobsOptions...)
 
 		var influxJob *job.Job
 		{
 			var err error
-			influxJob, err = health_job.MakeInfluxJob(influxHM, healthChecksValidity[influxKey], cockroachModule)
+			influxJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeInfluxJob(influxHM, healthChecksValidity[influxKey], hysteresisModule))))
 			if err != nil {
-				logger.Log("msg", "could not create influx health job", "error", err)
+				level.Error(logger).Log("msg", "could not create influx health job", "error", err)
 				return
 			}
 			localCtrl.Register(influxJob)
@@ -495,9 +886,9 @@ func main() {
 		var jaegerJob *job.Job
 		{
 			var err error
-			jaegerJob, err = health_job.MakeJaegerJob(jaegerHM, healthChecksValidity[jaegerKey], cockroachModule)
+			jaegerJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeJaegerJob(jaegerHM, healthChecksValidity[jaegerKey], hysteresisModule))))
 			if err != nil {
-				logger.Log("msg", "could not create jaeger health job", "error", err)
+				level.Error(logger).Log("msg", "could not create jaeger health job", "error", err)
 				return
 			}
 			localCtrl.Register(jaegerJob)
@@ -507,9 +898,9 @@ func main() {
 		var redisJob *job.Job
 		{
 			var err error
-			redisJob, err = health_job.MakeRedisJob(redisHM, healthChecksValidity[redisKey], cockroachModule)
+			redisJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeRedisJob(redisHM, healthChecksValidity[redisKey], hysteresisModule))))
 			if err != nil {
-				logger.Log("msg", "could not create redis health job", "error", err)
+				level.Error(logger).Log("msg", "could not create redis health job", "error", err)
 				return
 			}
 			localCtrl.Register(redisJob)
@@ -519,9 +910,9 @@ func main() {
 		var sentryJob *job.Job
 		{
 			var err error
-			sentryJob, err = health_job.MakeSentryJob(sentryHM, healthChecksValidity[sentryKey], cockroachModule)
+			sentryJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeSentryJob(sentryHM, healthChecksValidity[sentryKey], hysteresisModule))))
 			if err != nil {
-				logger.Log("msg", "could not create sentry health job", "error", err)
+				level.Error(logger).Log("msg", "could not create sentry health job", "error", err)
 				return
 			}
 			localCtrl.Register(sentryJob)
@@ -531,9 +922,9 @@ func main() {
 		var flakiJob *job.Job
 		{
 			var err error
-			flakiJob, err = health_job.MakeFlakiJob(flakiHM, healthChecksValidity[flakiKey], cockroachModule)
+			flakiJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeFlakiJob(flakiHM, healthChecksValidity[flakiKey], hysteresisModule))))
 			if err != nil {
-				logger.Log("msg", "could not create flaki health job", "error", err)
+				level.Error(logger).Log("msg", "could not create flaki health job", "error", err)
 				return
 			}
 			localCtrl.Register(flakiJob)
@@ -543,21 +934,33 @@ func main() {
 		var elasticsearchJob *job.Job
 		{
 			var err error
-			elasticsearchJob, err = health_job.MakeElasticsearchJob(elasticsearchHM, healthChecksValidity[elasticsearchKey], cockroachModule)
+			elasticsearchJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeElasticsearchJob(elasticsearchHM, healthChecksValidity[elasticsearchKey], hysteresisModule))))
 			if err != nil {
-				logger.Log("msg", "could not create elasticsearch health job", "error", err)
+				level.Error(logger).Log("msg", "could not create elasticsearch health job", "error", err)
 				return
 			}
 			localCtrl.Register(elasticsearchJob)
 			localCtrl.Schedule("@minutely", elasticsearchJob.Name())
 		}
 
+		var cockroachJob *job.Job
+		{
+			var err error
+			cockroachJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeCockroachJob(cockroachHM, healthChecksValidity[cockroachKey], hysteresisModule))))
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create cockroach health job", "error", err)
+				return
+			}
+			localCtrl.Register(cockroachJob)
+			localCtrl.Schedule("@minutely", cockroachJob.Name())
+		}
+
 		var cleanHealthChecksJob *job.Job
 		{
 			var err error
-			cleanHealthChecksJob, err = health_job.MakeCleanCockroachJob(cockroachModule, log.With(logger, "job", "clean health checks"))
+			cleanHealthChecksJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeCleanCockroachJob(storageModule, log.With(logger, "job", "clean health checks")))))
 			if err != nil {
-				logger.Log("msg", "could not create clean health checks job", "error", err)
+				level.Error(logger).Log("msg", "could not create clean health checks job", "error", err)
 				return
 			}
 			localCtrl.Register(cleanHealthChecksJob)
@@ -570,27 +973,46 @@ func main() {
 
 	// Distributed Jobs
 	{
-		var distributedCtrl = controller.NewController(ComponentName, ComponentID, &idGenerator{flakiClient}, job_lock.New(cJobsDB), controller.EnableStatusStorage(job_status.New(cJobsDB)))
+		// Without cockroach, the distributed jobs are locked in the embedded store. It is not
+		// shared, so the lock only guards the executions of this instance.
+		var distributedCtrl *controller.Controller
+		if cockroachEnabled {
+			distributedCtrl = controller.NewController(ComponentName, ComponentID, &idGenerator{idClient}, job_lock.New(cJobsDB), jobsOptions...)
+		} else {
+			distributedCtrl = controller.NewController(ComponentName, ComponentID, &idGenerator{idClient}, health_job.NewLock(jobsStore), jobsOptions...)
+		}
 
 		var cleanElasticIndexesJob *job.Job
 		{
 			var err error
-			cleanElasticIndexesJob, err = health_job.MakeElasticsearchCleanIndexJob(elasticsearchClient, elasticsearchIndexExpiration)
+			cleanElasticIndexesJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeElasticsearchCleanIndexJob(elasticsearchClient, elasticsearchIndexExpiration))))
 			if err != nil {
-				logger.Log("msg", "could not create clean elastic indexes job", "error", err)
+				level.Error(logger).Log("msg", "could not create clean elastic indexes job", "error", err)
 				return
 			}
 			distributedCtrl.Register(cleanElasticIndexesJob)
 			distributedCtrl.Schedule(fmt.Sprintf("@every %s", elasticsearchIndexCleanInterval), cleanElasticIndexesJob.Name())
 		}
 
+		var cleanProbeIndexesJob *job.Job
+		{
+			var err error
+			cleanProbeIndexesJob, err = jobInstrumentingMW(jobTracingMW(jobErrorTrackingMW(health_job.MakeElasticsearchCleanProbeIndexJob(elasticsearchClient, elasticsearchThresholds.ProbeIndexMaxAge))))
+			if err != nil {
+				level.Error(logger).Log("msg", "could not create clean probe indexes job", "error", err)
+				return
+			}
+			distributedCtrl.Register(cleanProbeIndexesJob)
+			distributedCtrl.Schedule(fmt.Sprintf("@every %s", elasticsearchProbeCleanInterval), cleanProbeIndexesJob.Name())
+		}
+
 		distributedCtrl.Start()
 	}
 
 	// HTTP server.
 	go func() {
 		var logger = log.With(logger, "transport", "http")
-		logger.Log("addr", httpAddr)
+		level.Info(logger).Log("addr", httpAddr)
 
 		var route = mux.NewRouter()
 
@@ -600,78 +1022,119 @@ func main() {
 		// Health checks.
 		var healthSubroute = route.PathPrefix("/health").Subrouter()
 
-		var allHealthChecksHandler = health.MakeHealthCheckHandler(healthEndpoints.AllHealthChecks)
-		healthSubroute.Handle("", allHealthChecksHandler)
+		var allHealthChecksHandler = health.MakeHealthCheckHandler(healthEndpoints.AllHealthChecks, tracer)
+		healthSubroute.Handle("", allHealthChecksHandler).Methods("GET")
+		healthSubroute.Handle("", health.MakeHealthCheckHandler(healthEndpoints.DeepHealthChecks, tracer)).Methods("POST")
+
+		healthSubroute.Handle("/influx", health.MakeHealthCheckHandler(healthEndpoints.InfluxReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/influx", health.MakeHealthCheckHandler(healthEndpoints.InfluxExecHealthCheck, tracer)).Methods("POST")
+
+		healthSubroute.Handle("/jaeger", health.MakeHealthCheckHandler(healthEndpoints.JaegerReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/jaeger", health.MakeHealthCheckHandler(healthEndpoints.JaegerExecHealthCheck, tracer)).Methods("POST")
+
+		healthSubroute.Handle("/redis", health.MakeHealthCheckHandler(healthEndpoints.RedisReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/redis", health.MakeHealthCheckHandler(healthEndpoints.RedisExecHealthCheck, tracer)).Methods("POST")
 
-		healthSubroute.Handle("/influx", health.MakeHealthCheckHandler(healthEndpoints.InfluxReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/influx", health.MakeHealthCheckHandler(healthEndpoints.InfluxExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/sentry", health.MakeHealthCheckHandler(healthEndpoints.SentryReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/sentry", health.MakeHealthCheckHandler(healthEndpoints.SentryExecHealthCheck, tracer)).Methods("POST")
 
-		healthSubroute.Handle("/jaeger", health.MakeHealthCheckHandler(healthEndpoints.JaegerReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/jaeger", health.MakeHealthCheckHandler(healthEndpoints.JaegerExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/flaki", health.MakeHealthCheckHandler(healthEndpoints.FlakiReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/flaki", health.MakeHealthCheckHandler(healthEndpoints.FlakiExecHealthCheck, tracer)).Methods("POST")
 
-		healthSubroute.Handle("/redis", health.MakeHealthCheckHandler(healthEndpoints.RedisReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/redis", health.MakeHealthCheckHandler(healthEndpoints.RedisExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/elasticsearch", health.MakeHealthCheckHandler(healthEndpoints.ElasticsearchReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/elasticsearch", health.MakeHealthCheckHandler(healthEndpoints.ElasticsearchExecHealthCheck, tracer)).Methods("POST")
 
-		healthSubroute.Handle("/sentry", health.MakeHealthCheckHandler(healthEndpoints.SentryReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/sentry", health.MakeHealthCheckHandler(healthEndpoints.SentryExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/cockroach", health.MakeHealthCheckHandler(healthEndpoints.CockroachReadHealthCheck, tracer)).Methods("GET")
+		healthSubroute.Handle("/cockroach", health.MakeHealthCheckHandler(healthEndpoints.CockroachExecHealthCheck, tracer)).Methods("POST")
 
-		healthSubroute.Handle("/flaki", health.MakeHealthCheckHandler(healthEndpoints.FlakiReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/flaki", health.MakeHealthCheckHandler(healthEndpoints.FlakiExecHealthCheck)).Methods("POST")
+		healthSubroute.Handle("/fleet", health.MakeHealthCheckHandler(healthEndpoints.FleetHealthChecks, tracer)).Methods("GET")
 
-		healthSubroute.Handle("/elasticsearch", health.MakeHealthCheckHandler(healthEndpoints.ElasticsearchReadHealthCheck)).Methods("GET")
-		healthSubroute.Handle("/elasticsearch", health.MakeHealthCheckHandler(healthEndpoints.ElasticsearchExecHealthCheck)).Methods("POST")
+		// Metrics.
+		if prometheusEnabled {
+			route.Handle("/metrics", prometheusMetrics).Methods("GET")
+		}
 
 		// Debug.
+		var debugSubroute = route.PathPrefix("/debug").Subrouter()
 		if pprofRouteEnabled {
-			var debugSubroute = route.PathPrefix("/debug").Subrouter()
 			debugSubroute.HandleFunc("/pprof/", http.HandlerFunc(pprof.Index))
 			debugSubroute.HandleFunc("/pprof/cmdline", http.HandlerFunc(pprof.Cmdline))
 			debugSubroute.HandleFunc("/pprof/profile", http.HandlerFunc(pprof.Profile))
 			debugSubroute.HandleFunc("/pprof/symbol", http.HandlerFunc(pprof.Symbol))
 			debugSubroute.HandleFunc("/pprof/trace", http.HandlerFunc(pprof.Trace))
 		}
+		if logLevelRouteEnabled {
+			debugSubroute.Handle("/loglevel", loglevel.MakeHandler(logLevels, logLevelTTL, log.With(logger, "unit", "loglevel"))).Methods("PUT")
+		}
 
 		errc <- http.ListenAndServe(httpAddr, route)
 	}()
 
-	// Influx writing.
+	// gRPC health server.
+	if grpcHealthAddr != "" {
+		go func() {
+			var logger = log.With(logger, "transport", "grpc")
+			level.Info(logger).Log("addr", grpcHealthAddr)
+
+			var lis, err = net.Listen("tcp", grpcHealthAddr)
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			var units = []string{influxKey, jaegerKey, redisKey, sentryKey, flakiKey, elasticsearchKey, cockroachKey}
+			var grpcServer = grpc.NewServer()
+			healthpb.RegisterHealthServer(grpcServer, health.NewGRPCHealthServer(watchModule, units))
+
+			errc <- grpcServer.Serve(lis)
+		}()
+	}
+
+	// Metrics writing.
 	go func() {
 		var tic = time.NewTicker(influxWriteInterval)
 		defer tic.Stop()
-		influxMetrics.WriteLoop(tic.C)
+		metricsBackend.WriteLoop(tic.C)
 	}()
 
 	// Redis writing.
 	if redisEnabled {
-		go func() {
-			var tic = time.NewTicker(redisWriteInterval)
-			defer tic.Stop()
-			for range tic.C {
-				redisClient.Flush()
-			}
-		}()
+		var sentCounter = metricsBackend.NewCounter("redis_log_lines_sent", "component_id").With("component_id", ComponentID)
+		var droppedCounter = metricsBackend.NewCounter("redis_log_lines_dropped", "component_id").With("component_id", ComponentID)
+		go redisShipper.Run(sentCounter, droppedCounter)
+	}
+
+	// Flaki IDs prefetching.
+	{
+		var depthGauge = metricsBackend.NewGauge("flaki_pool_depth", "component_id").With("component_id", ComponentID)
+		var latencyHistogram = metricsBackend.NewHistogram("flaki_call_duration_seconds", "component_id").With("component_id", ComponentID)
+		go flakiClient.Run(depthGauge, latencyHistogram)
+	}
+
+	// Elasticsearch log indexing.
+	if logElasticsearchEnabled {
+		var indexedCounter = metricsBackend.NewCounter("elasticsearch_log_lines_indexed", "component_id").With("component_id", ComponentID)
+		var droppedCounter = metricsBackend.NewCounter("elasticsearch_log_lines_dropped", "component_id").With("component_id", ComponentID)
+		go elasticsearchSink.Run(indexedCounter, droppedCounter)
+	}
+	level.Error(logger).Log("error", <-errc)
+	flakiClient.Close()
+
+	// Final flush of the logs to Redis and Elasticsearch.
+	if redisEnabled {
+		redisShipper.Close()
+	}
+	if logElasticsearchEnabled {
+		elasticsearchSink.Close()
 	}
-	logger.Log("error", <-errc)
 }
 
 type idGenerator struct {
-	flaki fb_flaki.FlakiClient
+	flaki *flaki.FallbackClient
 }
 
 func (g *idGenerator) NextID() string {
-	var b = flatbuffers.NewBuilder(0)
-	fb_flaki.FlakiRequestStart(b)
-	b.Finish(fb_flaki.FlakiRequestEnd(b))
-
-	var reply, err = g.flaki.NextValidID(context.Background(), b)
-
-	// If we cannot get ID from Flaki, we generate a random one.
-	if err != nil {
-		rand.Seed(time.Now().UnixNano())
-		return "degraded-" + strconv.FormatUint(rand.Uint64(), 10)
-	}
-
-	return string(reply.Id())
+	return g.flaki.NextValidID(context.Background())
 }
 
 type info struct {
@@ -705,22 +1168,68 @@ func makeVersion(componentName, componentID, version, environment, gitCommit str
 	}
 }
 
+// hysteresisConfig returns the hysteresis configuration of the health checks of a unit.
+func hysteresisConfig(v *viper.Viper, unit string) health.HysteresisConfig {
+	return health.HysteresisConfig{
+		ConsecutiveResults: v.GetInt(fmt.Sprintf("job-%s-health-hysteresis-results", unit)),
+		MinDuration:        v.GetDuration(fmt.Sprintf("job-%s-health-hysteresis-duration", unit)),
+		FlapWindow:         v.GetDuration(fmt.Sprintf("job-%s-health-flap-window", unit)),
+		FlapThreshold:      v.GetInt(fmt.Sprintf("job-%s-health-flap-threshold", unit)),
+	}
+}
+
+// splitAddrs returns the addresses of a comma separated list.
+func splitAddrs(list string) []string {
+	var addrs = []string{}
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
 func config(logger log.Logger) *viper.Viper {
-	logger.Log("msg", "load configuration and command args")
+	level.Info(logger).Log("msg", "load configuration and command args")
 
 	var v = viper.New()
 
 	// Component default.
 	v.SetDefault("config-file", "./configs/elasticsearch_bridge.yml")
 	v.SetDefault("component-http-host-port", "0.0.0.0:8888")
+	v.SetDefault("component-grpc-health-host-port", "")
 
 	// ElasticSearch
 	v.SetDefault("elasticsearch-host-port", "")
 	v.SetDefault("elasticsearch-index-clean-interval", "24h")
 	v.SetDefault("elasticsearch-index-expiration", "24h")
+	v.SetDefault("elasticsearch-health-index", "elasticsearch-bridge-health")
+	v.SetDefault("elasticsearch-probe-index-clean-interval", "1h")
+	v.SetDefault("elasticsearch-probe-index-max-age", "1h")
+	v.SetDefault("elasticsearch-health-jvm-heap-degraded", 75)
+	v.SetDefault("elasticsearch-health-jvm-heap-ko", 90)
+	v.SetDefault("elasticsearch-health-pending-tasks", 50)
+	v.SetDefault("elasticsearch-health-pending-tasks-max-wait", "30s")
+	v.SetDefault("elasticsearch-health-shards-per-node-ratio", 0.85)
+	v.SetDefault("elasticsearch-health-expected-data-nodes", 0)
+	v.SetDefault("elasticsearch-health-search-visibility-timeout", "5s")
+	v.SetDefault("elasticsearch-health-check-timeout", "10s")
 
 	// Flaki
 	v.SetDefault("flaki-host-port", "")
+	v.SetDefault("flaki-tls", false)
+	v.SetDefault("flaki-tls-ca-file", "")
+	v.SetDefault("flaki-tls-cert-file", "")
+	v.SetDefault("flaki-tls-key-file", "")
+	v.SetDefault("flaki-tls-server-name", "")
+	v.SetDefault("flaki-keepalive-time", "0s")
+	v.SetDefault("flaki-keepalive-timeout", "20s")
+	v.SetDefault("flaki-fallback-node-id", -1)
+	v.SetDefault("flaki-probe-interval", "5s")
+	v.SetDefault("flaki-pool-size", 100)
+	v.SetDefault("flaki-timeout", "500ms")
+	v.SetDefault("flaki-retries", 1)
+	v.SetDefault("flaki-retry-backoff", "100ms")
 
 	// Influx DB client default.
 	v.SetDefault("influx", false)
@@ -733,9 +1242,16 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("influx-write-consistency", "")
 	v.SetDefault("influx-write-interval", 1000)
 
+	// Prometheus default.
+	v.SetDefault("prometheus", false)
+	v.SetDefault("prometheus-namespace", "elasticsearch_bridge")
+	v.SetDefault("prometheus-scrape-max-age", "1m")
+
 	// Sentry client default.
 	v.SetDefault("sentry", false)
 	v.SetDefault("sentry-dsn", "")
+	v.SetDefault("sentry-dedup-window", "5m")
+	v.SetDefault("sentry-max-errors-per-minute", 60)
 
 	// Jaeger tracing default.
 	v.SetDefault("jaeger", false)
@@ -746,6 +1262,19 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("jaeger-write-interval", "1s")
 	v.SetDefault("jaeger-collector-healthcheck-host-port", "")
 
+	// Tracing exporter default.
+	v.SetDefault("tracing-exporter", "jaeger")
+	v.SetDefault("tracing-resource-attributes", map[string]string{})
+	v.SetDefault("otlp-protocol", "grpc")
+	v.SetDefault("otlp-endpoint", "")
+	v.SetDefault("otlp-timeout", "5s")
+	v.SetDefault("otlp-batch-size", 100)
+	v.SetDefault("otlp-tls", false)
+	v.SetDefault("otlp-tls-ca-file", "")
+	v.SetDefault("otlp-tls-cert-file", "")
+	v.SetDefault("otlp-tls-key-file", "")
+	v.SetDefault("otlp-tls-server-name", "")
+
 	// Debug routes enabled.
 	v.SetDefault("pprof-route-enabled", true)
 
@@ -756,6 +1285,38 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("redis-database", 0)
 	v.SetDefault("redis-database", 0)
 	v.SetDefault("redis-write-interval", "1s")
+	v.SetDefault("redis-queue-size", 10000)
+	v.SetDefault("redis-batch-size", 100)
+	v.SetDefault("redis-drop-policy", "drop-oldest")
+	v.SetDefault("redis-reconnect-min-backoff", "1s")
+	v.SetDefault("redis-reconnect-max-backoff", "30s")
+
+	// Log levels.
+	v.SetDefault("log-level", "info")
+	v.SetDefault("log-unit-levels", map[string]string{})
+	v.SetDefault("log-svc-levels", map[string]string{})
+	v.SetDefault("log-level-ttl", "15m")
+	v.SetDefault("log-level-route-enabled", true)
+
+	// Log formats.
+	v.SetDefault("log-stdout-format", "json")
+	v.SetDefault("log-redis-format", "logstash-v1")
+
+	// Elasticsearch log sink.
+	v.SetDefault("log-elasticsearch", false)
+	v.SetDefault("log-elasticsearch-format", "ecs")
+	v.SetDefault("log-elasticsearch-index-prefix", "elasticsearch-bridge")
+	v.SetDefault("log-elasticsearch-queue-size", 10000)
+	v.SetDefault("log-elasticsearch-batch-size", 500)
+	v.SetDefault("log-elasticsearch-write-interval", "1s")
+	v.SetDefault("log-elasticsearch-timeout", "10s")
+	v.SetDefault("log-elasticsearch-retry-min-backoff", "1s")
+	v.SetDefault("log-elasticsearch-retry-max-backoff", "30s")
+
+	// Logstash.
+	v.SetDefault("logstash-timestamp-key", "ts")
+	v.SetDefault("logstash-message-key", "msg")
+	v.SetDefault("logstash-field-mapping", map[string]string{})
 
 	// Cockroach.
 	v.SetDefault("cockroach", false)
@@ -765,12 +1326,33 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetDefault("cockroach-health-database", "")
 	v.SetDefault("cockroach-jobs-database", "")
 	v.SetDefault("cockroach-clean-interval", "24h")
+	v.SetDefault("cockroach-health-latency-degraded", "500ms")
+
+	// Embedded health storage: memory or bolt.
+	v.SetDefault("health-storage", "memory")
+	v.SetDefault("health-storage-bolt-path", "./elasticsearch_bridge.db")
+	v.SetDefault("health-gone-retention", "1h")
 
 	// Jobs
 	v.SetDefault("job-influx-health-validity", "1m")
 	v.SetDefault("job-jaeger-health-validity", "1m")
 	v.SetDefault("job-redis-health-validity", "1m")
 	v.SetDefault("job-sentry-health-validity", "1m")
+	v.SetDefault("job-cockroach-health-validity", "1m")
+
+	// Health checks timeouts.
+	for _, unit := range []string{influxKey, jaegerKey, redisKey, sentryKey, flakiKey, elasticsearchKey, cockroachKey} {
+		v.SetDefault(fmt.Sprintf("health-%s-timeout", unit), "30s")
+	}
+	v.SetDefault("health-deep-timeout", "45s")
+
+	// Health checks hysteresis and flap detection.
+	for _, unit := range []string{influxKey, jaegerKey, redisKey, sentryKey, flakiKey, elasticsearchKey, cockroachKey} {
+		v.SetDefault(fmt.Sprintf("job-%s-health-hysteresis-results", unit), 1)
+		v.SetDefault(fmt.Sprintf("job-%s-health-hysteresis-duration", unit), "0s")
+		v.SetDefault(fmt.Sprintf("job-%s-health-flap-window", unit), "10m")
+		v.SetDefault(fmt.Sprintf("job-%s-health-flap-threshold", unit), 0)
+	}
 
 	// First level of override.
 	pflag.String("config-file", v.GetString("config-file"), "The configuration file path can be relative or absolute.")
@@ -781,13 +1363,17 @@ func config(logger log.Logger) *viper.Viper {
 	v.SetConfigFile(v.GetString("config-file"))
 	var err = v.ReadInConfig()
 	if err != nil {
-		logger.Log("error", err)
+		level.Error(logger).Log("error", err)
 	}
 
 	// If the host/port is not set, we consider the components deactivated.
 	v.Set("influx", v.GetString("influx-host-port") != "")
 	v.Set("sentry", v.GetString("sentry-dsn") != "")
-	v.Set("jaeger", v.GetString("jaeger-sampler-host-port") != "")
+	if v.GetString("tracing-exporter") == elasticsearch_bridge.OTLPExporter {
+		v.Set("jaeger", v.GetString("otlp-endpoint") != "")
+	} else {
+		v.Set("jaeger", v.GetString("jaeger-sampler-host-port") != "")
+	}
 	v.Set("redis", v.GetString("redis-host-port") != "")
 	v.Set("cockroach", v.GetString("cockroach-host-port") != "")
 
@@ -796,7 +1382,7 @@ func config(logger log.Logger) *viper.Viper {
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		logger.Log(k, v.Get(k))
+		level.Info(logger).Log(k, v.Get(k))
 	}
 
 	return v
@@ -0,0 +1,31 @@
+// Package correlation carries the correlation ID of the requests in their context.
+package correlation
+
+import "context"
+
+// Header is the HTTP header carrying the correlation ID.
+const Header = "X-Correlation-ID"
+
+// key is the context key of the correlation ID.
+type key struct{}
+
+// legacyKey is the raw string key of the correlation ID. The common-healthcheck modules
+// and go-jobs read and write the correlation ID with it, so it is still set alongside the
+// typed key.
+const legacyKey = "correlation_id"
+
+// WithID returns a copy of the context carrying the correlation ID.
+func WithID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, key{}, id)
+	return context.WithValue(ctx, legacyKey, id)
+}
+
+// ID returns the correlation ID of the context. The ID set with the legacy key, e.g. by
+// go-jobs in the context of the job steps, is returned if there is none set with WithID.
+func ID(ctx context.Context) (string, bool) {
+	if id, ok := ctx.Value(key{}).(string); ok {
+		return id, true
+	}
+	var id, ok = ctx.Value(legacyKey).(string)
+	return id, ok
+}
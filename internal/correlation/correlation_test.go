@@ -0,0 +1,43 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelationID(t *testing.T) {
+	// No correlation ID.
+	{
+		var _, ok = ID(context.Background())
+		assert.False(t, ok)
+	}
+
+	// Typed key.
+	{
+		var ctx = WithID(context.Background(), "123")
+		var id, ok = ID(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "123", id)
+
+		// The legacy key is set for the libraries that use it.
+		assert.Equal(t, "123", ctx.Value("correlation_id"))
+	}
+
+	// Legacy key.
+	{
+		var ctx = context.WithValue(context.Background(), "correlation_id", "456")
+		var id, ok = ID(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "456", id)
+	}
+
+	// The typed key wins.
+	{
+		var ctx = WithID(context.Background(), "123")
+		ctx = context.WithValue(ctx, "correlation_id", "456")
+		var id, _ = ID(ctx)
+		assert.Equal(t, "123", id)
+	}
+}
@@ -1,21 +1,35 @@
 package elasticsearch_bridge
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	net_url "net/url"
 	"time"
 
+	"github.com/pkg/errors"
 	"gopkg.in/h2non/gentleman.v2"
+	gentleman_context "gopkg.in/h2non/gentleman.v2/context"
 	"gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+	"gopkg.in/h2non/gentleman.v2/plugins/headers"
 	"gopkg.in/h2non/gentleman.v2/plugins/query"
 	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
 	"gopkg.in/h2non/gentleman.v2/plugins/url"
 )
 
 const (
-	listIndexes = "/_cat/indices"
-	health      = "/_cluster/health"
+	listIndexes     = "/_cat/indices"
+	health          = "/_cluster/health"
+	nodesStats      = "/_nodes/stats/fs,jvm"
+	clusterSettings = "/_cluster/settings"
+	catAllocation   = "/_cat/allocation"
+	indexesSettings = "/_all/_settings/"
+	document        = "/%s/_doc/%s"
+	search          = "/%s/_search"
+	refresh         = "/%s/_refresh"
+	bulk            = "/_bulk"
 )
 
 type Config struct {
@@ -24,9 +38,54 @@ type Config struct {
 }
 
 type Client struct {
-	httpClient *gentleman.Client
+	httpClient  *gentleman.Client
+	middlewares []ElasticsearchMiddleware
 }
 
+// ElasticsearchRequest is the description of a request to Elasticsearch given to the
+// middlewares. The operation is the name of the client method, e.g. list_indexes. The
+// header is sent with the request.
+type ElasticsearchRequest struct {
+	Operation string
+	Method    string
+	Path      string
+	Header    http.Header
+}
+
+// ElasticsearchResponse is the description of the response of Elasticsearch given to the
+// middlewares. The status code is 0 if there is no response, and the error type is the
+// type of the Elasticsearch error, e.g. index_not_found_exception.
+type ElasticsearchResponse struct {
+	StatusCode int
+	ErrorType  string
+}
+
+// ElasticsearchError is the error returned when Elasticsearch answers with an error status
+// code. The type is the type of the Elasticsearch error, e.g. index_not_found_exception.
+type ElasticsearchError struct {
+	StatusCode int
+	Status     string
+	Type       string
+	Body       string
+}
+
+func (e *ElasticsearchError) Error() string {
+	return fmt.Sprintf("invalid status code: '%v': %v", e.Status, e.Body)
+}
+
+// IsNotFound returns true if the error is a 404 response of Elasticsearch, e.g. the index
+// does not exist.
+func IsNotFound(err error) bool {
+	var e, ok = errors.Cause(err).(*ElasticsearchError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// ElasticsearchRequestFunc sends a request to Elasticsearch.
+type ElasticsearchRequestFunc func(context.Context, ElasticsearchRequest) (ElasticsearchResponse, error)
+
+// ElasticsearchMiddleware is a middleware around the requests sent to Elasticsearch.
+type ElasticsearchMiddleware func(ElasticsearchRequestFunc) ElasticsearchRequestFunc
+
 type IndexSettingsRepresentation struct {
 	CreationDate     time.Time `json:"creation_date"`
 	NumberOfShards   int       `json:"number_of_shards"`
@@ -57,7 +116,95 @@ type HealthRepresentation struct {
 	ActiveShardsPercentAsNumber float64 `json:"active_shards_percent_as_number"`
 }
 
-func New(config Config) (*Client, error) {
+type NodesStatsRepresentation struct {
+	Nodes map[string]NodeStatsRepresentation `json:"nodes"`
+}
+
+type NodeStatsRepresentation struct {
+	Name  string                `json:"name"`
+	Roles []string              `json:"roles"`
+	FS    NodeFSRepresentation  `json:"fs"`
+	JVM   NodeJVMRepresentation `json:"jvm"`
+}
+
+type NodeFSRepresentation struct {
+	Total struct {
+		TotalInBytes     int64 `json:"total_in_bytes"`
+		FreeInBytes      int64 `json:"free_in_bytes"`
+		AvailableInBytes int64 `json:"available_in_bytes"`
+	} `json:"total"`
+}
+
+type NodeJVMRepresentation struct {
+	Mem struct {
+		HeapUsedInBytes int64 `json:"heap_used_in_bytes"`
+		HeapUsedPercent int   `json:"heap_used_percent"`
+		HeapMaxInBytes  int64 `json:"heap_max_in_bytes"`
+	} `json:"mem"`
+}
+
+type ClusterSettingsRepresentation struct {
+	Persistent map[string]interface{} `json:"persistent"`
+	Transient  map[string]interface{} `json:"transient"`
+	Defaults   map[string]interface{} `json:"defaults"`
+}
+
+// Setting returns the effective value of a cluster setting, i.e. the transient value,
+// else the persistent value, else the default value.
+func (r ClusterSettingsRepresentation) Setting(key string) string {
+	for _, settings := range []map[string]interface{}{r.Transient, r.Persistent, r.Defaults} {
+		if v, ok := settings[key]; ok {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+type AllocationRepresentation struct {
+	Shards      string `json:"shards"`
+	DiskPercent string `json:"disk.percent"`
+	Host        string `json:"host"`
+	IP          string `json:"ip"`
+	Node        string `json:"node"`
+}
+
+type IndexSettingRepresentation struct {
+	Settings map[string]string `json:"settings"`
+}
+
+type DocumentRepresentation struct {
+	Index  string          `json:"_index"`
+	ID     string          `json:"_id"`
+	Found  bool            `json:"found"`
+	Source json.RawMessage `json:"_source"`
+}
+
+type SearchRepresentation struct {
+	Took     int  `json:"took"`
+	TimedOut bool `json:"timed_out"`
+	Hits     struct {
+		Hits []DocumentRepresentation `json:"hits"`
+	} `json:"hits"`
+}
+
+// BulkRepresentation is the response of the bulk API. Errors is true if at least one of
+// the actions failed.
+type BulkRepresentation struct {
+	Took   int                                 `json:"took"`
+	Errors bool                                `json:"errors"`
+	Items  []map[string]BulkItemRepresentation `json:"items"`
+}
+
+// BulkItemRepresentation is the result of an action of a bulk request.
+type BulkItemRepresentation struct {
+	Index  string          `json:"_index"`
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// New returns the Elasticsearch client. The requests go through the middlewares, the
+// first one being the outermost.
+func New(config Config, middlewares ...ElasticsearchMiddleware) (*Client, error) {
 	var u *net_url.URL
 	{
 		var err error
@@ -74,130 +221,183 @@ func New(config Config) (*Client, error) {
 	}
 
 	return &Client{
-		httpClient: httpClient,
+		httpClient:  httpClient,
+		middlewares: middlewares,
 	}, nil
 }
 
-func (c *Client) Health() (HealthRepresentation, error) {
+func (c *Client) Health(ctx context.Context) (HealthRepresentation, error) {
 	var resp = HealthRepresentation{}
-	var err = c.get(&resp, url.Path(health))
-
+	var err = c.get(ctx, "health", health, &resp)
 	return resp, err
 }
 
-func (c *Client) ListIndexes() ([]IndexRepresentation, error) {
+func (c *Client) ListIndexes(ctx context.Context) ([]IndexRepresentation, error) {
 	var resp []IndexRepresentation
-	var plugins = createQueryPlugins("format", "json")
-	plugins = append(plugins, url.Path(listIndexes))
-	var err = c.get(&resp, plugins...)
+	var err = c.get(ctx, "list_indexes", listIndexes, &resp, createQueryPlugins("format", "json")...)
 	return resp, err
 }
 
-func (c *Client) GetIndex(indexName string) (IndexSettingsRepresentation, error) {
+// NodesStats returns the file system and JVM statistics of the nodes.
+func (c *Client) NodesStats(ctx context.Context) (NodesStatsRepresentation, error) {
+	var resp = NodesStatsRepresentation{}
+	var err = c.get(ctx, "nodes_stats", nodesStats, &resp)
+	return resp, err
+}
+
+// ClusterSettings returns the cluster settings, including the default values, in flat format.
+func (c *Client) ClusterSettings(ctx context.Context) (ClusterSettingsRepresentation, error) {
+	var resp = ClusterSettingsRepresentation{}
+	var err = c.get(ctx, "cluster_settings", clusterSettings, &resp, createQueryPlugins("include_defaults", "true", "flat_settings", "true")...)
+	return resp, err
+}
+
+// Allocation returns the number of shards and the disk usage of each node.
+func (c *Client) Allocation(ctx context.Context) ([]AllocationRepresentation, error) {
+	var resp []AllocationRepresentation
+	var err = c.get(ctx, "allocation", catAllocation, &resp, createQueryPlugins("format", "json")...)
+	return resp, err
+}
+
+// GetIndexesSetting returns the value of the setting for all indexes, in flat format.
+func (c *Client) GetIndexesSetting(ctx context.Context, setting string) (map[string]IndexSettingRepresentation, error) {
+	var resp = map[string]IndexSettingRepresentation{}
+	var err = c.get(ctx, "get_indexes_setting", indexesSettings+setting, &resp, createQueryPlugins("flat_settings", "true")...)
+	return resp, err
+}
+
+func (c *Client) GetIndex(ctx context.Context, indexName string) (IndexSettingsRepresentation, error) {
 	var resp = IndexSettingsRepresentation{}
-	var err = c.get(&resp, url.Path(indexName))
+	var err = c.get(ctx, "get_index", indexName, &resp)
 	return resp, err
 }
 
-func (c *Client) CreateIndex(indexName string) error {
-	return c.put(url.Path("/" + indexName))
+func (c *Client) CreateIndex(ctx context.Context, indexName string) error {
+	return c.put(ctx, "create_index", "/"+indexName)
 }
 
-func (c *Client) DeleteIndex(indexName string) error {
-	return c.delete(url.Path(indexName))
+func (c *Client) DeleteIndex(ctx context.Context, indexName string) error {
+	return c.delete(ctx, "delete_index", indexName)
 }
 
-// get is a HTTP get method.
-func (c *Client) get(data interface{}, plugins ...plugin.Plugin) error {
-	var req = c.httpClient.Get()
-	req = applyPlugins(req, "", plugins...)
+// IndexDocument creates or replaces the document with the given ID.
+func (c *Client) IndexDocument(ctx context.Context, indexName, id string, doc interface{}) error {
+	return c.put(ctx, "index_document", fmt.Sprintf(document, indexName, id), body.JSON(doc))
+}
 
-	var resp *gentleman.Response
-	{
-		var err error
-		resp, err = req.Do()
-		if err != nil {
-			return fmt.Errorf("could not get response: %v", err)
-		}
+// GetDocument returns the document with the given ID. The get API is real time,
+// the document does not need to be refreshed to be found.
+func (c *Client) GetDocument(ctx context.Context, indexName, id string) (DocumentRepresentation, error) {
+	var resp = DocumentRepresentation{}
+	var err = c.get(ctx, "get_document", fmt.Sprintf(document, indexName, id), &resp)
+	return resp, err
+}
 
-		switch {
-		case resp.StatusCode >= 400:
-			return fmt.Errorf("invalid status code: '%v': %v", resp.RawResponse.Status, string(resp.Bytes()))
-		case resp.StatusCode >= 200:
-			return json.Unmarshal(resp.Bytes(), data)
-		default:
-			return fmt.Errorf("unknown response status code: %v", resp.StatusCode)
-		}
-	}
+// DeleteDocument deletes the document with the given ID.
+func (c *Client) DeleteDocument(ctx context.Context, indexName, id string) error {
+	return c.delete(ctx, "delete_document", fmt.Sprintf(document, indexName, id))
 }
 
-func (c *Client) post(plugins ...plugin.Plugin) error {
-	var req = c.httpClient.Post()
-	req = applyPlugins(req, "", plugins...)
+// Search executes the query on the index.
+func (c *Client) Search(ctx context.Context, indexName string, query interface{}) (SearchRepresentation, error) {
+	var resp = SearchRepresentation{}
+	var err = c.post(ctx, "search", fmt.Sprintf(search, indexName), &resp, body.JSON(query))
+	return resp, err
+}
 
-	var resp *gentleman.Response
-	{
-		var err error
-		resp, err = req.Do()
-		if err != nil {
-			return fmt.Errorf("could not get response: %v", err)
-		}
+// Refresh makes all the operations performed on the index available for search.
+func (c *Client) Refresh(ctx context.Context, indexName string) error {
+	return c.post(ctx, "refresh", fmt.Sprintf(refresh, indexName), nil)
+}
 
-		switch {
-		case resp.StatusCode >= 400:
-			return fmt.Errorf("invalid status code: '%v': %v", resp.RawResponse.Status, string(resp.Bytes()))
-		case resp.StatusCode >= 200:
-			return nil
-		default:
-			return fmt.Errorf("unknown response status code: %v", resp.StatusCode)
-		}
-	}
+// Bulk sends the newline delimited JSON actions of the body to the bulk API.
+func (c *Client) Bulk(ctx context.Context, actions []byte) (BulkRepresentation, error) {
+	var resp = BulkRepresentation{}
+	var err = c.post(ctx, "bulk", bulk, &resp, headers.Set("Content-Type", "application/x-ndjson"), body.String(string(actions)))
+	return resp, err
 }
 
-func (c *Client) delete(plugins ...plugin.Plugin) error {
-	var req = c.httpClient.Delete()
-	req = applyPlugins(req, "", plugins...)
+// get is a HTTP get method.
+func (c *Client) get(ctx context.Context, operation, path string, data interface{}, plugins ...plugin.Plugin) error {
+	return c.do(ctx, ElasticsearchRequest{Operation: operation, Method: "GET", Path: path}, data, plugins...)
+}
 
-	var resp *gentleman.Response
-	{
-		var err error
-		resp, err = req.Do()
+// post is a HTTP post method. The response is decoded in data if it is not nil.
+func (c *Client) post(ctx context.Context, operation, path string, data interface{}, plugins ...plugin.Plugin) error {
+	return c.do(ctx, ElasticsearchRequest{Operation: operation, Method: "POST", Path: path}, data, plugins...)
+}
+
+func (c *Client) delete(ctx context.Context, operation, path string, plugins ...plugin.Plugin) error {
+	return c.do(ctx, ElasticsearchRequest{Operation: operation, Method: "DELETE", Path: path}, nil, plugins...)
+}
+
+func (c *Client) put(ctx context.Context, operation, path string, plugins ...plugin.Plugin) error {
+	return c.do(ctx, ElasticsearchRequest{Operation: operation, Method: "PUT", Path: path}, nil, plugins...)
+}
+
+// do sends the request through the middlewares. The response is decoded in data if it
+// is not nil.
+func (c *Client) do(ctx context.Context, req ElasticsearchRequest, data interface{}, plugins ...plugin.Plugin) error {
+	var send ElasticsearchRequestFunc = func(ctx context.Context, req ElasticsearchRequest) (ElasticsearchResponse, error) {
+		var r = c.httpClient.Request().Method(req.Method)
+		r = applyPlugins(r, "", append(plugins, url.Path(req.Path), withContext(ctx))...)
+		for k := range req.Header {
+			r = r.SetHeader(k, req.Header.Get(k))
+		}
+
+		var resp, err = r.Do()
 		if err != nil {
-			return fmt.Errorf("could not get response: %v", err)
+			return ElasticsearchResponse{}, fmt.Errorf("could not get response: %v", err)
 		}
 
+		var res = ElasticsearchResponse{StatusCode: resp.StatusCode}
 		switch {
 		case resp.StatusCode >= 400:
-			return fmt.Errorf("invalid status code: '%v': %v", resp.RawResponse.Status, string(resp.Bytes()))
+			res.ErrorType = errorType(resp.Bytes())
+			return res, &ElasticsearchError{StatusCode: resp.StatusCode, Status: resp.RawResponse.Status, Type: res.ErrorType, Body: string(resp.Bytes())}
+		case resp.StatusCode >= 200 && data != nil:
+			return res, json.Unmarshal(resp.Bytes(), data)
 		case resp.StatusCode >= 200:
-			return nil
+			return res, nil
 		default:
-			return fmt.Errorf("unknown response status code: %v", resp.StatusCode)
+			return res, fmt.Errorf("unknown response status code: %v", resp.StatusCode)
 		}
 	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		send = c.middlewares[i](send)
+	}
+
+	var _, err = send(ctx, req)
+	return err
 }
 
-func (c *Client) put(plugins ...plugin.Plugin) error {
-	var req = c.httpClient.Put()
-	req = applyPlugins(req, "", plugins...)
+// withContext binds the HTTP request to ctx, so the deadline or the cancellation of ctx
+// aborts the request before the global timeout.
+func withContext(ctx context.Context) plugin.Plugin {
+	return plugin.NewRequestPlugin(func(c *gentleman_context.Context, h gentleman_context.Handler) {
+		c.Request = c.Request.WithContext(ctx)
+		h.Next(c)
+	})
+}
 
-	var resp *gentleman.Response
-	{
-		var err error
-		resp, err = req.Do()
-		if err != nil {
-			return fmt.Errorf("could not get response: %v", err)
-		}
+// errorType returns the type of the Elasticsearch error in the response body, e.g.
+// index_not_found_exception.
+func errorType(body []byte) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
 
-		switch {
-		case resp.StatusCode >= 400:
-			return fmt.Errorf("invalid status code: '%v': %v", resp.RawResponse.Status, string(resp.Bytes()))
-		case resp.StatusCode >= 200:
-			return nil
-		default:
-			return fmt.Errorf("unknown response status code: %v", resp.StatusCode)
-		}
+	var e struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(resp.Error, &e); err != nil {
+		return ""
 	}
+	return e.Type
 }
 
 // applyPlugins apply all the plugins to the request req.
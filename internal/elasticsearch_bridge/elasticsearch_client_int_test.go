@@ -3,6 +3,7 @@
 package elasticsearch_bridge_test
 
 import (
+	"context"
 	"flag"
 	"testing"
 	"time"
@@ -36,23 +37,24 @@ func TestIntClient(t *testing.T) {
 	}
 
 	{
-		_, err = client.GetIndex(UUID)
+		_, err = client.GetIndex(context.Background(), UUID)
 		assert.NotNil(t, err)
+		assert.True(t, IsNotFound(err))
 	}
 
 	var indexes []IndexRepresentation
 	{
-		indexes, err = client.ListIndexes()
+		indexes, err = client.ListIndexes(context.Background())
 		assert.Nil(t, err)
 		var numberOfIndexes = len(indexes)
 
-		_, err = client.GetIndex(UUID)
+		_, err = client.GetIndex(context.Background(), UUID)
 		assert.NotNil(t, err)
 
-		err = client.CreateIndex(UUID)
+		err = client.CreateIndex(context.Background(), UUID)
 		assert.Nil(t, err)
 
-		indexes, err = client.ListIndexes()
+		indexes, err = client.ListIndexes(context.Background())
 		assert.Nil(t, err)
 		var numberOfIndexesAfterCreation = len(indexes)
 
@@ -61,17 +63,17 @@ func TestIntClient(t *testing.T) {
 
 	var index IndexSettingsRepresentation
 	{
-		index, err = client.GetIndex(UUID)
+		index, err = client.GetIndex(context.Background(), UUID)
 		assert.Nil(t, err)
 		assert.NotNil(t, index)
 	}
 
 	var indexesNew []IndexRepresentation
 	{
-		err = client.DeleteIndex(UUID)
+		err = client.DeleteIndex(context.Background(), UUID)
 		assert.Nil(t, err)
 
-		indexesNew, err = client.ListIndexes()
+		indexesNew, err = client.ListIndexes(context.Background())
 		assert.Nil(t, err)
 		assert.Equal(t, len(indexes)-1, len(indexesNew))
 	}
@@ -92,7 +94,7 @@ func TestIntHealth(t *testing.T) {
 	}
 
 	{
-		var health, err = client.Health()
+		var health, err = client.Health(context.Background())
 		assert.NotNil(t, err)
 		assert.NotNil(t, health.ClusterName)
 	}
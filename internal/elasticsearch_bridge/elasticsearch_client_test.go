@@ -0,0 +1,24 @@
+package elasticsearch_bridge
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "index_not_found_exception", errorType([]byte(`{"error":{"type":"index_not_found_exception","reason":"no such index"},"status":404}`)))
+	assert.Equal(t, "", errorType([]byte(`{"error":"Incorrect HTTP method","status":405}`)))
+	assert.Equal(t, "", errorType([]byte(`not json`)))
+}
+
+func TestIsNotFound(t *testing.T) {
+	var notFound = &ElasticsearchError{StatusCode: 404, Status: "404 Not Found", Type: "index_not_found_exception"}
+	assert.True(t, IsNotFound(notFound))
+	assert.True(t, IsNotFound(errors.Wrap(notFound, "could not get index")))
+	assert.False(t, IsNotFound(&ElasticsearchError{StatusCode: 500, Status: "500 Internal Server Error"}))
+	assert.False(t, IsNotFound(fmt.Errorf("could not get response: timeout")))
+	assert.False(t, IsNotFound(nil))
+}
@@ -0,0 +1,235 @@
+package elasticsearch_bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/pkg/errors"
+)
+
+// ElasticsearchBulkIndexer is the interface of the Elasticsearch client indexing the logs.
+type ElasticsearchBulkIndexer interface {
+	Bulk(ctx context.Context, actions []byte) (BulkRepresentation, error)
+}
+
+// ElasticsearchSinkConfig is the configuration of the Elasticsearch log sink. The logs are
+// indexed in the daily index named after the index prefix, e.g. elasticsearch-bridge-2018.02.13.
+type ElasticsearchSinkConfig struct {
+	IndexPrefix   string
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+	MinBackoff    time.Duration
+	MaxBackoff    time.Duration
+}
+
+// sinkLog is a log waiting to be indexed.
+type sinkLog struct {
+	index string
+	doc   []byte
+}
+
+// ElasticsearchSink encodes logs and bulk-indexes them into Elasticsearch. The logs are
+// queued in memory and indexed by Run, when a batch is full or every flush interval. When
+// Elasticsearch is unreachable, the batch is kept and retried with an exponential backoff.
+// When the queue is full, the new logs are dropped.
+//
+// The sink must not log into itself: the indexer must not log its requests to a logger
+// writing to the sink, and the sink errors are logged with the error logger, which must
+// not write to the sink either.
+type ElasticsearchSink struct {
+	indexer   ElasticsearchBulkIndexer
+	encoder   LogEncoder
+	config    ElasticsearchSinkConfig
+	errLogger log.Logger
+	queue     chan sinkLog
+
+	// droppedOnWrite counts the logs dropped by Write, reported by Run.
+	droppedOnWrite int64
+
+	backoff time.Duration
+	retryAt time.Time
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewElasticsearchSink returns an Elasticsearch log sink that encodes logs with the encoder.
+func NewElasticsearchSink(indexer ElasticsearchBulkIndexer, encoder LogEncoder, c ElasticsearchSinkConfig, errLogger log.Logger) (*ElasticsearchSink, error) {
+	switch {
+	case c.IndexPrefix == "":
+		return nil, fmt.Errorf("empty index prefix")
+	case c.QueueSize <= 0:
+		return nil, fmt.Errorf("invalid queue size %d", c.QueueSize)
+	case c.BatchSize <= 0:
+		return nil, fmt.Errorf("invalid batch size %d", c.BatchSize)
+	}
+
+	return &ElasticsearchSink{
+		indexer:   indexer,
+		encoder:   encoder,
+		config:    c,
+		errLogger: errLogger,
+		queue:     make(chan sinkLog, c.QueueSize),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}, nil
+}
+
+// Write encodes logs and queues them for the index of the day. It never blocks: when the
+// queue is full, the log is dropped.
+func (s *ElasticsearchSink) Write(data []byte) (int, error) {
+	var encoded, err = encodeLog(s.encoder, data)
+	if err != nil {
+		return 0, err
+	}
+
+	var l = sinkLog{
+		index: fmt.Sprintf("%s-%s", s.config.IndexPrefix, time.Now().UTC().Format("2006.01.02")),
+		doc:   encoded,
+	}
+
+	select {
+	case s.queue <- l:
+	default:
+		atomic.AddInt64(&s.droppedOnWrite, 1)
+	}
+	return len(data), nil
+}
+
+// Run indexes the queued logs until Close is called. The indexed and dropped counters
+// count the logs.
+func (s *ElasticsearchSink) Run(indexed, dropped metrics.Counter) {
+	defer close(s.stopped)
+
+	var tic = time.NewTicker(s.config.FlushInterval)
+	defer tic.Stop()
+
+	var batch []sinkLog
+	for {
+		select {
+		case l := <-s.queue:
+			batch = append(batch, l)
+			if len(batch) >= s.config.BatchSize {
+				batch = s.flush(batch, indexed, dropped, false)
+			}
+		case <-tic.C:
+			batch = s.flush(batch, indexed, dropped, false)
+		case <-s.done:
+			for len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+			}
+			s.flush(batch, indexed, dropped, true)
+			return
+		}
+	}
+}
+
+// Close stops Run after a final flush of the queued logs.
+func (s *ElasticsearchSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+// flush indexes the batch and returns the logs that remain to index. A batch that could
+// not be indexed is kept for the next flush, bounded by the queue size, unless it is the
+// final flush. While backing off, the batch is kept without trying to index it.
+func (s *ElasticsearchSink) flush(batch []sinkLog, indexed, dropped metrics.Counter, final bool) []sinkLog {
+	if n := atomic.SwapInt64(&s.droppedOnWrite, 0); n > 0 {
+		dropped.Add(float64(n))
+	}
+
+	if len(batch) == 0 || (!final && time.Now().Before(s.retryAt)) {
+		return s.bound(batch, dropped)
+	}
+
+	var failed, err = s.index(batch)
+	if err != nil {
+		switch {
+		case s.backoff == 0:
+			s.backoff = s.config.MinBackoff
+		case s.backoff*2 > s.config.MaxBackoff:
+			s.backoff = s.config.MaxBackoff
+		default:
+			s.backoff *= 2
+		}
+		s.retryAt = time.Now().Add(s.backoff)
+
+		s.errLogger.Log("msg", "could not index logs into Elasticsearch", "retry_in", s.backoff, "error", err)
+		if final {
+			dropped.Add(float64(len(batch)))
+			return nil
+		}
+		return s.bound(batch, dropped)
+	}
+
+	s.backoff = 0
+	s.retryAt = time.Time{}
+
+	// The logs rejected by Elasticsearch would be rejected again, they are dropped.
+	if failed > 0 {
+		s.errLogger.Log("msg", "Elasticsearch rejected logs", "count", failed)
+		dropped.Add(float64(failed))
+	}
+	indexed.Add(float64(len(batch) - failed))
+	return batch[:0]
+}
+
+// bound drops the oldest logs of the batch exceeding the queue size.
+func (s *ElasticsearchSink) bound(batch []sinkLog, dropped metrics.Counter) []sinkLog {
+	var n = len(batch) - s.config.QueueSize
+	if n <= 0 {
+		return batch
+	}
+
+	dropped.Add(float64(n))
+	return append(batch[:0], batch[n:]...)
+}
+
+// index bulk-indexes the batch and returns the number of logs rejected by Elasticsearch.
+func (s *ElasticsearchSink) index(batch []sinkLog) (int, error) {
+	var actions = &bytes.Buffer{}
+	for _, l := range batch {
+		var action, err = json.Marshal(map[string]interface{}{"index": map[string]string{"_index": l.index}})
+		if err != nil {
+			return 0, errors.Wrap(err, "could not encode bulk action")
+		}
+		actions.Write(action)
+		actions.WriteByte('\n')
+		actions.Write(l.doc)
+		actions.WriteByte('\n')
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	var resp, err = s.indexer.Bulk(ctx, actions.Bytes())
+	if err != nil {
+		return 0, errors.Wrap(err, "could not bulk index logs")
+	}
+	if !resp.Errors {
+		return 0, nil
+	}
+
+	var failed = 0
+	for _, item := range resp.Items {
+		for _, r := range item {
+			if r.Status >= 300 {
+				failed++
+			}
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,168 @@
+package elasticsearch_bridge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewElasticsearchSinkInvalidConfig(t *testing.T) {
+	var c = ElasticsearchSinkConfig{QueueSize: 10, BatchSize: 10, FlushInterval: time.Second, Timeout: time.Second}
+	var _, err = NewElasticsearchSink(nil, NewJSONEncoder(), c, log.NewNopLogger())
+	assert.NotNil(t, err)
+
+	c.IndexPrefix = "elasticsearch-bridge"
+	c.BatchSize = 0
+	_, err = NewElasticsearchSink(nil, NewJSONEncoder(), c, log.NewNopLogger())
+	assert.NotNil(t, err)
+}
+
+func TestElasticsearchSink(t *testing.T) {
+	var indexer = &recordingBulkIndexer{bulks: make(chan string, 10)}
+	var c = ElasticsearchSinkConfig{IndexPrefix: "elasticsearch-bridge", QueueSize: 10, BatchSize: 2, FlushInterval: time.Hour, Timeout: time.Second}
+	var s, err = NewElasticsearchSink(indexer, NewJSONEncoder(), c, log.NewNopLogger())
+	assert.Nil(t, err)
+
+	var indexed, dropped = &countingCounter{}, &countingCounter{}
+	go s.Run(indexed, dropped)
+
+	var index = "elasticsearch-bridge-" + time.Now().UTC().Format("2006.01.02")
+	var action = fmt.Sprintf(`{"index":{"_index":"%s"}}`, index)
+
+	// A full batch is indexed in one bulk request.
+	var line = `{"msg":"1"}` + "\n"
+	var n int
+	n, err = s.Write([]byte(line))
+	assert.Nil(t, err)
+	assert.Equal(t, len(line), n)
+	s.Write([]byte(`{"msg":"2"}`))
+	assert.Equal(t, action+"\n"+`{"msg":"1"}`+"\n"+action+"\n"+`{"msg":"2"}`+"\n", <-indexer.bulks)
+
+	// Close flushes the remaining logs.
+	s.Write([]byte(`{"msg":"3"}`))
+	assert.Nil(t, s.Close())
+	assert.Equal(t, action+"\n"+`{"msg":"3"}`+"\n", <-indexer.bulks)
+	assert.Equal(t, float64(3), indexed.value)
+	assert.Equal(t, float64(0), dropped.value)
+}
+
+func TestElasticsearchSinkFailures(t *testing.T) {
+	var indexer = &recordingBulkIndexer{bulks: make(chan string, 10)}
+	var errLogs = &bytes.Buffer{}
+	var c = ElasticsearchSinkConfig{IndexPrefix: "elasticsearch-bridge", QueueSize: 2, BatchSize: 10, FlushInterval: time.Hour, Timeout: time.Second}
+	var s, err = NewElasticsearchSink(indexer, NewJSONEncoder(), c, log.NewLogfmtLogger(errLogs))
+	assert.Nil(t, err)
+	var indexed, dropped = &countingCounter{}, &countingCounter{}
+
+	var batch = []sinkLog{{index: "i", doc: []byte("1")}, {index: "i", doc: []byte("2")}, {index: "i", doc: []byte("3")}}
+
+	// Elasticsearch is unreachable, the batch is kept, bounded by the queue size.
+	{
+		indexer.setResponse(BulkRepresentation{}, fmt.Errorf("connection refused"))
+		batch = s.flush(batch, indexed, dropped, false)
+		<-indexer.bulks
+		assert.Equal(t, []sinkLog{{index: "i", doc: []byte("2")}, {index: "i", doc: []byte("3")}}, batch)
+		assert.Equal(t, float64(1), dropped.value)
+		assert.True(t, strings.Contains(errLogs.String(), "connection refused"))
+	}
+
+	// The logs rejected by Elasticsearch are dropped.
+	{
+		indexer.setResponse(BulkRepresentation{Errors: true, Items: []map[string]BulkItemRepresentation{
+			{"index": {Index: "i", Status: 201}},
+			{"index": {Index: "i", Status: 400}},
+		}}, nil)
+		batch = s.flush(batch, indexed, dropped, false)
+		<-indexer.bulks
+		assert.Equal(t, 0, len(batch))
+		assert.Equal(t, float64(1), indexed.value)
+		assert.Equal(t, float64(2), dropped.value)
+	}
+
+	// The logs are dropped when the queue is full.
+	{
+		for i := 0; i < 3; i++ {
+			s.Write([]byte(`{"msg":"health checks"}`))
+		}
+		s.flush(nil, indexed, dropped, false)
+		assert.Equal(t, float64(3), dropped.value)
+	}
+}
+
+func TestElasticsearchSinkBackoff(t *testing.T) {
+	var indexer = &recordingBulkIndexer{bulks: make(chan string, 10)}
+	var errLogs = &bytes.Buffer{}
+	var c = ElasticsearchSinkConfig{IndexPrefix: "elasticsearch-bridge", QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour, Timeout: time.Second, MinBackoff: time.Second, MaxBackoff: 3 * time.Second}
+	var s, err = NewElasticsearchSink(indexer, NewJSONEncoder(), c, log.NewLogfmtLogger(errLogs))
+	assert.Nil(t, err)
+	var indexed, dropped = &countingCounter{}, &countingCounter{}
+
+	// Elasticsearch is down, the batch is kept and the backoff doubles up to the maximum.
+	{
+		indexer.setResponse(BulkRepresentation{}, fmt.Errorf("connection refused"))
+
+		var batch = s.flush([]sinkLog{{index: "i", doc: []byte("1")}}, indexed, dropped, false)
+		<-indexer.bulks
+		assert.Equal(t, 1, len(batch))
+		assert.Equal(t, time.Second, s.backoff)
+
+		// No retry before the end of the backoff.
+		batch = s.flush(batch, indexed, dropped, false)
+		assert.Equal(t, 1, len(batch))
+		assert.Equal(t, 0, len(indexer.bulks))
+
+		s.retryAt = time.Time{}
+		batch = s.flush(batch, indexed, dropped, false)
+		<-indexer.bulks
+		assert.Equal(t, 2*time.Second, s.backoff)
+
+		s.retryAt = time.Time{}
+		batch = s.flush(append(batch, sinkLog{index: "i", doc: []byte("2")}, sinkLog{index: "i", doc: []byte("3")}), indexed, dropped, false)
+		<-indexer.bulks
+		assert.Equal(t, 3*time.Second, s.backoff)
+
+		// The batch is bounded by the queue size.
+		assert.Equal(t, []sinkLog{{index: "i", doc: []byte("2")}, {index: "i", doc: []byte("3")}}, batch)
+		assert.Equal(t, float64(1), dropped.value)
+	}
+
+	// Elasticsearch is back.
+	{
+		s.retryAt = time.Time{}
+		indexer.setResponse(BulkRepresentation{}, nil)
+
+		var batch = s.flush([]sinkLog{{index: "i", doc: []byte("2")}, {index: "i", doc: []byte("3")}}, indexed, dropped, false)
+		<-indexer.bulks
+		assert.Equal(t, 0, len(batch))
+		assert.Equal(t, time.Duration(0), s.backoff)
+		assert.Equal(t, float64(2), indexed.value)
+	}
+}
+
+// recordingBulkIndexer records the bulk requests.
+type recordingBulkIndexer struct {
+	mu    sync.Mutex
+	resp  BulkRepresentation
+	err   error
+	bulks chan string
+}
+
+func (i *recordingBulkIndexer) setResponse(resp BulkRepresentation, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.resp, i.err = resp, err
+}
+
+func (i *recordingBulkIndexer) Bulk(_ context.Context, actions []byte) (BulkRepresentation, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.bulks <- string(actions)
+	return i.resp, i.err
+}
@@ -2,32 +2,25 @@ package elasticsearch_bridge
 
 import (
 	"context"
-
-	fb_flaki "github.com/cloudtrust/elasticsearch-bridge/api/fb"
-	flatbuffers "github.com/google/flatbuffers/go"
-	"github.com/pkg/errors"
 )
 
+// FlakiFetcher is the interface of the Flaki client fetching the IDs from Flaki.
+type FlakiFetcher interface {
+	FetchValidID(context.Context) (string, error)
+}
+
 type FlakiLightClient struct {
-	flakiClient fb_flaki.FlakiClient
+	flakiClient FlakiFetcher
 }
 
-func NewFlakiLightClient(client fb_flaki.FlakiClient) *FlakiLightClient {
+func NewFlakiLightClient(client FlakiFetcher) *FlakiLightClient {
 	return &FlakiLightClient{
 		flakiClient: client,
 	}
 }
 
+// NextValidID fetches an ID from Flaki, bypassing the prefetched IDs, so the health checks
+// reach Flaki.
 func (f *FlakiLightClient) NextValidID() (string, error) {
-	var b = flatbuffers.NewBuilder(0)
-	fb_flaki.FlakiRequestStart(b)
-	b.Finish(fb_flaki.FlakiRequestEnd(b))
-
-	var reply, err = f.flakiClient.NextValidID(context.Background(), b)
-
-	if err != nil {
-		return "", errors.Wrapf(err, "cannot get ID from flaki-service")
-	}
-
-	return string(reply.Id()), nil
+	return f.flakiClient.FetchValidID(context.Background())
 }
@@ -2,13 +2,29 @@ package elasticsearch_bridge
 
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/multi"
 	metric "github.com/go-kit/kit/metrics/influx"
 	influx "github.com/influxdata/influxdb/client/v2"
 )
 
+// Metrics is the interface of the metrics backends. The label names of a metric are the
+// keys that may be used in With, the backends that accept any key ignore them.
+type Metrics interface {
+	NewCounter(name string, labelNames ...string) metrics.Counter
+	NewGauge(name string, labelNames ...string) metrics.Gauge
+	NewHistogram(name string, labelNames ...string) metrics.Histogram
+	WriteLoop(c <-chan time.Time)
+	Ping(timeout time.Duration) (time.Duration, string, error)
+}
+
 // Influx is the Influx client interface.
 type Influx interface {
 	Ping(timeout time.Duration) (time.Duration, string, error)
@@ -39,17 +55,17 @@ func NewMetrics(influx Influx, metrics GoKitMetrics) *InfluxMetrics {
 }
 
 // NewCounter returns a go-kit Counter.
-func (m *InfluxMetrics) NewCounter(name string) metrics.Counter {
+func (m *InfluxMetrics) NewCounter(name string, labelNames ...string) metrics.Counter {
 	return m.metrics.NewCounter(name)
 }
 
 // NewGauge returns a go-kit Gauge.
-func (m *InfluxMetrics) NewGauge(name string) metrics.Gauge {
+func (m *InfluxMetrics) NewGauge(name string, labelNames ...string) metrics.Gauge {
 	return m.metrics.NewGauge(name)
 }
 
 // NewHistogram returns a go-kit Histogram.
-func (m *InfluxMetrics) NewHistogram(name string) metrics.Histogram {
+func (m *InfluxMetrics) NewHistogram(name string, labelNames ...string) metrics.Histogram {
 	return m.metrics.NewHistogram(name)
 }
 
@@ -63,17 +79,110 @@ func (m *InfluxMetrics) Ping(timeout time.Duration) (time.Duration, string, erro
 	return m.influx.Ping(timeout)
 }
 
+// MultiMetrics sends the metrics to several backends at the same time.
+type MultiMetrics struct {
+	backends []Metrics
+}
+
+// NewMultiMetrics returns a MultiMetrics.
+func NewMultiMetrics(backends ...Metrics) *MultiMetrics {
+	return &MultiMetrics{
+		backends: backends,
+	}
+}
+
+// NewCounter returns a go-kit Counter that adds to the counters of all the backends.
+func (m *MultiMetrics) NewCounter(name string, labelNames ...string) metrics.Counter {
+	var counters = []metrics.Counter{}
+	for _, b := range m.backends {
+		counters = append(counters, b.NewCounter(name, labelNames...))
+	}
+	return multi.NewCounter(counters...)
+}
+
+// NewGauge returns a go-kit Gauge that sets the gauges of all the backends.
+func (m *MultiMetrics) NewGauge(name string, labelNames ...string) metrics.Gauge {
+	var gauges = []metrics.Gauge{}
+	for _, b := range m.backends {
+		gauges = append(gauges, b.NewGauge(name, labelNames...))
+	}
+	return multi.NewGauge(gauges...)
+}
+
+// NewHistogram returns a go-kit Histogram that observes in the histograms of all the backends.
+func (m *MultiMetrics) NewHistogram(name string, labelNames ...string) metrics.Histogram {
+	var histograms = []metrics.Histogram{}
+	for _, b := range m.backends {
+		histograms = append(histograms, b.NewHistogram(name, labelNames...))
+	}
+	return multi.NewHistogram(histograms...)
+}
+
+// WriteLoop runs the write loops of all the backends. Each tick is forwarded to the
+// backends, a backend still writing the previous tick skips it.
+func (m *MultiMetrics) WriteLoop(c <-chan time.Time) {
+	var ticks = []chan time.Time{}
+	var wg sync.WaitGroup
+	for _, b := range m.backends {
+		var tick = make(chan time.Time, 1)
+		ticks = append(ticks, tick)
+		wg.Add(1)
+		go func(b Metrics) {
+			defer wg.Done()
+			b.WriteLoop(tick)
+		}(b)
+	}
+
+	for t := range c {
+		for _, tick := range ticks {
+			select {
+			case tick <- t:
+			default:
+			}
+		}
+	}
+
+	for _, tick := range ticks {
+		close(tick)
+	}
+	wg.Wait()
+}
+
+// Ping pings all the backends. It returns the longest duration and the errors of all
+// the backends.
+func (m *MultiMetrics) Ping(timeout time.Duration) (time.Duration, string, error) {
+	var duration time.Duration
+	var versions, errs = []string{}, []string{}
+	for _, b := range m.backends {
+		var d, v, err = b.Ping(timeout)
+		if d > duration {
+			duration = d
+		}
+		if v != "" {
+			versions = append(versions, v)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return duration, strings.Join(versions, ", "), fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return duration, strings.Join(versions, ", "), nil
+}
+
 // NoopMetrics is an Influx metrics that does nothing.
 type NoopMetrics struct{}
 
 // NewCounter returns a Counter that does nothing.
-func (m *NoopMetrics) NewCounter(name string) metrics.Counter { return &NoopCounter{} }
+func (m *NoopMetrics) NewCounter(name string, labelNames ...string) metrics.Counter { return &NoopCounter{} }
 
 // NewGauge returns a Gauge that does nothing.
-func (m *NoopMetrics) NewGauge(name string) metrics.Gauge { return &NoopGauge{} }
+func (m *NoopMetrics) NewGauge(name string, labelNames ...string) metrics.Gauge { return &NoopGauge{} }
 
 // NewHistogram returns an Histogram that does nothing.
-func (m *NoopMetrics) NewHistogram(name string) metrics.Histogram { return &NoopHistogram{} }
+func (m *NoopMetrics) NewHistogram(name string, labelNames ...string) metrics.Histogram { return &NoopHistogram{} }
 
 // WriteLoop does nothing.
 func (m *NoopMetrics) WriteLoop(c <-chan time.Time) {}
@@ -112,3 +221,26 @@ func (h *NoopHistogram) With(labelValues ...string) metrics.Histogram { return h
 
 // Observe does nothing.
 func (h *NoopHistogram) Observe(value float64) {}
+
+// MakeElasticsearchInstrumentingMW makes an instrumenting middleware for the Elasticsearch
+// client. The durations of the requests are labeled by operation, and the errors by
+// operation, status code and Elasticsearch error type. A request without response has the
+// status code 0 and the error type "connection".
+func MakeElasticsearchInstrumentingMW(histogram metrics.Histogram, counter metrics.Counter) ElasticsearchMiddleware {
+	return func(next ElasticsearchRequestFunc) ElasticsearchRequestFunc {
+		return func(ctx context.Context, req ElasticsearchRequest) (ElasticsearchResponse, error) {
+			var begin = time.Now()
+			var resp, err = next(ctx, req)
+			histogram.With("operation", req.Operation).Observe(time.Since(begin).Seconds())
+
+			if err != nil {
+				var errorType = resp.ErrorType
+				if resp.StatusCode == 0 {
+					errorType = "connection"
+				}
+				counter.With("operation", req.Operation, "status", strconv.Itoa(resp.StatusCode), "error_type", errorType).Add(1)
+			}
+			return resp, err
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package elasticsearch_bridge
 
-//go:generate mockgen -source=instrumenting.go -destination=./mock/instrumenting.go -package=mock -mock_names=Influx=Influx,GoKitMetrics=GoKitMetrics github.com/cloudtrust/elasticsearch-bridge/cmd Influx,GoKitMetrics
+//go:generate mockgen -source=instrumenting.go -destination=./mock/instrumenting.go -package=mock -mock_names=Metrics=Metrics,Influx=Influx,GoKitMetrics=GoKitMetrics github.com/cloudtrust/elasticsearch-bridge/cmd Metrics,Influx,GoKitMetrics
 
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge/mock"
+	"github.com/go-kit/kit/metrics"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -56,3 +59,152 @@ func TestNoopMetrics(t *testing.T) {
 	assert.Equal(t, "", s)
 	assert.Nil(t, err)
 }
+
+func TestMultiMetrics(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockMetrics1 = mock.NewMetrics(mockCtrl)
+	var mockMetrics2 = mock.NewMetrics(mockCtrl)
+
+	var multiMetrics = NewMultiMetrics(mockMetrics1, mockMetrics2)
+
+	// Counter.
+	{
+		var c1, c2 = &countingCounter{}, &countingCounter{}
+		mockMetrics1.EXPECT().NewCounter("counter name", "unit").Return(c1).Times(1)
+		mockMetrics2.EXPECT().NewCounter("counter name", "unit").Return(c2).Times(1)
+		multiMetrics.NewCounter("counter name", "unit").Add(2)
+		assert.Equal(t, 2.0, c1.value)
+		assert.Equal(t, 2.0, c2.value)
+	}
+
+	// Gauge and histogram.
+	{
+		mockMetrics1.EXPECT().NewGauge("gauge name").Return(&NoopGauge{}).Times(1)
+		mockMetrics2.EXPECT().NewGauge("gauge name").Return(&NoopGauge{}).Times(1)
+		multiMetrics.NewGauge("gauge name").Set(1)
+
+		mockMetrics1.EXPECT().NewHistogram("histogram name").Return(&NoopHistogram{}).Times(1)
+		mockMetrics2.EXPECT().NewHistogram("histogram name").Return(&NoopHistogram{}).Times(1)
+		multiMetrics.NewHistogram("histogram name").Observe(1)
+	}
+
+	// Ping, one backend in error.
+	{
+		mockMetrics1.EXPECT().Ping(1*time.Second).Return(1*time.Millisecond, "1.7", nil).Times(1)
+		mockMetrics2.EXPECT().Ping(1*time.Second).Return(2*time.Millisecond, "", fmt.Errorf("fail")).Times(1)
+		var duration, version, err = multiMetrics.Ping(1 * time.Second)
+		assert.Equal(t, 2*time.Millisecond, duration)
+		assert.Equal(t, "1.7", version)
+		assert.NotNil(t, err)
+	}
+
+	// Ping, all backends OK.
+	{
+		mockMetrics1.EXPECT().Ping(1*time.Second).Return(1*time.Millisecond, "", nil).Times(1)
+		mockMetrics2.EXPECT().Ping(1*time.Second).Return(1*time.Millisecond, "", nil).Times(1)
+		var _, _, err = multiMetrics.Ping(1 * time.Second)
+		assert.Nil(t, err)
+	}
+}
+
+func TestMultiMetricsWriteLoop(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockMetrics1 = mock.NewMetrics(mockCtrl)
+	var mockMetrics2 = mock.NewMetrics(mockCtrl)
+
+	var multiMetrics = NewMultiMetrics(mockMetrics1, mockMetrics2)
+
+	var ticks1, ticks2 = make(chan time.Time, 10), make(chan time.Time, 10)
+	var forward = func(ticks chan time.Time) func(<-chan time.Time) {
+		return func(c <-chan time.Time) {
+			for t := range c {
+				ticks <- t
+			}
+			close(ticks)
+		}
+	}
+	mockMetrics1.EXPECT().WriteLoop(gomock.Any()).Do(forward(ticks1)).Times(1)
+	mockMetrics2.EXPECT().WriteLoop(gomock.Any()).Do(forward(ticks2)).Times(1)
+
+	var c = make(chan time.Time)
+	var done = make(chan struct{})
+	go func() {
+		multiMetrics.WriteLoop(c)
+		close(done)
+	}()
+
+	var now = time.Now()
+	c <- now
+	assert.Equal(t, now, <-ticks1)
+	assert.Equal(t, now, <-ticks2)
+
+	// The write loops of the backends end with the tick channel.
+	close(c)
+	<-done
+	var _, ok1 = <-ticks1
+	var _, ok2 = <-ticks2
+	assert.False(t, ok1)
+	assert.False(t, ok2)
+}
+
+func TestElasticsearchInstrumentingMW(t *testing.T) {
+	var histogram = &recordingHistogram{}
+	var counter = &countingCounter{}
+
+	var resp ElasticsearchResponse
+	var err error
+	var next = func(context.Context, ElasticsearchRequest) (ElasticsearchResponse, error) {
+		return resp, err
+	}
+	var m = MakeElasticsearchInstrumentingMW(histogram, counter)(next)
+
+	// Success.
+	{
+		resp, err = ElasticsearchResponse{StatusCode: 200}, nil
+		m(context.Background(), ElasticsearchRequest{Operation: "health", Method: "GET", Path: "/_cluster/health"})
+		assert.Equal(t, []string{"operation", "health"}, histogram.labelValues)
+		assert.Equal(t, 1, histogram.observations)
+		assert.Equal(t, 0.0, counter.value)
+	}
+
+	// Elasticsearch error.
+	{
+		resp, err = ElasticsearchResponse{StatusCode: 404, ErrorType: "index_not_found_exception"}, fmt.Errorf("fail")
+		var _, e = m(context.Background(), ElasticsearchRequest{Operation: "get_index", Method: "GET", Path: "index"})
+		assert.Equal(t, err, e)
+		assert.Equal(t, []string{"operation", "get_index", "status", "404", "error_type", "index_not_found_exception"}, counter.labelValues)
+		assert.Equal(t, 1.0, counter.value)
+	}
+
+	// No response.
+	{
+		resp, err = ElasticsearchResponse{}, fmt.Errorf("fail")
+		m(context.Background(), ElasticsearchRequest{Operation: "health", Method: "GET", Path: "/_cluster/health"})
+		assert.Equal(t, []string{"operation", "health", "status", "0", "error_type", "connection"}, counter.labelValues)
+		assert.Equal(t, 2.0, counter.value)
+	}
+}
+
+type countingCounter struct {
+	labelValues []string
+	value       float64
+}
+
+func (c *countingCounter) With(labelValues ...string) metrics.Counter {
+	c.labelValues = labelValues
+	return c
+}
+func (c *countingCounter) Add(delta float64) { c.value += delta }
+
+type recordingHistogram struct {
+	labelValues  []string
+	observations int
+}
+
+func (h *recordingHistogram) With(labelValues ...string) metrics.Histogram {
+	h.labelValues = labelValues
+	return h
+}
+func (h *recordingHistogram) Observe(value float64) { h.observations++ }
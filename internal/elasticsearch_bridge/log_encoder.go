@@ -0,0 +1,302 @@
+package elasticsearch_bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Log formats.
+const (
+	LogFormatLogstashV1 = "logstash-v1"
+	LogFormatECS        = "ecs"
+	LogFormatJSON       = "json"
+)
+
+// ecsVersion is the version of the Elastic Common Schema of the ECS logs.
+const ecsVersion = "8.4.0"
+
+// LogEncoder encodes the logs decoded from the JSON log lines of the go-kit JSONLogger.
+type LogEncoder interface {
+	// Encode encodes the decoded log.
+	Encode(logs map[string]interface{}) ([]byte, error)
+	// EncodeRaw encodes a line that could not be decoded as the message of a log.
+	EncodeRaw(data []byte, decodeErr error) ([]byte, error)
+}
+
+// NewLogEncoder returns the encoder of the log format. The mapping gives the timestamp and
+// message keys of the logs to all the formats, the field renaming applies to logstash-v1.
+func NewLogEncoder(format string, mapping LogstashFieldMapping) (LogEncoder, error) {
+	switch format {
+	case LogFormatLogstashV1:
+		return NewLogstashEncoder(mapping), nil
+	case LogFormatECS:
+		return NewECSEncoder(mapping.Timestamp, mapping.Message), nil
+	case LogFormatJSON:
+		return NewJSONEncoder(), nil
+	default:
+		return nil, fmt.Errorf("unknown log format '%s'", format)
+	}
+}
+
+// EncodingWriter encodes logs and writes them, one per line, to the underlying writer.
+type EncodingWriter struct {
+	w       io.Writer
+	encoder LogEncoder
+}
+
+// NewEncodingWriter returns a writer that encodes logs with the encoder.
+func NewEncodingWriter(w io.Writer, encoder LogEncoder) *EncodingWriter {
+	return &EncodingWriter{
+		w:       w,
+		encoder: encoder,
+	}
+}
+
+// Write encodes logs and writes them. A line that is not a JSON object is not lost, it
+// is written as the message of a log.
+func (w *EncodingWriter) Write(data []byte) (int, error) {
+	var encoded, err = encodeLog(w.encoder, data)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = w.w.Write(append(encoded, '\n'))
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// encodeLog decodes the JSON log line and encodes it with the encoder.
+func encodeLog(encoder LogEncoder, data []byte) ([]byte, error) {
+	var encoded []byte
+	var logs, err = decodeJSONLog(data)
+	if err != nil {
+		encoded, err = encoder.EncodeRaw(data, err)
+	} else {
+		encoded, err = encoder.Encode(logs)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode logs")
+	}
+	return encoded, nil
+}
+
+// decodeJSONLog decodes the JSON log line. The numbers are kept as json.Number, so
+// they are encoded back as they were logged.
+func decodeJSONLog(data []byte) (map[string]interface{}, error) {
+	var logs map[string]interface{}
+
+	var dec = json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var err = dec.Decode(&logs)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode JSON logs")
+	}
+	if logs == nil {
+		return nil, errors.New("could not decode JSON logs: not an object")
+	}
+	return logs, nil
+}
+
+// logstashLog is the logstash log format. The fields keep the JSON types of the logs.
+type logstashLog struct {
+	Timestamp       string                 `json:"@timestamp"`
+	LogstashVersion int                    `json:"@version"`
+	Fields          map[string]interface{} `json:"@fields"`
+	Message         string                 `json:"@message,omitempty"`
+}
+
+// LogstashFieldMapping maps the keys of the logs to the logstash log. The values of the
+// timestamp and message keys become @timestamp and @message. The other keys are renamed
+// in @fields with Fields, e.g. {"caller": "source"}, or kept as is.
+type LogstashFieldMapping struct {
+	Timestamp string
+	Message   string
+	Fields    map[string]string
+}
+
+// DefaultLogstashFieldMapping is the mapping of the logs of the go-kit JSONLogger.
+var DefaultLogstashFieldMapping = LogstashFieldMapping{
+	Timestamp: "ts",
+	Message:   "msg",
+}
+
+// LogstashEncoder encodes logs in logstash v1 format.
+type LogstashEncoder struct {
+	mapping LogstashFieldMapping
+}
+
+// NewLogstashEncoder returns a logstash v1 encoder.
+func NewLogstashEncoder(mapping LogstashFieldMapping) *LogstashEncoder {
+	return &LogstashEncoder{
+		mapping: mapping,
+	}
+}
+
+// Encode encodes the log in logstash format.
+func (e *LogstashEncoder) Encode(m map[string]interface{}) ([]byte, error) {
+	var l = logstashLog{
+		LogstashVersion: 1,
+		Fields:          make(map[string]interface{}),
+	}
+
+	for k, v := range m {
+		var s, isString = v.(string)
+		switch {
+		case k == e.mapping.Timestamp && isString:
+			l.Timestamp = s
+		case k == e.mapping.Message && isString:
+			l.Message = s
+		default:
+			if name, ok := e.mapping.Fields[k]; ok {
+				k = name
+			}
+			l.Fields[k] = v
+		}
+	}
+
+	if l.Timestamp == "" {
+		l.Timestamp = logTimestamp()
+	}
+
+	return json.Marshal(l)
+}
+
+// EncodeRaw encodes the line as the message of a logstash log, with the error explaining
+// why it could not be decoded.
+func (e *LogstashEncoder) EncodeRaw(data []byte, decodeErr error) ([]byte, error) {
+	var l = logstashLog{
+		Timestamp:       logTimestamp(),
+		LogstashVersion: 1,
+		Fields:          map[string]interface{}{"decode_error": decodeErr.Error()},
+		Message:         string(bytes.TrimSpace(data)),
+	}
+
+	return json.Marshal(l)
+}
+
+// ecsFields maps the keys of the logs to their ECS fields. The keys without ECS field
+// are kept as is.
+var ecsFields = map[string]string{
+	"level":             "log.level",
+	"component_name":    "service.name",
+	"component_id":      "service.id",
+	"component_version": "service.version",
+	"environment":       "service.environment",
+	"git_commit":        "labels.git_commit",
+	"correlation_id":    "trace.id",
+	"error":             "error.message",
+}
+
+// ECSEncoder encodes logs in the Elastic Common Schema, with dotted field names. The
+// caller becomes the log origin, and the duration 'took' the event duration in
+// nanoseconds.
+type ECSEncoder struct {
+	timestampKey string
+	messageKey   string
+}
+
+// NewECSEncoder returns an ECS encoder for the logs with the timestamp and message keys.
+func NewECSEncoder(timestampKey, messageKey string) *ECSEncoder {
+	return &ECSEncoder{
+		timestampKey: timestampKey,
+		messageKey:   messageKey,
+	}
+}
+
+// Encode encodes the log in ECS format.
+func (e *ECSEncoder) Encode(m map[string]interface{}) ([]byte, error) {
+	var l = map[string]interface{}{
+		"ecs.version": ecsVersion,
+	}
+
+	for k, v := range m {
+		var s, isString = v.(string)
+		switch {
+		case k == e.timestampKey && isString:
+			l["@timestamp"] = s
+		case k == e.messageKey && isString:
+			l["message"] = s
+		case k == "caller" && isString:
+			var i = strings.LastIndex(s, ":")
+			var line, err = strconv.Atoi(s[i+1:])
+			if i < 0 || err != nil {
+				l["log.origin.file.name"] = s
+				continue
+			}
+			l["log.origin.file.name"] = s[:i]
+			l["log.origin.file.line"] = line
+		case k == "took":
+			l["event.duration"] = ecsDuration(v)
+		default:
+			if name, ok := ecsFields[k]; ok {
+				k = name
+			}
+			l[k] = v
+		}
+	}
+
+	if _, ok := l["@timestamp"]; !ok {
+		l["@timestamp"] = logTimestamp()
+	}
+
+	return json.Marshal(l)
+}
+
+// EncodeRaw encodes the line as the message of an ECS log, with the error explaining
+// why it could not be decoded.
+func (e *ECSEncoder) EncodeRaw(data []byte, decodeErr error) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"@timestamp":    logTimestamp(),
+		"ecs.version":   ecsVersion,
+		"message":       string(bytes.TrimSpace(data)),
+		"error.message": decodeErr.Error(),
+	})
+}
+
+// ecsDuration returns the duration in nanoseconds. The go-kit JSONLogger logs the
+// durations with their String method, e.g. "1.5s".
+func ecsDuration(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d.Nanoseconds()
+		}
+	}
+	return v
+}
+
+// JSONEncoder encodes logs in JSON, as logged.
+type JSONEncoder struct{}
+
+// NewJSONEncoder returns a JSON encoder.
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{}
+}
+
+// Encode encodes the log in JSON.
+func (e *JSONEncoder) Encode(m map[string]interface{}) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// EncodeRaw encodes the line as the message of a JSON log, with the error explaining
+// why it could not be decoded.
+func (e *JSONEncoder) EncodeRaw(data []byte, decodeErr error) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"ts":           logTimestamp(),
+		"msg":          string(bytes.TrimSpace(data)),
+		"decode_error": decodeErr.Error(),
+	})
+}
+
+// logTimestamp returns the current time in the format of the go-kit timestamps.
+func logTimestamp() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,92 @@
+package elasticsearch_bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogEncoder(t *testing.T) {
+	for format, encoder := range map[string]LogEncoder{
+		LogFormatLogstashV1: &LogstashEncoder{},
+		LogFormatECS:        &ECSEncoder{},
+		LogFormatJSON:       &JSONEncoder{},
+	} {
+		var e, err = NewLogEncoder(format, DefaultLogstashFieldMapping)
+		assert.Nil(t, err)
+		assert.IsType(t, encoder, e)
+	}
+
+	var _, err = NewLogEncoder("gelf", DefaultLogstashFieldMapping)
+	assert.NotNil(t, err)
+}
+
+func TestECSEncoder(t *testing.T) {
+	var e = NewECSEncoder("ts", "msg")
+
+	var jsonLog = `{"msg":"health checks","level":"info","caller":"elasticsearch_bridge.go:120","component_name":"elasticsearch-bridge",
+		"component_id":"1234","component_version":"1.0","environment":"DEV","git_commit":"5fb7de0","correlation_id":"5678",
+		"took":"1.5s","unit":"redis","ts":"2018-02-13T06:27:07.123915229Z"}`
+	var logs, err = decodeJSONLog([]byte(jsonLog))
+	assert.Nil(t, err)
+
+	var ecsLog []byte
+	ecsLog, err = e.Encode(logs)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"@timestamp":"2018-02-13T06:27:07.123915229Z","ecs.version":"8.4.0","message":"health checks","log.level":"info",
+		"log.origin.file.name":"elasticsearch_bridge.go","log.origin.file.line":120,"service.name":"elasticsearch-bridge",
+		"service.id":"1234","service.version":"1.0","service.environment":"DEV","labels.git_commit":"5fb7de0","trace.id":"5678",
+		"event.duration":1500000000,"unit":"redis"}`, string(ecsLog))
+}
+
+func TestECSEncoderRaw(t *testing.T) {
+	var e = NewECSEncoder("ts", "msg")
+
+	var ecsLog, err = e.EncodeRaw([]byte("panic: runtime error\n"), assert.AnError)
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(ecsLog, &m))
+	assert.Equal(t, "panic: runtime error", m["message"])
+	assert.Equal(t, assert.AnError.Error(), m["error.message"])
+	assert.NotEmpty(t, m["@timestamp"])
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var e = NewJSONEncoder()
+
+	var logs, err = decodeJSONLog([]byte(`{"msg":"health checks","took":1500000000,"report":{"status":"OK"}}`))
+	assert.Nil(t, err)
+
+	var jsonLog []byte
+	jsonLog, err = e.Encode(logs)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"msg":"health checks","report":{"status":"OK"},"took":1500000000}`, string(jsonLog))
+}
+
+func TestEncodingWriter(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	var w = NewEncodingWriter(buf, NewECSEncoder("ts", "msg"))
+
+	// JSON log.
+	{
+		var line = `{"msg":"health checks","ts":"2018-02-13T06:27:07.123915229Z"}` + "\n"
+		var n, err = w.Write([]byte(line))
+		assert.Nil(t, err)
+		assert.Equal(t, len(line), n)
+		assert.Equal(t, `{"@timestamp":"2018-02-13T06:27:07.123915229Z","ecs.version":"8.4.0","message":"health checks"}`+"\n", buf.String())
+	}
+
+	// Raw line.
+	{
+		buf.Reset()
+		var n, err = w.Write([]byte("panic: runtime error\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, 21, n)
+		assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+		assert.Contains(t, buf.String(), `"message":"panic: runtime error"`)
+	}
+}
@@ -1,23 +1,20 @@
 package elasticsearch_bridge
 
 import (
-	"encoding/json"
+	"context"
+	"time"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 )
 
-// logstashLog is the logstash log format.
-type logstashLog struct {
-	Timestamp       string            `json:"@timestamp"`
-	LogstashVersion int               `json:"@version"`
-	Fields          map[string]string `json:"@fields"`
-	Message         string            `json:"@message,omitempty"`
-}
-
-// RedisWriter encodes logs in logstash format and writes them to Redis.
+// RedisWriter encodes logs and writes them to Redis.
 type RedisWriter struct {
-	redis Redis
-	key   string
+	redis   Redis
+	key     string
+	encoder LogEncoder
 }
 
 // Redis is the redis client interface.
@@ -25,59 +22,32 @@ type Redis interface {
 	Send(commandName string, args ...interface{}) error
 }
 
-// NewLogstashRedisWriter returns a writer that writes logs into a redis DB.
-func NewLogstashRedisWriter(redis Redis, key string) *RedisWriter {
+// NewRedisWriter returns a writer that encodes logs with the encoder and writes them
+// into a redis DB.
+func NewRedisWriter(redis Redis, key string, encoder LogEncoder) *RedisWriter {
 	return &RedisWriter{
-		redis: redis,
-		key:   key,
+		redis:   redis,
+		key:     key,
+		encoder: encoder,
 	}
 }
 
-// Write encodes logs in logstash format and writes them to Redis.
+// Write encodes logs and writes them to Redis. A line that is not a JSON object is not
+// lost, it is written as the message of a log.
 func (w *RedisWriter) Write(data []byte) (int, error) {
-	// The current logs are JSON formatted by the go-kit JSONLogger.
-	var logs = make(map[string]string)
-	{
-		var err = json.Unmarshal(data, &logs)
-		if err != nil {
-			return 0, errors.Wrap(err, "could not decode JSON logs")
-		}
-	}
-
-	// Encode to logstash format.
-	var logstashLog []byte
-	{
-		var err error
-		logstashLog, err = logstashEncode(logs)
-		if err != nil {
-			return 0, errors.Wrap(err, "could not encode logs to logstash format")
-		}
+	var encoded, err = encodeLog(w.encoder, data)
+	if err != nil {
+		return 0, err
 	}
 
 	// Write to Redis.
-	var err = w.redis.Send("RPUSH", w.key, logstashLog)
+	err = w.redis.Send("RPUSH", w.key, encoded)
 	if err != nil {
 		return 0, errors.Wrap(err, "could not write logs to Redis")
 	}
 	return len(data), nil
 }
 
-func logstashEncode(m map[string]string) ([]byte, error) {
-	var timestamp = m["ts"]
-	delete(m, "ts")
-	var msg = m["msg"]
-	delete(m, "msg")
-
-	var l = logstashLog{
-		Timestamp:       timestamp,
-		LogstashVersion: 1,
-		Fields:          m,
-		Message:         msg,
-	}
-
-	return json.Marshal(l)
-}
-
 // NoopRedis is a Redis client that does nothing.
 type NoopRedis struct{}
 
@@ -94,3 +64,25 @@ func (r *NoopRedis) Send(commandName string, args ...interface{}) error { return
 
 // Flush does nothing.
 func (r *NoopRedis) Flush() error { return nil }
+
+// MakeElasticsearchLoggingMW makes a logging middleware for the Elasticsearch client. It
+// logs each request at debug level, with the correlation ID of the context if there is one.
+func MakeElasticsearchLoggingMW(logger log.Logger) ElasticsearchMiddleware {
+	return func(next ElasticsearchRequestFunc) ElasticsearchRequestFunc {
+		return func(ctx context.Context, req ElasticsearchRequest) (ElasticsearchResponse, error) {
+			var begin = time.Now()
+			var resp, err = next(ctx, req)
+
+			var kv = []interface{}{"operation", req.Operation, "method", req.Method, "path", req.Path, "status", resp.StatusCode, "took", time.Since(begin)}
+			if id, ok := correlation.ID(ctx); ok {
+				kv = append(kv, "correlation_id", id)
+			}
+			if err != nil {
+				kv = append(kv, "error_type", resp.ErrorType, "error", err)
+			}
+			level.Debug(logger).Log(kv...)
+
+			return resp, err
+		}
+	}
+}
@@ -5,10 +5,16 @@ package elasticsearch_bridge
 
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	"github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge/mock"
+	"github.com/go-kit/kit/log"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,15 +24,63 @@ func TestLogstashRedisWriter(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockRedis = mock.NewRedis(mockCtrl)
 
-	var w = NewLogstashRedisWriter(mockRedis, "redisKey")
+	var w = NewRedisWriter(mockRedis, "redisKey", NewLogstashEncoder(DefaultLogstashFieldMapping))
 
 	var jsonLog = "{\"msg\":\"logstash log\",\"caller\":\"elasticsearch_bridge.go:120\",\"component_name\":\"elasticsearch-bridge\",\"component_version\":\"1.0\",\"environment\":\"DEV\",\"git_commit\":\"5fb7de0d7ae3f3d5f5d6a322b2344bdab645fd33\",\"ts\":\"2018-02-13T06:27:07.123915229Z\"}"
 	mockRedis.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).Return(nil).Times(1)
 	w.Write([]byte(jsonLog))
 }
 
+func TestLogstashRedisWriterTypes(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockRedis = mock.NewRedis(mockCtrl)
+
+	var w = NewRedisWriter(mockRedis, "redisKey", NewLogstashEncoder(DefaultLogstashFieldMapping))
+
+	var sent []byte
+	mockRedis.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).DoAndReturn(func(_ string, args ...interface{}) error {
+		sent = args[1].([]byte)
+		return nil
+	}).Times(1)
+
+	var jsonLog = `{"msg":"health checks","took":1500000000,"ratio":0.75,"stale":false,"report":{"status":"OK"},"ts":"2018-02-13T06:27:07.123915229Z"}` + "\n"
+	var n, err = w.Write([]byte(jsonLog))
+	assert.Nil(t, err)
+	assert.Equal(t, len(jsonLog), n)
+
+	assert.JSONEq(t, `{"@timestamp":"2018-02-13T06:27:07.123915229Z","@version":1,"@message":"health checks",
+		"@fields":{"took":1500000000,"ratio":0.75,"stale":false,"report":{"status":"OK"}}}`, string(sent))
+}
+
+func TestLogstashRedisWriterRaw(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockRedis = mock.NewRedis(mockCtrl)
+
+	var w = NewRedisWriter(mockRedis, "redisKey", NewLogstashEncoder(DefaultLogstashFieldMapping))
+
+	var sent []byte
+	mockRedis.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).DoAndReturn(func(_ string, args ...interface{}) error {
+		sent = args[1].([]byte)
+		return nil
+	}).AnyTimes()
+
+	for _, line := range []string{"panic: runtime error\n", `["not", "an", "object"]`, "null"} {
+		var n, err = w.Write([]byte(line))
+		assert.Nil(t, err)
+		assert.Equal(t, len(line), n)
+
+		var m = map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(sent, &m))
+		assert.Equal(t, strings.TrimSpace(line), m["@message"])
+		assert.NotEmpty(t, m["@timestamp"])
+		assert.Contains(t, m["@fields"], "decode_error")
+	}
+}
+
 func TestLogstashEncode(t *testing.T) {
-	var logs = map[string]string{
+	var logs = map[string]interface{}{
 		"msg":               "logstash log",
 		"caller":            "elasticsearch_bridge.go:120",
 		"component_name":    "elasticsearch-bridge",
@@ -36,7 +90,7 @@ func TestLogstashEncode(t *testing.T) {
 		"ts":                "2018-02-13T06:27:07.123915229Z",
 	}
 
-	var logstashLog, err = logstashEncode(logs)
+	var logstashLog, err = NewLogstashEncoder(DefaultLogstashFieldMapping).Encode(logs)
 	assert.Nil(t, err)
 
 	var m = map[string]interface{}{}
@@ -58,6 +112,35 @@ func TestLogstashEncode(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestLogstashEncodeFieldMapping(t *testing.T) {
+	var mapping = LogstashFieldMapping{
+		Timestamp: "time",
+		Message:   "message",
+		Fields:    map[string]string{"caller": "source"},
+	}
+	var logs = map[string]interface{}{
+		"message": "logstash log",
+		"caller":  "elasticsearch_bridge.go:120",
+		"time":    "2018-02-13T06:27:07.123915229Z",
+		"msg":     "kept",
+	}
+
+	var logstashLog, err = NewLogstashEncoder(mapping).Encode(logs)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"@timestamp":"2018-02-13T06:27:07.123915229Z","@version":1,"@message":"logstash log",
+		"@fields":{"source":"elasticsearch_bridge.go:120","msg":"kept"}}`, string(logstashLog))
+
+	// A message that is not a string stays in the fields, and the timestamp defaults to now.
+	logstashLog, err = NewLogstashEncoder(mapping).Encode(map[string]interface{}{"message": json.Number("1")})
+	assert.Nil(t, err)
+
+	var m = map[string]interface{}{}
+	json.Unmarshal(logstashLog, &m)
+	assert.NotEmpty(t, m["@timestamp"])
+	assert.Nil(t, m["@message"])
+	assert.Equal(t, map[string]interface{}{"message": float64(1)}, m["@fields"])
+}
+
 func TestNoopRedis(t *testing.T) {
 	var noopRedis = &NoopRedis{}
 
@@ -71,3 +154,39 @@ func TestNoopRedis(t *testing.T) {
 
 	assert.Nil(t, noopRedis.Flush())
 }
+
+func TestElasticsearchLoggingMW(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	var logger = log.NewJSONLogger(buf)
+
+	var next = func(context.Context, ElasticsearchRequest) (ElasticsearchResponse, error) {
+		return ElasticsearchResponse{StatusCode: 404, ErrorType: "index_not_found_exception"}, fmt.Errorf("fail")
+	}
+	var m = MakeElasticsearchLoggingMW(logger)(next)
+
+	// With correlation ID.
+	{
+		var ctx = correlation.WithID(context.Background(), "123")
+		m(ctx, ElasticsearchRequest{Operation: "get_index", Method: "GET", Path: "index"})
+
+		var l = map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &l))
+		assert.Equal(t, "debug", l["level"])
+		assert.Equal(t, "get_index", l["operation"])
+		assert.Equal(t, "GET", l["method"])
+		assert.Equal(t, "index", l["path"])
+		assert.Equal(t, 404.0, l["status"])
+		assert.Equal(t, "123", l["correlation_id"])
+		assert.Equal(t, "index_not_found_exception", l["error_type"])
+	}
+
+	// Without correlation ID.
+	{
+		buf.Reset()
+		m(context.Background(), ElasticsearchRequest{Operation: "get_index", Method: "GET", Path: "index"})
+
+		var l = map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &l))
+		assert.NotContains(t, l, "correlation_id")
+	}
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	metrics "github.com/go-kit/kit/metrics"
 	influx "github.com/go-kit/kit/metrics/influx"
 	gomock "github.com/golang/mock/gomock"
 	v2 "github.com/influxdata/influxdb/client/v2"
@@ -12,6 +13,104 @@ import (
 	time "time"
 )
 
+// Metrics is a mock of Metrics interface
+type Metrics struct {
+	ctrl     *gomock.Controller
+	recorder *MetricsMockRecorder
+}
+
+// MetricsMockRecorder is the mock recorder for Metrics
+type MetricsMockRecorder struct {
+	mock *Metrics
+}
+
+// NewMetrics creates a new mock instance
+func NewMetrics(ctrl *gomock.Controller) *Metrics {
+	mock := &Metrics{ctrl: ctrl}
+	mock.recorder = &MetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *Metrics) EXPECT() *MetricsMockRecorder {
+	return m.recorder
+}
+
+// NewCounter mocks base method
+func (m *Metrics) NewCounter(name string, labelNames ...string) metrics.Counter {
+	varargs := []interface{}{name}
+	for _, a := range labelNames {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "NewCounter", varargs...)
+	ret0, _ := ret[0].(metrics.Counter)
+	return ret0
+}
+
+// NewCounter indicates an expected call of NewCounter
+func (mr *MetricsMockRecorder) NewCounter(name interface{}, labelNames ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{name}, labelNames...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewCounter", reflect.TypeOf((*Metrics)(nil).NewCounter), varargs...)
+}
+
+// NewGauge mocks base method
+func (m *Metrics) NewGauge(name string, labelNames ...string) metrics.Gauge {
+	varargs := []interface{}{name}
+	for _, a := range labelNames {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "NewGauge", varargs...)
+	ret0, _ := ret[0].(metrics.Gauge)
+	return ret0
+}
+
+// NewGauge indicates an expected call of NewGauge
+func (mr *MetricsMockRecorder) NewGauge(name interface{}, labelNames ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{name}, labelNames...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewGauge", reflect.TypeOf((*Metrics)(nil).NewGauge), varargs...)
+}
+
+// NewHistogram mocks base method
+func (m *Metrics) NewHistogram(name string, labelNames ...string) metrics.Histogram {
+	varargs := []interface{}{name}
+	for _, a := range labelNames {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "NewHistogram", varargs...)
+	ret0, _ := ret[0].(metrics.Histogram)
+	return ret0
+}
+
+// NewHistogram indicates an expected call of NewHistogram
+func (mr *MetricsMockRecorder) NewHistogram(name interface{}, labelNames ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{name}, labelNames...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewHistogram", reflect.TypeOf((*Metrics)(nil).NewHistogram), varargs...)
+}
+
+// Ping mocks base method
+func (m *Metrics) Ping(timeout time.Duration) (time.Duration, string, error) {
+	ret := m.ctrl.Call(m, "Ping", timeout)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Ping indicates an expected call of Ping
+func (mr *MetricsMockRecorder) Ping(timeout interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*Metrics)(nil).Ping), timeout)
+}
+
+// WriteLoop mocks base method
+func (m *Metrics) WriteLoop(c <-chan time.Time) {
+	m.ctrl.Call(m, "WriteLoop", c)
+}
+
+// WriteLoop indicates an expected call of WriteLoop
+func (mr *MetricsMockRecorder) WriteLoop(c interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLoop", reflect.TypeOf((*Metrics)(nil).WriteLoop), c)
+}
+
 // Influx is a mock of Influx interface
 type Influx struct {
 	ctrl     *gomock.Controller
@@ -35,6 +134,18 @@ func (m *Influx) EXPECT() *InfluxMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method
+func (m *Influx) Close() error {
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *InfluxMockRecorder) Close() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*Influx)(nil).Close))
+}
+
 // Ping mocks base method
 func (m *Influx) Ping(timeout time.Duration) (time.Duration, string, error) {
 	ret := m.ctrl.Call(m, "Ping", timeout)
@@ -61,18 +172,6 @@ func (mr *InfluxMockRecorder) Write(bp interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*Influx)(nil).Write), bp)
 }
 
-// Close mocks base method
-func (m *Influx) Close() error {
-	ret := m.ctrl.Call(m, "Close")
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Close indicates an expected call of Close
-func (mr *InfluxMockRecorder) Close() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*Influx)(nil).Close))
-}
-
 // GoKitMetrics is a mock of GoKitMetrics interface
 type GoKitMetrics struct {
 	ctrl     *gomock.Controller
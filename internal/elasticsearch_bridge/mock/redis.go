@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge (interfaces: RedisPool)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	redis "github.com/garyburd/redigo/redis"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// RedisPool is a mock of RedisPool interface
+type RedisPool struct {
+	ctrl     *gomock.Controller
+	recorder *RedisPoolMockRecorder
+}
+
+// RedisPoolMockRecorder is the mock recorder for RedisPool
+type RedisPoolMockRecorder struct {
+	mock *RedisPool
+}
+
+// NewRedisPool creates a new mock instance
+func NewRedisPool(ctrl *gomock.Controller) *RedisPool {
+	mock := &RedisPool{ctrl: ctrl}
+	mock.recorder = &RedisPoolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *RedisPool) EXPECT() *RedisPoolMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method
+func (m *RedisPool) Get() redis.Conn {
+	ret := m.ctrl.Call(m, "Get")
+	ret0, _ := ret[0].(redis.Conn)
+	return ret0
+}
+
+// Get indicates an expected call of Get
+func (mr *RedisPoolMockRecorder) Get() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*RedisPool)(nil).Get))
+}
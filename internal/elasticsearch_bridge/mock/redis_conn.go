@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/garyburd/redigo/redis (interfaces: Conn)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// RedisConn is a mock of Conn interface
+type RedisConn struct {
+	ctrl     *gomock.Controller
+	recorder *RedisConnMockRecorder
+}
+
+// RedisConnMockRecorder is the mock recorder for RedisConn
+type RedisConnMockRecorder struct {
+	mock *RedisConn
+}
+
+// NewRedisConn creates a new mock instance
+func NewRedisConn(ctrl *gomock.Controller) *RedisConn {
+	mock := &RedisConn{ctrl: ctrl}
+	mock.recorder = &RedisConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *RedisConn) EXPECT() *RedisConnMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method
+func (m *RedisConn) Close() error {
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *RedisConnMockRecorder) Close() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*RedisConn)(nil).Close))
+}
+
+// Do mocks base method
+func (m *RedisConn) Do(arg0 string, arg1 ...interface{}) (interface{}, error) {
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Do", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do
+func (mr *RedisConnMockRecorder) Do(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*RedisConn)(nil).Do), varargs...)
+}
+
+// Err mocks base method
+func (m *RedisConn) Err() error {
+	ret := m.ctrl.Call(m, "Err")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Err indicates an expected call of Err
+func (mr *RedisConnMockRecorder) Err() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Err", reflect.TypeOf((*RedisConn)(nil).Err))
+}
+
+// Flush mocks base method
+func (m *RedisConn) Flush() error {
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush
+func (mr *RedisConnMockRecorder) Flush() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*RedisConn)(nil).Flush))
+}
+
+// Receive mocks base method
+func (m *RedisConn) Receive() (interface{}, error) {
+	ret := m.ctrl.Call(m, "Receive")
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive
+func (mr *RedisConnMockRecorder) Receive() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*RedisConn)(nil).Receive))
+}
+
+// Send mocks base method
+func (m *RedisConn) Send(arg0 string, arg1 ...interface{}) error {
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Send", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *RedisConnMockRecorder) Send(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*RedisConn)(nil).Send), varargs...)
+}
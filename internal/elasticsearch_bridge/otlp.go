@@ -0,0 +1,212 @@
+package elasticsearch_bridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+)
+
+// OTLP protocols.
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+)
+
+// OTLPConfig is the configuration of the OTLP transport. With gRPC, the endpoint is the
+// host:port of the collector. With HTTP, it is the URL of the traces, e.g.
+// http://collector:4318/v1/traces. With TLS, the collector certificate is verified with
+// the CA, or the system CAs if there is none, and the client presents its certificate if
+// there is one (mTLS). The spans are exported by batches of BatchSize spans at most.
+type OTLPConfig struct {
+	Protocol   string
+	Endpoint   string
+	Timeout    time.Duration
+	BatchSize  int
+	TLS        bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// OTLPTransport is the OpenTelemetry OTLP exporter of the spans to the collector, over
+// gRPC or HTTP, with the connection used to health check the collector.
+type OTLPTransport struct {
+	exporter  *otlptrace.Exporter
+	ping      func(context.Context, *coltracepb.ExportTraceServiceRequest) error
+	closer    io.Closer
+	timeout   time.Duration
+	batchSize int
+}
+
+// NewOTLPTransport returns an OTLP transport. With gRPC, the connection to the collector
+// is established lazily. As with the Jaeger reporter, the spans of a failed export are
+// dropped, they are not retried.
+func NewOTLPTransport(c OTLPConfig) (*OTLPTransport, error) {
+	if c.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid OTLP batch size %d, it must be positive", c.BatchSize)
+	}
+
+	var t = &OTLPTransport{
+		timeout:   c.Timeout,
+		batchSize: c.BatchSize,
+	}
+
+	var tlsConfig *tls.Config
+	if c.TLS {
+		var err error
+		tlsConfig, err = otlpTLSConfig(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var client otlptrace.Client
+	switch c.Protocol {
+	case OTLPProtocolGRPC:
+		var opt = grpc.WithInsecure()
+		if tlsConfig != nil {
+			opt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		}
+		var conn, err = grpc.Dial(c.Endpoint, opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create OTLP gRPC connection")
+		}
+		t.closer = conn
+
+		// The exporter and the health check share the connection.
+		client = otlptracegrpc.NewClient(
+			otlptracegrpc.WithGRPCConn(conn),
+			otlptracegrpc.WithTimeout(c.Timeout),
+			otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}),
+		)
+		var traceClient = coltracepb.NewTraceServiceClient(conn)
+		t.ping = func(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) error {
+			var _, err = traceClient.Export(ctx, req)
+			return err
+		}
+	case OTLPProtocolHTTP:
+		var u, err = url.Parse(c.Endpoint)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid OTLP collector URL")
+		}
+
+		var opts = []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(u.Host),
+			otlptracehttp.WithURLPath(u.Path),
+			otlptracehttp.WithTimeout(c.Timeout),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+		}
+		var httpClient = &http.Client{}
+		if tlsConfig != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+			httpClient.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		client = otlptracehttp.NewClient(opts...)
+		t.ping = func(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) error {
+			return otlpHTTPExport(ctx, httpClient, c.Endpoint, req)
+		}
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol '%s'", c.Protocol)
+	}
+
+	var err error
+	t.exporter, err = otlptrace.New(context.Background(), client)
+	if err != nil {
+		t.Close()
+		return nil, errors.Wrap(err, "could not create OTLP exporter")
+	}
+	return t, nil
+}
+
+// otlpHTTPExport posts the export request to the collector URL.
+func otlpHTTPExport(ctx context.Context, client *http.Client, endpoint string, req *coltracepb.ExportTraceServiceRequest) error {
+	var body, err = proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	var r *http.Request
+	r, err = http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+
+	var resp *http.Response
+	resp, err = client.Do(r.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OTLP collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// otlpTLSConfig returns the TLS configuration of the connection to the collector.
+func otlpTLSConfig(c OTLPConfig) (*tls.Config, error) {
+	var config = &tls.Config{ServerName: c.ServerName}
+
+	if c.CAFile != "" {
+		var pem, err = ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read OTLP collector CA")
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in OTLP collector CA '%s'", c.CAFile)
+		}
+	}
+
+	switch {
+	case c.CertFile != "" && c.KeyFile != "":
+		var cert, err = tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load OTLP client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case c.CertFile != "" || c.KeyFile != "":
+		return nil, fmt.Errorf("both the certificate and the key are needed for mTLS")
+	}
+	return config, nil
+}
+
+// Ping exports an empty request, which the collector accepts without storing anything. The
+// exporter skips the empty exports, so the request is sent by the transport.
+func (t *OTLPTransport) Ping(ctx context.Context) error {
+	var ctx2, cancel = context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.ping(ctx2, &coltracepb.ExportTraceServiceRequest{})
+}
+
+// Close closes the gRPC connection to the collector. The exporter is shut down with the
+// tracer provider.
+func (t *OTLPTransport) Close() error {
+	if t.closer == nil {
+		return nil
+	}
+	return t.closer.Close()
+}
@@ -0,0 +1,118 @@
+package elasticsearch_bridge
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPHTTPTransport(t *testing.T) {
+	var status = http.StatusOK
+	var contentType string
+	var body []byte
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer s.Close()
+
+	var transport, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolHTTP, Endpoint: s.URL + "/v1/traces", Timeout: time.Second, BatchSize: 2})
+	assert.Nil(t, err)
+	defer transport.Close()
+
+	// Ping sends an empty request.
+	assert.Nil(t, transport.Ping(context.Background()))
+	assert.Equal(t, "application/x-protobuf", contentType)
+	var req = &coltracepb.ExportTraceServiceRequest{}
+	assert.Nil(t, proto.Unmarshal(body, req))
+	assert.Equal(t, 0, len(req.ResourceSpans))
+
+	// Collector failure.
+	status = http.StatusServiceUnavailable
+	assert.NotNil(t, transport.Ping(context.Background()))
+}
+
+func TestOTLPHTTPTransportTLS(t *testing.T) {
+	var s = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	var dir, _ = ioutil.TempDir("", "otlp")
+	defer os.RemoveAll(dir)
+	var caFile = filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}), 0600)
+
+	// Without the CA, the collector certificate is rejected.
+	var transport, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolHTTP, Endpoint: s.URL + "/v1/traces", Timeout: time.Second, BatchSize: 10, TLS: true})
+	assert.Nil(t, err)
+	assert.NotNil(t, transport.Ping(context.Background()))
+
+	transport, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolHTTP, Endpoint: s.URL + "/v1/traces", Timeout: time.Second, BatchSize: 10, TLS: true, CAFile: caFile})
+	assert.Nil(t, err)
+	assert.Nil(t, transport.Ping(context.Background()))
+}
+
+func TestOTLPTLSConfigFailure(t *testing.T) {
+	// Unknown CA file.
+	var _, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolGRPC, Endpoint: "localhost:4317", BatchSize: 10, TLS: true, CAFile: "/nonexistent/ca.pem"})
+	assert.NotNil(t, err)
+
+	// Key without certificate.
+	_, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolGRPC, Endpoint: "localhost:4317", BatchSize: 10, TLS: true, KeyFile: "key.pem"})
+	assert.NotNil(t, err)
+}
+
+// collector is an OTLP trace service that records the export requests.
+type collector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	requests chan *coltracepb.ExportTraceServiceRequest
+}
+
+func (c *collector) Export(_ context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	c.requests <- req
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestOTLPGRPCTransport(t *testing.T) {
+	var c = &collector{requests: make(chan *coltracepb.ExportTraceServiceRequest, 1)}
+	var server = grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(server, c)
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	var transport *OTLPTransport
+	transport, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolGRPC, Endpoint: listener.Addr().String(), Timeout: 5 * time.Second, BatchSize: 10})
+	assert.Nil(t, err)
+	defer transport.Close()
+
+	assert.Nil(t, transport.Ping(context.Background()))
+	assert.Equal(t, 0, len((<-c.requests).ResourceSpans))
+}
+
+func TestNewOTLPTransportUnknownProtocol(t *testing.T) {
+	var _, err = NewOTLPTransport(OTLPConfig{Protocol: "thrift", BatchSize: 10})
+	assert.NotNil(t, err)
+}
+
+func TestNewOTLPTransportInvalidBatchSize(t *testing.T) {
+	for _, batchSize := range []int{0, -1} {
+		var _, err = NewOTLPTransport(OTLPConfig{Protocol: OTLPProtocolHTTP, Endpoint: "http://localhost:4318/v1/traces", BatchSize: batchSize})
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,114 @@
+package elasticsearch_bridge
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	kit_prometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var invalidMetricNameChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// PrometheusMetrics exposes the metrics to Prometheus. Prometheus pulls the metrics
+// from the handler, so there is nothing to write.
+type PrometheusMetrics struct {
+	namespace     string
+	scrapeMaxAge  time.Duration
+	registry      *prometheus.Registry
+	mutex         sync.Mutex
+	lastScrape    time.Time
+	everScraped   bool
+	scrapeHandler http.Handler
+}
+
+// NewPrometheusMetrics returns a PrometheusMetrics. The metrics names are prefixed by the
+// namespace. Prometheus is expected to scrape the metrics at least once per scrape max age.
+func NewPrometheusMetrics(namespace string, scrapeMaxAge time.Duration) *PrometheusMetrics {
+	var registry = prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector())
+	registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+
+	return &PrometheusMetrics{
+		namespace:     sanitizeMetricName(namespace),
+		scrapeMaxAge:  scrapeMaxAge,
+		registry:      registry,
+		lastScrape:    time.Now(),
+		scrapeHandler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// NewCounter returns a go-kit Counter. The label names are the keys that may be used in With.
+func (m *PrometheusMetrics) NewCounter(name string, labelNames ...string) metrics.Counter {
+	var cv = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Name:      sanitizeMetricName(name),
+		Help:      name,
+	}, labelNames)
+	m.registry.MustRegister(cv)
+	return kit_prometheus.NewCounter(cv)
+}
+
+// NewGauge returns a go-kit Gauge. The label names are the keys that may be used in With.
+func (m *PrometheusMetrics) NewGauge(name string, labelNames ...string) metrics.Gauge {
+	var gv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: m.namespace,
+		Name:      sanitizeMetricName(name),
+		Help:      name,
+	}, labelNames)
+	m.registry.MustRegister(gv)
+	return kit_prometheus.NewGauge(gv)
+}
+
+// NewHistogram returns a go-kit Histogram with the default buckets. The label names are
+// the keys that may be used in With.
+func (m *PrometheusMetrics) NewHistogram(name string, labelNames ...string) metrics.Histogram {
+	var hv = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Name:      sanitizeMetricName(name),
+		Help:      name,
+	}, labelNames)
+	m.registry.MustRegister(hv)
+	return kit_prometheus.NewHistogram(hv)
+}
+
+// WriteLoop does nothing, the metrics are pulled by Prometheus.
+func (m *PrometheusMetrics) WriteLoop(c <-chan time.Time) {}
+
+// Ping returns the time elapsed since the last scrape. It returns an error if Prometheus
+// did not scrape the metrics in the last scrape max age. Before the first scrape, the
+// elapsed time is counted from the creation of the metrics.
+func (m *PrometheusMetrics) Ping(timeout time.Duration) (time.Duration, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var elapsed = time.Since(m.lastScrape)
+	if elapsed > m.scrapeMaxAge {
+		if !m.everScraped {
+			return elapsed, "", fmt.Errorf("metrics not scraped since startup %s ago", elapsed)
+		}
+		return elapsed, "", fmt.Errorf("metrics not scraped since %s", elapsed)
+	}
+	return elapsed, "", nil
+}
+
+// ServeHTTP serves the metrics in the Prometheus exposition format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mutex.Lock()
+	m.lastScrape = time.Now()
+	m.everScraped = true
+	m.mutex.Unlock()
+
+	m.scrapeHandler.ServeHTTP(w, r)
+}
+
+// sanitizeMetricName replaces the characters that are not allowed in Prometheus metric
+// names by underscores.
+func sanitizeMetricName(name string) string {
+	return invalidMetricNameChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,65 @@
+package elasticsearch_bridge
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	var prometheusMetrics = NewPrometheusMetrics("elasticsearch-bridge", 1*time.Minute)
+
+	prometheusMetrics.NewCounter("health_checks", "unit").With("unit", "redis").Add(1)
+	prometheusMetrics.NewGauge("unit-status", "unit").With("unit", "redis").Set(1)
+	prometheusMetrics.NewHistogram("duration").Observe(0.2)
+
+	var w = httptest.NewRecorder()
+	prometheusMetrics.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	var body, _ = ioutil.ReadAll(w.Result().Body)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, string(body), `elasticsearch_bridge_health_checks{unit="redis"} 1`)
+	assert.Contains(t, string(body), `elasticsearch_bridge_unit_status{unit="redis"} 1`)
+	assert.Contains(t, string(body), `elasticsearch_bridge_duration_count 1`)
+	assert.Contains(t, string(body), `go_goroutines`)
+}
+
+func TestPrometheusPing(t *testing.T) {
+	// Not scraped since startup.
+	{
+		var prometheusMetrics = NewPrometheusMetrics("elasticsearch_bridge", 10*time.Millisecond)
+
+		var _, _, err = prometheusMetrics.Ping(1 * time.Second)
+		assert.Nil(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		var duration, _, err2 = prometheusMetrics.Ping(1 * time.Second)
+		assert.True(t, duration > 10*time.Millisecond)
+		assert.NotNil(t, err2)
+	}
+
+	// Scraped.
+	{
+		var prometheusMetrics = NewPrometheusMetrics("elasticsearch_bridge", 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		prometheusMetrics.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+		var duration, _, err = prometheusMetrics.Ping(1 * time.Second)
+		assert.True(t, duration < 10*time.Millisecond)
+		assert.Nil(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		_, _, err = prometheusMetrics.Ping(1 * time.Second)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestPrometheusWriteLoop(t *testing.T) {
+	var prometheusMetrics = NewPrometheusMetrics("elasticsearch_bridge", 1*time.Minute)
+
+	// The metrics are pulled, the write loop returns immediately.
+	prometheusMetrics.WriteLoop(make(chan time.Time))
+}
@@ -0,0 +1,257 @@
+package elasticsearch_bridge
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/go-kit/kit/metrics"
+	"github.com/pkg/errors"
+)
+
+// Drop policies of the Redis shipper, applied when its queue is full.
+const (
+	DropNewest = "drop-newest"
+	DropOldest = "drop-oldest"
+)
+
+// RedisPool is the interface of the Redis connection pool.
+type RedisPool interface {
+	Get() redis.Conn
+}
+
+// NewRedisPool returns a Redis connection pool. The connections are checked with a PING
+// when they are borrowed after being idle, so a broken connection is replaced by a new one.
+func NewRedisPool(addr, password string, database int) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 4 * time.Minute,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr, redis.DialDatabase(database), redis.DialPassword(password))
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			if time.Since(t) < time.Minute {
+				return nil
+			}
+			var _, err = c.Do("PING")
+			return err
+		},
+	}
+}
+
+// PooledRedis is a Redis client that executes each command on a connection of the pool.
+type PooledRedis struct {
+	pool RedisPool
+}
+
+// NewPooledRedis returns a Redis client backed by the connection pool.
+func NewPooledRedis(pool RedisPool) *PooledRedis {
+	return &PooledRedis{
+		pool: pool,
+	}
+}
+
+// Do executes the command on a connection of the pool.
+func (r *PooledRedis) Do(commandName string, args ...interface{}) (interface{}, error) {
+	var conn = r.pool.Get()
+	defer conn.Close()
+	return conn.Do(commandName, args...)
+}
+
+// RedisShipperConfig is the configuration of the Redis shipper.
+type RedisShipperConfig struct {
+	Key           string
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	DropPolicy    string
+	MinBackoff    time.Duration
+	MaxBackoff    time.Duration
+}
+
+// RedisShipper encodes logs and ships them to Redis. The logs are queued in memory and
+// pushed in pipelined RPUSH batches by Run. When Redis is unreachable, the batch is kept
+// and retried with an exponential backoff, on a new connection of the pool. When the queue
+// is full, logs are dropped according to the drop policy.
+type RedisShipper struct {
+	pool    RedisPool
+	encoder LogEncoder
+	config  RedisShipperConfig
+	queue   chan []byte
+
+	// droppedOnWrite counts the logs dropped by Write, reported by Run.
+	droppedOnWrite int64
+
+	backoff time.Duration
+	retryAt time.Time
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewRedisShipper returns a Redis shipper that encodes logs with the encoder.
+func NewRedisShipper(pool RedisPool, encoder LogEncoder, c RedisShipperConfig) (*RedisShipper, error) {
+	switch {
+	case c.DropPolicy != DropNewest && c.DropPolicy != DropOldest:
+		return nil, fmt.Errorf("unknown drop policy '%s'", c.DropPolicy)
+	case c.QueueSize <= 0:
+		return nil, fmt.Errorf("invalid queue size %d", c.QueueSize)
+	case c.BatchSize <= 0:
+		return nil, fmt.Errorf("invalid batch size %d", c.BatchSize)
+	}
+
+	return &RedisShipper{
+		pool:    pool,
+		encoder: encoder,
+		config:  c,
+		queue:   make(chan []byte, c.QueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}, nil
+}
+
+// Write encodes logs and queues them. It never blocks: when the queue is full, the new
+// log or the oldest queued log is dropped.
+func (s *RedisShipper) Write(data []byte) (int, error) {
+	var encoded, err = encodeLog(s.encoder, data)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		select {
+		case s.queue <- encoded:
+			return len(data), nil
+		default:
+		}
+
+		if s.config.DropPolicy == DropNewest {
+			atomic.AddInt64(&s.droppedOnWrite, 1)
+			return len(data), nil
+		}
+
+		select {
+		case <-s.queue:
+			atomic.AddInt64(&s.droppedOnWrite, 1)
+		default:
+		}
+	}
+}
+
+// Run ships the queued logs to Redis until Close is called. A batch is shipped when it is
+// full or every flush interval. The sent and dropped counters count the logs.
+func (s *RedisShipper) Run(sent, dropped metrics.Counter) {
+	defer close(s.stopped)
+
+	var tic = time.NewTicker(s.config.FlushInterval)
+	defer tic.Stop()
+
+	var batch [][]byte
+	for {
+		select {
+		case l := <-s.queue:
+			batch = append(batch, l)
+			if len(batch) >= s.config.BatchSize {
+				batch = s.ship(batch, sent, dropped, false)
+			}
+		case <-tic.C:
+			batch = s.ship(batch, sent, dropped, false)
+		case <-s.done:
+			for {
+				select {
+				case l := <-s.queue:
+					batch = append(batch, l)
+					continue
+				default:
+				}
+				break
+			}
+			s.ship(batch, sent, dropped, true)
+			return
+		}
+	}
+}
+
+// Close stops Run after a final flush of the queued logs.
+func (s *RedisShipper) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+// ship pushes the batch to Redis and returns the logs that remain to ship. While backing
+// off, the batch is kept without trying to push it, unless it is the final flush. The
+// batch is bounded by the queue size.
+func (s *RedisShipper) ship(batch [][]byte, sent, dropped metrics.Counter, final bool) [][]byte {
+	if n := atomic.SwapInt64(&s.droppedOnWrite, 0); n > 0 {
+		dropped.Add(float64(n))
+	}
+
+	if len(batch) == 0 || (!final && time.Now().Before(s.retryAt)) {
+		return s.bound(batch, dropped)
+	}
+
+	var err = s.push(batch)
+	if err != nil {
+		switch {
+		case s.backoff == 0:
+			s.backoff = s.config.MinBackoff
+		case s.backoff*2 > s.config.MaxBackoff:
+			s.backoff = s.config.MaxBackoff
+		default:
+			s.backoff *= 2
+		}
+		s.retryAt = time.Now().Add(s.backoff)
+
+		if final {
+			dropped.Add(float64(len(batch)))
+		}
+		return s.bound(batch, dropped)
+	}
+
+	s.backoff = 0
+	s.retryAt = time.Time{}
+	sent.Add(float64(len(batch)))
+	return batch[:0]
+}
+
+// bound drops the logs of the batch exceeding the queue size, according to the drop policy.
+func (s *RedisShipper) bound(batch [][]byte, dropped metrics.Counter) [][]byte {
+	var n = len(batch) - s.config.QueueSize
+	if n <= 0 {
+		return batch
+	}
+
+	dropped.Add(float64(n))
+	if s.config.DropPolicy == DropNewest {
+		return batch[:s.config.QueueSize]
+	}
+	return append(batch[:0], batch[n:]...)
+}
+
+// push pipelines the RPUSH of the batch on a connection of the pool. The connection is
+// closed on error, so the pool dials a new one on the next push.
+func (s *RedisShipper) push(batch [][]byte) error {
+	var conn = s.pool.Get()
+	defer conn.Close()
+
+	for _, l := range batch {
+		if err := conn.Send("RPUSH", s.config.Key, l); err != nil {
+			return errors.Wrap(err, "could not write logs to Redis")
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return errors.Wrap(err, "could not write logs to Redis")
+	}
+	for range batch {
+		if _, err := conn.Receive(); err != nil {
+			return errors.Wrap(err, "could not write logs to Redis")
+		}
+	}
+	return nil
+}
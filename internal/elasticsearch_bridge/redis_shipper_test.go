@@ -0,0 +1,154 @@
+package elasticsearch_bridge
+
+//go:generate mockgen -destination=./mock/redis.go -package=mock -mock_names=RedisPool=RedisPool github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge RedisPool
+//go:generate mockgen -destination=./mock/redis_conn.go -package=mock -mock_names=Conn=RedisConn github.com/garyburd/redigo/redis Conn
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedisShipperInvalidConfig(t *testing.T) {
+	var c = RedisShipperConfig{Key: "redisKey", QueueSize: 10, BatchSize: 10, FlushInterval: time.Second, DropPolicy: "drop-all"}
+	var _, err = NewRedisShipper(nil, NewJSONEncoder(), c)
+	assert.NotNil(t, err)
+
+	c.DropPolicy = DropOldest
+	c.QueueSize = 0
+	_, err = NewRedisShipper(nil, NewJSONEncoder(), c)
+	assert.NotNil(t, err)
+}
+
+func TestRedisShipperDropPolicy(t *testing.T) {
+	for policy, expected := range map[string][]string{
+		DropNewest: {`{"msg":"1"}`, `{"msg":"2"}`},
+		DropOldest: {`{"msg":"2"}`, `{"msg":"3"}`},
+	} {
+		var c = RedisShipperConfig{Key: "redisKey", QueueSize: 2, BatchSize: 10, FlushInterval: time.Hour, DropPolicy: policy}
+		var s, err = NewRedisShipper(nil, NewJSONEncoder(), c)
+		assert.Nil(t, err)
+
+		for _, msg := range []string{"1", "2", "3"} {
+			var line = fmt.Sprintf(`{"msg":"%s"}`, msg)
+			var n, err = s.Write([]byte(line))
+			assert.Nil(t, err)
+			assert.Equal(t, len(line), n)
+		}
+
+		assert.Equal(t, int64(1), s.droppedOnWrite)
+		assert.Equal(t, expected[0], string(<-s.queue))
+		assert.Equal(t, expected[1], string(<-s.queue))
+	}
+}
+
+func TestRedisShipper(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockPool = mock.NewRedisPool(mockCtrl)
+	var mockConn = mock.NewRedisConn(mockCtrl)
+
+	var c = RedisShipperConfig{Key: "redisKey", QueueSize: 10, BatchSize: 2, FlushInterval: time.Hour, DropPolicy: DropOldest, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	var s, err = NewRedisShipper(mockPool, NewJSONEncoder(), c)
+	assert.Nil(t, err)
+
+	var pushed = make(chan []byte, 10)
+	mockPool.EXPECT().Get().Return(mockConn).AnyTimes()
+	mockConn.EXPECT().Close().Return(nil).AnyTimes()
+	mockConn.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).DoAndReturn(func(_ string, args ...interface{}) error {
+		pushed <- args[1].([]byte)
+		return nil
+	}).Times(3)
+	mockConn.EXPECT().Flush().Return(nil).Times(2)
+	mockConn.EXPECT().Receive().Return(int64(1), nil).Times(3)
+
+	var sent, dropped = &countingCounter{}, &countingCounter{}
+	go s.Run(sent, dropped)
+
+	// A full batch is pushed in one pipeline.
+	s.Write([]byte(`{"msg":"1"}`))
+	s.Write([]byte(`{"msg":"2"}`))
+	assert.Equal(t, `{"msg":"1"}`, string(<-pushed))
+	assert.Equal(t, `{"msg":"2"}`, string(<-pushed))
+
+	// Close flushes the remaining logs.
+	s.Write([]byte(`{"msg":"3"}`))
+	assert.Nil(t, s.Close())
+	assert.Equal(t, `{"msg":"3"}`, string(<-pushed))
+	assert.Equal(t, float64(3), sent.value)
+	assert.Equal(t, float64(0), dropped.value)
+}
+
+func TestRedisShipperBackoff(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockPool = mock.NewRedisPool(mockCtrl)
+	var mockConn = mock.NewRedisConn(mockCtrl)
+
+	var c = RedisShipperConfig{Key: "redisKey", QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour, DropPolicy: DropOldest, MinBackoff: time.Second, MaxBackoff: 3 * time.Second}
+	var s, err = NewRedisShipper(mockPool, NewJSONEncoder(), c)
+	assert.Nil(t, err)
+	var sent, dropped = &countingCounter{}, &countingCounter{}
+
+	mockPool.EXPECT().Get().Return(mockConn).AnyTimes()
+	mockConn.EXPECT().Close().Return(nil).AnyTimes()
+
+	// Redis is down, the batch is kept and the backoff doubles up to the maximum.
+	{
+		mockConn.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).Return(fmt.Errorf("connection refused")).Times(3)
+
+		var batch = s.ship([][]byte{[]byte("1")}, sent, dropped, false)
+		assert.Equal(t, 1, len(batch))
+		assert.Equal(t, time.Second, s.backoff)
+
+		// No retry before the end of the backoff.
+		batch = s.ship(batch, sent, dropped, false)
+		assert.Equal(t, 1, len(batch))
+
+		s.retryAt = time.Time{}
+		batch = s.ship(batch, sent, dropped, false)
+		assert.Equal(t, 2*time.Second, s.backoff)
+
+		s.retryAt = time.Time{}
+		batch = s.ship(append(batch, []byte("2"), []byte("3")), sent, dropped, false)
+		assert.Equal(t, 3*time.Second, s.backoff)
+
+		// The batch is bounded by the queue size.
+		assert.Equal(t, [][]byte{[]byte("2"), []byte("3")}, batch)
+		assert.Equal(t, float64(1), dropped.value)
+	}
+
+	// Redis is back.
+	{
+		s.retryAt = time.Time{}
+		mockConn.EXPECT().Send("RPUSH", "redisKey", gomock.Any()).Return(nil).Times(2)
+		mockConn.EXPECT().Flush().Return(nil).Times(1)
+		mockConn.EXPECT().Receive().Return(int64(1), nil).Times(2)
+
+		var batch = s.ship([][]byte{[]byte("2"), []byte("3")}, sent, dropped, false)
+		assert.Equal(t, 0, len(batch))
+		assert.Equal(t, time.Duration(0), s.backoff)
+		assert.Equal(t, float64(2), sent.value)
+	}
+}
+
+func TestPooledRedis(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockPool = mock.NewRedisPool(mockCtrl)
+	var mockConn = mock.NewRedisConn(mockCtrl)
+
+	var r = NewPooledRedis(mockPool)
+
+	mockPool.EXPECT().Get().Return(mockConn).Times(1)
+	mockConn.EXPECT().Do("PING").Return("PONG", nil).Times(1)
+	mockConn.EXPECT().Close().Return(nil).Times(1)
+
+	var reply, err = r.Do("PING")
+	assert.Nil(t, err)
+	assert.Equal(t, "PONG", reply)
+}
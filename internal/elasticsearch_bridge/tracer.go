@@ -0,0 +1,156 @@
+package elasticsearch_bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger_config "github.com/uber/jaeger-client-go/config"
+	"go.opentelemetry.io/otel/attribute"
+	otel_bridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// Tracing exporters.
+const (
+	JaegerExporter = "jaeger"
+	OTLPExporter   = "otlp"
+)
+
+// otlpScopeName is the name of the instrumentation scope of the spans exported with OTLP.
+const otlpScopeName = "github.com/cloudtrust/elasticsearch-bridge"
+
+// TracingConfig is the configuration of the tracer. The sampler and the reporter flush
+// interval apply to both exporters, but the OTLP exporter only supports the const and
+// probabilistic samplers. The resource attributes, e.g. service.version, are added to the
+// service name: they are the OTLP resource, or the Jaeger process tags.
+type TracingConfig struct {
+	Enabled            bool
+	Exporter           string
+	Sampler            *jaeger_config.SamplerConfig
+	Reporter           *jaeger_config.ReporterConfig
+	OTLP               OTLPConfig
+	ResourceAttributes map[string]string
+}
+
+// NewTracer returns the opentracing tracer of the component. The opentracing API is the
+// tracing abstraction of the bridge. With the Jaeger exporter, the spans are recorded by
+// the Jaeger tracer. With the OTLP exporter, they are recorded by the OpenTelemetry SDK
+// through the opentracing bridge, and propagated with the W3C trace context headers. The
+// OTLP transport is returned so the collector can be health checked, it is nil otherwise.
+func NewTracer(serviceName string, c TracingConfig) (opentracing.Tracer, io.Closer, *OTLPTransport, error) {
+	var keys = []string{}
+	for k := range c.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch c.Exporter {
+	case JaegerExporter, OTLPExporter:
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown tracing exporter '%s'", c.Exporter)
+	}
+
+	if c.Exporter == JaegerExporter || !c.Enabled {
+		var config = jaeger_config.Configuration{
+			Disabled: !c.Enabled,
+			Sampler:  c.Sampler,
+			Reporter: c.Reporter,
+		}
+
+		var opts = []jaeger_config.Option{}
+		for _, k := range keys {
+			opts = append(opts, jaeger_config.Tag(k, c.ResourceAttributes[k]))
+		}
+
+		var tracer, closer, err = config.New(serviceName, opts...)
+		return tracer, closer, nil, err
+	}
+
+	var sampler, err = otlpSampler(c.Sampler)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var attributes = []attribute.KeyValue{semconv.ServiceNameKey.String(serviceName)}
+	for _, k := range keys {
+		attributes = append(attributes, attribute.String(k, c.ResourceAttributes[k]))
+	}
+
+	var transport *OTLPTransport
+	transport, err = NewOTLPTransport(c.OTLP)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var opts = []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attributes...)),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithBatcher(transport.exporter,
+			sdktrace.WithMaxExportBatchSize(transport.batchSize),
+			sdktrace.WithBatchTimeout(c.Reporter.BufferFlushInterval),
+			sdktrace.WithExportTimeout(transport.timeout),
+		),
+	}
+	if c.Reporter.LogSpans {
+		opts = append(opts, sdktrace.WithSpanProcessor(&loggingSpanProcessor{}))
+	}
+	var provider = sdktrace.NewTracerProvider(opts...)
+
+	var tracer, _ = otel_bridge.NewTracerPair(provider.Tracer(otlpScopeName))
+	tracer.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	tracer.SetWarningHandler(func(msg string) {
+		log.Printf("opentracing bridge: %s", msg)
+	})
+
+	return tracer, &otlpTracerCloser{provider: provider, transport: transport}, transport, nil
+}
+
+// otlpSampler returns the OpenTelemetry sampler of the Jaeger sampler configuration. As
+// with Jaeger, the sampling decision of the parent span is kept.
+func otlpSampler(c *jaeger_config.SamplerConfig) (sdktrace.Sampler, error) {
+	switch c.Type {
+	case "const":
+		if c.Param == 0 {
+			return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+		}
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "probabilistic":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.Param)), nil
+	default:
+		return nil, fmt.Errorf("sampler type '%s' is not supported by the OTLP exporter, only const and probabilistic are", c.Type)
+	}
+}
+
+// otlpTracerCloser flushes the spans and shuts the exporter down, then closes the transport.
+type otlpTracerCloser struct {
+	provider  *sdktrace.TracerProvider
+	transport *OTLPTransport
+}
+
+func (c *otlpTracerCloser) Close() error {
+	var err = c.provider.Shutdown(context.Background())
+	if closeErr := c.transport.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// loggingSpanProcessor logs the finished spans, like the Jaeger logging reporter.
+type loggingSpanProcessor struct{}
+
+func (p *loggingSpanProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *loggingSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	log.Printf("Reporting span %s:%s %s", s.SpanContext().TraceID(), s.SpanContext().SpanID(), s.Name())
+}
+
+func (p *loggingSpanProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *loggingSpanProcessor) ForceFlush(context.Context) error { return nil }
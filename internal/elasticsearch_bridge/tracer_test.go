@@ -0,0 +1,141 @@
+package elasticsearch_bridge
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	jaeger_config "github.com/uber/jaeger-client-go/config"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewTracerOTLP(t *testing.T) {
+	var bodies = make(chan []byte, 10)
+	var s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body, _ = ioutil.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer s.Close()
+
+	var tracer, closer, transport, err = NewTracer("elasticsearch-bridge", TracingConfig{
+		Enabled:  true,
+		Exporter: OTLPExporter,
+		Sampler:  &jaeger_config.SamplerConfig{Type: "const", Param: 1},
+		Reporter: &jaeger_config.ReporterConfig{BufferFlushInterval: time.Minute},
+		OTLP:     OTLPConfig{Protocol: OTLPProtocolHTTP, Endpoint: s.URL + "/v1/traces", Timeout: time.Second, BatchSize: 2},
+		ResourceAttributes: map[string]string{
+			"service.version":        "1.0",
+			"deployment.environment": "DEV",
+		},
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, transport)
+
+	var exported = func() *coltracepb.ExportTraceServiceRequest {
+		var req = &coltracepb.ExportTraceServiceRequest{}
+		select {
+		case body := <-bodies:
+			assert.Nil(t, proto.Unmarshal(body, req))
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "no spans exported")
+		}
+		return req
+	}
+
+	// The spans are exported when the batch size is reached.
+	var parent = tracer.StartSpan("health", opentracing.Tag{Key: "span.kind", Value: "server"})
+	tracer.StartSpan("elasticsearch_health", opentracing.ChildOf(parent.Context())).Finish()
+	parent.Finish()
+
+	var req = exported()
+	var attributes = map[string]string{}
+	for _, kv := range req.ResourceSpans[0].Resource.Attributes {
+		attributes[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, "elasticsearch-bridge", attributes["service.name"])
+	assert.Equal(t, "1.0", attributes["service.version"])
+	assert.Equal(t, "DEV", attributes["deployment.environment"])
+
+	var spans = req.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "elasticsearch_health", spans[0].Name)
+	assert.Equal(t, "health", spans[1].Name)
+	assert.Equal(t, spans[1].SpanId, spans[0].ParentSpanId)
+	assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, spans[1].Kind)
+
+	// The span context is propagated with the W3C trace context headers.
+	var span = tracer.StartSpan("clean")
+	var header = http.Header{}
+	assert.Nil(t, tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)))
+	assert.NotEmpty(t, header.Get("traceparent"))
+	span.Finish()
+
+	// Closing the tracer flushes the remaining spans.
+	assert.Nil(t, closer.Close())
+	spans = exported().ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "clean", spans[0].Name)
+}
+
+func TestNewTracerOTLPGRPC(t *testing.T) {
+	var c = &collector{requests: make(chan *coltracepb.ExportTraceServiceRequest, 1)}
+	var server = grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(server, c)
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	var tracer opentracing.Tracer
+	var closer io.Closer
+	tracer, closer, _, err = NewTracer("elasticsearch-bridge", TracingConfig{
+		Enabled:  true,
+		Exporter: OTLPExporter,
+		Sampler:  &jaeger_config.SamplerConfig{Type: "probabilistic", Param: 1},
+		Reporter: &jaeger_config.ReporterConfig{BufferFlushInterval: time.Minute},
+		OTLP:     OTLPConfig{Protocol: OTLPProtocolGRPC, Endpoint: listener.Addr().String(), Timeout: 5 * time.Second, BatchSize: 10},
+	})
+	assert.Nil(t, err)
+
+	tracer.StartSpan("health").Finish()
+	assert.Nil(t, closer.Close())
+
+	var spans = (<-c.requests).ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "health", spans[0].Name)
+}
+
+func TestNewTracerOTLPUnsupportedSampler(t *testing.T) {
+	var _, _, _, err = NewTracer("elasticsearch-bridge", TracingConfig{
+		Enabled:  true,
+		Exporter: OTLPExporter,
+		Sampler:  &jaeger_config.SamplerConfig{Type: "remote"},
+		Reporter: &jaeger_config.ReporterConfig{BufferFlushInterval: time.Minute},
+		OTLP:     OTLPConfig{Protocol: OTLPProtocolHTTP, Endpoint: "http://localhost:4318/v1/traces", Timeout: time.Second, BatchSize: 10},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestNewTracerDisabled(t *testing.T) {
+	for _, exporter := range []string{JaegerExporter, OTLPExporter} {
+		var tracer, closer, transport, err = NewTracer("elasticsearch-bridge", TracingConfig{Enabled: false, Exporter: exporter})
+		assert.Nil(t, err)
+		assert.Nil(t, transport)
+		assert.IsType(t, &opentracing.NoopTracer{}, tracer)
+		closer.Close()
+	}
+}
+
+func TestNewTracerUnknownExporter(t *testing.T) {
+	var _, _, _, err = NewTracer("elasticsearch-bridge", TracingConfig{Enabled: true, Exporter: "zipkin"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,50 @@
+package elasticsearch_bridge
+
+import (
+	"context"
+	"net/http"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+// MakeElasticsearchTracingMW makes a tracing middleware for the Elasticsearch client. Each
+// request is traced in a child span of the span in the context, e.g. the span of the
+// endpoint or of the job. The span context is propagated in the request headers. Without
+// span in the context, the request is not traced.
+func MakeElasticsearchTracingMW(tracer opentracing.Tracer) ElasticsearchMiddleware {
+	return func(next ElasticsearchRequestFunc) ElasticsearchRequestFunc {
+		return func(ctx context.Context, req ElasticsearchRequest) (ElasticsearchResponse, error) {
+			var parent = opentracing.SpanFromContext(ctx)
+			if parent == nil {
+				return next(ctx, req)
+			}
+
+			var span = tracer.StartSpan("elasticsearch_"+req.Operation, opentracing.ChildOf(parent.Context()))
+			defer span.Finish()
+			otag.SpanKindRPCClient.Set(span)
+			otag.Component.Set(span, "elasticsearch")
+			otag.HTTPMethod.Set(span, req.Method)
+			otag.HTTPUrl.Set(span, req.Path)
+
+			// Propagate the span context. A span that cannot be injected is still recorded.
+			var header = http.Header{}
+			for k, v := range req.Header {
+				header[k] = v
+			}
+			tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+			req.Header = header
+
+			var resp, err = next(opentracing.ContextWithSpan(ctx, span), req)
+			if resp.StatusCode != 0 {
+				otag.HTTPStatusCode.Set(span, uint16(resp.StatusCode))
+			}
+			if err != nil {
+				otag.Error.Set(span, true)
+				span.LogFields(olog.Error(err))
+			}
+			return resp, err
+		}
+	}
+}
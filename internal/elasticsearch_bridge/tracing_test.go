@@ -0,0 +1,65 @@
+package elasticsearch_bridge
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticsearchTracingMW(t *testing.T) {
+	var tracer = mocktracer.New()
+
+	var resp ElasticsearchResponse
+	var err error
+	var sent ElasticsearchRequest
+	var next = func(_ context.Context, req ElasticsearchRequest) (ElasticsearchResponse, error) {
+		sent = req
+		return resp, err
+	}
+	var m = MakeElasticsearchTracingMW(tracer)(next)
+
+	// Without span in the context, the request is not traced.
+	{
+		resp, err = ElasticsearchResponse{StatusCode: 200}, nil
+		m(context.Background(), ElasticsearchRequest{Operation: "health", Method: "GET", Path: "/_cluster/health"})
+		assert.Equal(t, 0, len(tracer.FinishedSpans()))
+	}
+
+	var parent = tracer.StartSpan("health_endpoint")
+	var ctx = opentracing.ContextWithSpan(context.Background(), parent)
+
+	// Child span.
+	{
+		tracer.Reset()
+		resp, err = ElasticsearchResponse{StatusCode: 200}, nil
+		m(ctx, ElasticsearchRequest{Operation: "health", Method: "GET", Path: "/_cluster/health"})
+
+		var spans = tracer.FinishedSpans()
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, "elasticsearch_health", spans[0].OperationName)
+		assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, spans[0].ParentID)
+		assert.Equal(t, "GET", spans[0].Tag("http.method"))
+		assert.Equal(t, "/_cluster/health", spans[0].Tag("http.url"))
+		assert.Equal(t, uint16(200), spans[0].Tag("http.status_code"))
+		assert.Nil(t, spans[0].Tag("error"))
+
+		// The span context is propagated in the headers.
+		assert.NotEmpty(t, sent.Header.Get("Mockpfx-Ids-Spanid"))
+	}
+
+	// Error.
+	{
+		tracer.Reset()
+		resp, err = ElasticsearchResponse{StatusCode: 404}, fmt.Errorf("fail")
+		m(ctx, ElasticsearchRequest{Operation: "get_index", Method: "GET", Path: "index"})
+
+		var spans = tracer.FinishedSpans()
+		assert.Equal(t, 1, len(spans))
+		assert.Equal(t, true, spans[0].Tag("error"))
+		assert.Equal(t, uint16(404), spans[0].Tag("http.status_code"))
+	}
+}
@@ -1,6 +1,12 @@
 package elasticsearch_bridge
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	sentry "github.com/getsentry/raven-go"
 )
 
@@ -22,3 +28,101 @@ func (s *NoopSentry) URL() string { return "" }
 
 // Close does nothing.
 func (s *NoopSentry) Close() {}
+
+// SentryClient is the interface of the Sentry client capturing the errors.
+type SentryClient interface {
+	CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string
+}
+
+// ErrorTracker captures errors in Sentry, with the tags of the component, e.g. its ID.
+// An error captured again within the deduplication window, with the same tags apart from
+// the correlation ID, is not sent again. At most maxPerMinute errors are sent each minute.
+type ErrorTracker struct {
+	sentry       SentryClient
+	tags         map[string]string
+	dedupWindow  time.Duration
+	maxPerMinute int
+
+	mu          sync.Mutex
+	seen        map[string]time.Time
+	minuteStart time.Time
+	sent        int
+	now         func() time.Time
+}
+
+// NewErrorTracker returns an error tracker sending the errors to Sentry.
+func NewErrorTracker(client SentryClient, tags map[string]string, dedupWindow time.Duration, maxPerMinute int) *ErrorTracker {
+	return &ErrorTracker{
+		sentry:       client,
+		tags:         tags,
+		dedupWindow:  dedupWindow,
+		maxPerMinute: maxPerMinute,
+		seen:         map[string]time.Time{},
+		now:          time.Now,
+	}
+}
+
+// CaptureError sends the error to Sentry with the tags of the component and the given tags,
+// unless it is a duplicate or the rate limit is reached. It returns the ID of the Sentry
+// event, or "" if the error was not sent.
+func (t *ErrorTracker) CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string {
+	if err == nil || !t.allow(dedupKey(err, tags)) {
+		return ""
+	}
+
+	var allTags = map[string]string{}
+	for k, v := range t.tags {
+		allTags[k] = v
+	}
+	for k, v := range tags {
+		allTags[k] = v
+	}
+	return t.sentry.CaptureError(err, allTags, interfaces...)
+}
+
+// allow returns whether the error with the deduplication key can be sent.
+func (t *ErrorTracker) allow(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var now = t.now()
+	if now.Sub(t.minuteStart) >= time.Minute {
+		t.minuteStart = now
+		t.sent = 0
+		for k, last := range t.seen {
+			if now.Sub(last) >= t.dedupWindow {
+				delete(t.seen, k)
+			}
+		}
+	}
+
+	if last, ok := t.seen[key]; ok && now.Sub(last) < t.dedupWindow {
+		return false
+	}
+	if t.sent >= t.maxPerMinute {
+		return false
+	}
+
+	t.seen[key] = now
+	t.sent++
+	return true
+}
+
+// dedupKey returns the key identifying the duplicates of the error: the error message and
+// the tags, without the correlation ID which differs for each request.
+func dedupKey(err error, tags map[string]string) string {
+	var keys = []string{}
+	for k := range tags {
+		if k != "correlation_id" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(err.Error())
+	for _, k := range keys {
+		fmt.Fprintf(&b, "|%s=%s", k, tags[k])
+	}
+	return b.String()
+}
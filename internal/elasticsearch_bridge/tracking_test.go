@@ -3,7 +3,9 @@ package elasticsearch_bridge
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	sentry "github.com/getsentry/raven-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +19,50 @@ func TestNoopSentry(t *testing.T) {
 	// URL
 	assert.Zero(t, noopSentry.URL())
 }
+
+func TestErrorTracker(t *testing.T) {
+	var client = &recordingSentry{}
+	var tracker = NewErrorTracker(client, map[string]string{"component_id": "1234"}, 5*time.Minute, 3)
+	var now = time.Date(2018, 2, 13, 6, 27, 7, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	// The tags of the component are added.
+	tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "influx", "correlation_id": "1"})
+	assert.Equal(t, 1, len(client.tags))
+	assert.Equal(t, map[string]string{"component_id": "1234", "unit": "influx", "correlation_id": "1"}, client.tags[0])
+
+	// The duplicates are not sent, whatever their correlation ID.
+	tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "influx", "correlation_id": "2"})
+	assert.Equal(t, 1, len(client.tags))
+
+	// Errors with other messages or tags are not duplicates.
+	tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "redis"})
+	tracker.CaptureError(fmt.Errorf("timeout"), map[string]string{"unit": "influx"})
+	assert.Equal(t, 3, len(client.tags))
+
+	// Rate limit.
+	tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "sentry"})
+	assert.Equal(t, 3, len(client.tags))
+
+	// The rate limit is reset each minute, the duplicates are sent again after the window.
+	now = now.Add(time.Minute)
+	tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "influx"})
+	assert.Equal(t, 3, len(client.tags))
+	now = now.Add(5 * time.Minute)
+	tracker.CaptureError(fmt.Errorf("fail"), map[string]string{"unit": "influx"})
+	assert.Equal(t, 4, len(client.tags))
+
+	// Nil errors are not sent.
+	assert.Zero(t, tracker.CaptureError(nil, nil))
+	assert.Equal(t, 4, len(client.tags))
+}
+
+// recordingSentry records the tags of the captured errors.
+type recordingSentry struct {
+	tags []map[string]string
+}
+
+func (s *recordingSentry) CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string {
+	s.tags = append(s.tags, tags)
+	return "eventID"
+}
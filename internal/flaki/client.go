@@ -0,0 +1,174 @@
+package flaki
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	fb "github.com/cloudtrust/elasticsearch-bridge/api/fb"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+)
+
+// ClientConfig is the configuration of the Flaki client. The pool holds up to PoolSize
+// prefetched IDs, there is no prefetching if it is 0. Each call to Flaki has the Timeout
+// deadline, and the failed calls are retried up to Retries times, waiting RetryBackoff
+// before the first retry and doubling it before the next ones. With the pool, the
+// prefetching also waits RetryBackoff after a failure, so it must be positive.
+type ClientConfig struct {
+	PoolSize     int
+	Timeout      time.Duration
+	Retries      int
+	RetryBackoff time.Duration
+}
+
+// Client is the Flaki client. It prefetches the IDs in the background into a bounded
+// pool, so most IDs are returned without round-trip to Flaki.
+type Client struct {
+	flaki        fb.FlakiClient
+	pool         chan string
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.RWMutex
+	depth   metrics.Gauge
+	latency metrics.Histogram
+}
+
+// NewClient returns the Flaki client. The prefetching starts with Run.
+func NewClient(flaki fb.FlakiClient, c ClientConfig) (*Client, error) {
+	switch {
+	case c.PoolSize < 0:
+		return nil, fmt.Errorf("pool size must be positive or 0")
+	case c.Timeout <= 0:
+		return nil, fmt.Errorf("timeout must be positive")
+	case c.Retries < 0:
+		return nil, fmt.Errorf("retries must be positive or 0")
+	case c.RetryBackoff < 0:
+		return nil, fmt.Errorf("retry backoff must be positive or 0")
+	case c.PoolSize > 0 && c.RetryBackoff == 0:
+		return nil, fmt.Errorf("retry backoff must be positive with a pool")
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	return &Client{
+		flaki:        flaki,
+		pool:         make(chan string, c.PoolSize),
+		timeout:      c.Timeout,
+		retries:      c.Retries,
+		retryBackoff: c.RetryBackoff,
+		ctx:          ctx,
+		cancel:       cancel,
+		depth:        discard.NewGauge(),
+		latency:      discard.NewHistogram(),
+	}, nil
+}
+
+// NextValidID returns a prefetched ID, or fetches one from Flaki if the pool is empty.
+func (c *Client) NextValidID(ctx context.Context) (string, error) {
+	select {
+	case id := <-c.pool:
+		c.gauge().Set(float64(len(c.pool)))
+		return id, nil
+	default:
+		return c.FetchValidID(ctx)
+	}
+}
+
+// FetchValidID fetches an ID from Flaki, bypassing the pool, e.g. to check that Flaki is
+// reachable.
+func (c *Client) FetchValidID(ctx context.Context) (string, error) {
+	var backoff = c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		var id, err = c.call(ctx)
+		switch {
+		case err == nil:
+			return id, nil
+		case attempt == c.retries:
+			return "", errors.Wrapf(err, "cannot get ID from flaki-service after %d attempts", attempt+1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrapf(err, "cannot get ID from flaki-service after %d attempts", attempt+1)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// call makes one call to Flaki, with the deadline of the client.
+func (c *Client) call(ctx context.Context) (string, error) {
+	var b = flatbuffers.NewBuilder(0)
+	fb.FlakiRequestStart(b)
+	b.Finish(fb.FlakiRequestEnd(b))
+
+	var callCtx, cancel = context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var begin = time.Now()
+	var reply, err = c.flaki.NextValidID(callCtx, b)
+	c.histogram().Observe(time.Since(begin).Seconds())
+
+	if err != nil {
+		return "", err
+	}
+	return string(reply.Id()), nil
+}
+
+// Run prefetches the IDs into the pool until the client is closed. It reports the number
+// of IDs in the pool and the latency of the calls to Flaki, in seconds.
+func (c *Client) Run(depth metrics.Gauge, latency metrics.Histogram) {
+	c.mu.Lock()
+	c.depth, c.latency = depth, latency
+	c.mu.Unlock()
+
+	if cap(c.pool) == 0 {
+		return
+	}
+
+	for {
+		depth.Set(float64(len(c.pool)))
+
+		var id, err = c.FetchValidID(c.ctx)
+		if err != nil {
+			// Flaki is unreachable, the calls are served without pool until it is back.
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(c.retryBackoff):
+				continue
+			}
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case c.pool <- id:
+		}
+	}
+}
+
+// Close stops the prefetching.
+func (c *Client) Close() {
+	c.cancel()
+}
+
+func (c *Client) gauge() metrics.Gauge {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.depth
+}
+
+func (c *Client) histogram() metrics.Histogram {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latency
+}
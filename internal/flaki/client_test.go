@@ -0,0 +1,186 @@
+package flaki
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	fb "github.com/cloudtrust/elasticsearch-bridge/api/fb"
+	"github.com/go-kit/kit/metrics"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestNewClient(t *testing.T) {
+	var flaki = &fakeFlakiClient{}
+
+	var _, err = NewClient(flaki, ClientConfig{PoolSize: 10, Timeout: time.Second, Retries: 2, RetryBackoff: time.Millisecond})
+	assert.Nil(t, err)
+
+	for _, c := range []ClientConfig{
+		{PoolSize: -1, Timeout: time.Second},
+		{Timeout: 0},
+		{Timeout: time.Second, Retries: -1},
+		{Timeout: time.Second, RetryBackoff: -time.Second},
+		{PoolSize: 10, Timeout: time.Second, RetryBackoff: 0},
+	} {
+		_, err = NewClient(flaki, c)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var flaki = &fakeFlakiClient{failures: 2}
+	var c, _ = NewClient(flaki, ClientConfig{Timeout: time.Second, Retries: 2, RetryBackoff: time.Millisecond})
+
+	// The third attempt succeeds.
+	var id, err = c.NextValidID(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "1", id)
+	assert.Equal(t, 3, flaki.callCount())
+
+	// All attempts fail.
+	flaki.failures = 3
+	_, err = c.NextValidID(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, 6, flaki.callCount())
+}
+
+func TestClientDeadline(t *testing.T) {
+	var flaki = &fakeFlakiClient{}
+	var c, _ = NewClient(flaki, ClientConfig{Timeout: 50 * time.Millisecond})
+
+	var _, err = c.FetchValidID(context.Background())
+	assert.Nil(t, err)
+	var deadline, ok = flaki.lastDeadline()
+	assert.True(t, ok)
+	assert.True(t, time.Until(deadline) <= 50*time.Millisecond)
+}
+
+func TestClientPool(t *testing.T) {
+	var flaki = &fakeFlakiClient{}
+	var c, _ = NewClient(flaki, ClientConfig{PoolSize: 5, Timeout: time.Second, RetryBackoff: time.Millisecond})
+	var depth = &recordingGauge{}
+	var latency = &recordingHistogram{}
+
+	go c.Run(depth, latency)
+	defer c.Close()
+
+	// The pool is filled in the background.
+	for i := 0; i < 100 && depth.last() < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, float64(5), depth.last())
+	assert.True(t, latency.count() >= 5)
+
+	// The IDs come from the pool, in order and unique.
+	var ids = map[string]struct{}{}
+	for i := 1; i <= 10; i++ {
+		var id, err = c.NextValidID(context.Background())
+		assert.Nil(t, err)
+		ids[id] = struct{}{}
+	}
+	assert.Equal(t, 10, len(ids))
+	assert.Contains(t, ids, "1")
+}
+
+// fakeFlakiClient replies with increasing IDs. The first calls fail while failures is
+// positive.
+type fakeFlakiClient struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	id       uint64
+	deadline time.Time
+	ok       bool
+}
+
+func (c *fakeFlakiClient) NextID(ctx context.Context, in *flatbuffers.Builder, opts ...grpc.CallOption) (*fb.FlakiReply, error) {
+	return c.NextValidID(ctx, in, opts...)
+}
+
+func (c *fakeFlakiClient) NextValidID(ctx context.Context, in *flatbuffers.Builder, opts ...grpc.CallOption) (*fb.FlakiReply, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	c.deadline, c.ok = ctx.Deadline()
+	if c.failures > 0 {
+		c.failures--
+		return nil, fmt.Errorf("unavailable")
+	}
+	c.id++
+	return flakiReply(strconv.FormatUint(c.id, 10)), nil
+}
+
+func (c *fakeFlakiClient) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *fakeFlakiClient) lastDeadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline, c.ok
+}
+
+// flakiReply builds the Flaki reply carrying the ID.
+func flakiReply(id string) *fb.FlakiReply {
+	var b = flatbuffers.NewBuilder(0)
+	var s = b.CreateString(id)
+	fb.FlakiReplyStart(b)
+	fb.FlakiReplyAddId(b, s)
+	b.Finish(fb.FlakiReplyEnd(b))
+	return fb.GetRootAsFlakiReply(b.FinishedBytes(), 0)
+}
+
+// recordingGauge records the last value.
+type recordingGauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (g *recordingGauge) With(labelValues ...string) metrics.Gauge { return g }
+
+func (g *recordingGauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = value
+}
+
+func (g *recordingGauge) Add(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value += delta
+}
+
+func (g *recordingGauge) last() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// recordingHistogram counts the observations.
+type recordingHistogram struct {
+	mu           sync.Mutex
+	observations int
+}
+
+func (h *recordingHistogram) With(labelValues ...string) metrics.Histogram { return h }
+
+func (h *recordingHistogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observations++
+}
+
+func (h *recordingHistogram) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.observations
+}
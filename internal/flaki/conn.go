@@ -0,0 +1,120 @@
+package flaki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// resolverScheme is the scheme of the resolver of the Flaki addresses.
+const resolverScheme = "flaki"
+
+// ConnConfig is the configuration of the gRPC connection to Flaki. The calls are balanced
+// round robin across the addresses. With TLS, the server certificate is verified with the
+// CA, or the system CAs if there is none, and the client presents its certificate if
+// there is one (mTLS). The server name verified in the certificate defaults to the host of
+// the first address. The keepalive pings are disabled if KeepaliveTime is 0.
+type ConnConfig struct {
+	Addrs            []string
+	TLS              bool
+	CAFile           string
+	CertFile         string
+	KeyFile          string
+	ServerName       string
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+}
+
+// Dial creates the gRPC connection to Flaki. The connection is established in the
+// background, so Dial does not fail if Flaki is unreachable.
+func Dial(c ConnConfig, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if len(c.Addrs) == 0 {
+		return nil, fmt.Errorf("no flaki address")
+	}
+
+	var dialOpts, err = dialOptions(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// The addresses are resolved by a static resolver of the connection, not registered
+	// globally, and the balancer picks them round robin.
+	var r = manual.NewBuilderWithScheme(resolverScheme)
+	var addrs = []resolver.Address{}
+	for _, addr := range c.Addrs {
+		addrs = append(addrs, resolver.Address{Addr: addr})
+	}
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	dialOpts = append(dialOpts, grpc.WithResolvers(r), grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin": {}}]}`))
+	return grpc.Dial(resolverScheme+":///flaki", append(dialOpts, opts...)...)
+}
+
+func dialOptions(c ConnConfig) ([]grpc.DialOption, error) {
+	var opts = []grpc.DialOption{}
+
+	if c.TLS {
+		var creds, err = transportCredentials(c)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	if c.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    c.KeepaliveTime,
+			Timeout: c.KeepaliveTimeout,
+		}))
+	}
+	return opts, nil
+}
+
+func transportCredentials(c ConnConfig) (credentials.TransportCredentials, error) {
+	// Without server name, grpc would verify the authority of the dial target, which is
+	// not a host name.
+	var config = &tls.Config{ServerName: c.ServerName}
+	if config.ServerName == "" && len(c.Addrs) > 0 {
+		var host, _, err = net.SplitHostPort(c.Addrs[0])
+		if err != nil {
+			host = c.Addrs[0]
+		}
+		config.ServerName = host
+	}
+
+	if c.CAFile != "" {
+		var pem, err = ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read flaki CA")
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in flaki CA '%s'", c.CAFile)
+		}
+	}
+
+	switch {
+	case c.CertFile != "" && c.KeyFile != "":
+		var cert, err = tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load flaki client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case c.CertFile != "" || c.KeyFile != "":
+		return nil, fmt.Errorf("both the certificate and the key are needed for mTLS")
+	}
+
+	return credentials.NewTLS(config), nil
+}
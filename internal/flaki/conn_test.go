@@ -0,0 +1,189 @@
+package flaki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestDialRoundRobin(t *testing.T) {
+	var calls = map[string]int{}
+	var mu sync.Mutex
+	var addrs = []string{}
+	for i := 0; i < 2; i++ {
+		var lis, err = net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		var addr = lis.Addr().String()
+		addrs = append(addrs, addr)
+
+		var s = grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			mu.Lock()
+			calls[addr]++
+			mu.Unlock()
+			return handler(ctx, req)
+		}))
+		healthpb.RegisterHealthServer(s, health.NewServer())
+		go s.Serve(lis)
+		defer s.Stop()
+	}
+
+	var conn, err = Dial(ConnConfig{Addrs: addrs, KeepaliveTime: 10 * time.Second, KeepaliveTimeout: time.Second})
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	var servedBy = func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(calls)
+	}
+
+	// Once both addresses are connected, the calls are balanced across them.
+	var client = healthpb.NewHealthClient(conn)
+	for i := 0; i < 100 && servedBy() < 2; i++ {
+		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		cancel()
+		assert.Nil(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 2, servedBy())
+}
+
+func TestDialTLS(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flaki")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile)
+
+	// Two servers, each behind its own connection: the resolvers of the connections do
+	// not overwrite each other.
+	var conns = []*grpc.ClientConn{}
+	for _, status := range []healthpb.HealthCheckResponse_ServingStatus{healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING} {
+		var creds, err = credentials.NewServerTLSFromFile(certFile, keyFile)
+		assert.Nil(t, err)
+		var s = grpc.NewServer(grpc.Creds(creds))
+		var h = health.NewServer()
+		h.SetServingStatus("", status)
+		healthpb.RegisterHealthServer(s, h)
+
+		var lis net.Listener
+		lis, err = net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		go s.Serve(lis)
+		defer s.Stop()
+
+		// The server name is the host of the address.
+		var _, port, _ = net.SplitHostPort(lis.Addr().String())
+		var conn *grpc.ClientConn
+		conn, err = Dial(ConnConfig{Addrs: []string{net.JoinHostPort("localhost", port)}, TLS: true, CAFile: certFile})
+		assert.Nil(t, err)
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	for i, expected := range []healthpb.HealthCheckResponse_ServingStatus{healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING} {
+		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		var resp, err = healthpb.NewHealthClient(conns[i]).Check(ctx, &healthpb.HealthCheckRequest{})
+		cancel()
+		assert.Nil(t, err)
+		if assert.NotNil(t, resp) {
+			assert.Equal(t, expected, resp.Status)
+		}
+	}
+}
+
+func TestDialNoAddress(t *testing.T) {
+	var _, err = Dial(ConnConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestTransportCredentials(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "flaki")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var caFile, certFile, keyFile = filepath.Join(dir, "ca.pem"), filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile)
+	assert.Nil(t, ioutil.WriteFile(caFile, mustRead(t, certFile), 0600))
+
+	// TLS with the system CAs.
+	{
+		var _, err = transportCredentials(ConnConfig{TLS: true, ServerName: "flaki"})
+		assert.Nil(t, err)
+	}
+
+	// The server name defaults to the host of the first address.
+	{
+		var creds, err = transportCredentials(ConnConfig{Addrs: []string{"flaki-1:5555", "flaki-2:5555"}, TLS: true})
+		assert.Nil(t, err)
+		assert.Equal(t, "flaki-1", creds.Info().ServerName)
+	}
+
+	// mTLS.
+	{
+		var creds, err = transportCredentials(ConnConfig{TLS: true, CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "flaki"})
+		assert.Nil(t, err)
+		assert.Equal(t, "flaki", creds.Info().ServerName)
+	}
+
+	// Invalid configurations.
+	for _, c := range []ConnConfig{
+		{TLS: true, CAFile: filepath.Join(dir, "missing.pem")},
+		{TLS: true, CAFile: keyFile},
+		{TLS: true, CertFile: certFile},
+		{TLS: true, CertFile: certFile, KeyFile: caFile},
+	} {
+		var _, err = transportCredentials(c)
+		assert.NotNil(t, err)
+	}
+}
+
+// writeCertificate writes a self-signed certificate and its key.
+func writeCertificate(t *testing.T, certFile, keyFile string) {
+	var key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	var template = &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "flaki"},
+		DNSNames:              []string{"flaki", "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+func mustRead(t *testing.T, file string) []byte {
+	var b, err = ioutil.ReadFile(file)
+	assert.Nil(t, err)
+	return b
+}
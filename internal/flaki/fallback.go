@@ -0,0 +1,107 @@
+package flaki
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IDClient is the interface of the Flaki client.
+type IDClient interface {
+	NextValidID(context.Context) (string, error)
+}
+
+// FallbackClient is a Flaki client that generates the IDs with the embedded generator
+// when Flaki is unreachable. It is in degraded mode from the first Flaki error until
+// Flaki replies again. While degraded, the IDs come straight from the generator, and
+// Flaki is probed in the background every probe interval.
+type FallbackClient struct {
+	flaki         IDClient
+	generator     *Generator
+	probeInterval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	flakiErr error
+	since    time.Time
+}
+
+// NewFallbackClient returns a Flaki client falling back to the generator.
+func NewFallbackClient(flaki IDClient, generator *Generator, probeInterval time.Duration) (*FallbackClient, error) {
+	if probeInterval <= 0 {
+		return nil, fmt.Errorf("probe interval must be positive")
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	return &FallbackClient{
+		flaki:         flaki,
+		generator:     generator,
+		probeInterval: probeInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+// NextValidID returns an ID from Flaki, or from the generator if Flaki is unreachable.
+func (c *FallbackClient) NextValidID(ctx context.Context) string {
+	if degraded, _, _ := c.Degraded(); degraded {
+		return c.generatorID()
+	}
+
+	var id, err = c.flaki.NextValidID(ctx)
+	if err == nil {
+		return id
+	}
+
+	c.mu.Lock()
+	if c.flakiErr == nil {
+		c.since = time.Now()
+		go c.probe()
+	}
+	c.flakiErr = err
+	c.mu.Unlock()
+
+	return c.generatorID()
+}
+
+// probe calls Flaki every probe interval until it replies, which ends the degraded mode.
+func (c *FallbackClient) probe() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(c.probeInterval):
+		}
+
+		var _, err = c.flaki.NextValidID(c.ctx)
+
+		c.mu.Lock()
+		c.flakiErr = err
+		c.mu.Unlock()
+
+		if err == nil {
+			return
+		}
+	}
+}
+
+func (c *FallbackClient) generatorID() string {
+	return strconv.FormatUint(c.generator.NextValidID(), 10)
+}
+
+// Degraded returns whether the IDs are generated by the embedded generator, since when,
+// and the Flaki error.
+func (c *FallbackClient) Degraded() (bool, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flakiErr != nil, c.since, c.flakiErr
+}
+
+// Close stops the probing of Flaki.
+func (c *FallbackClient) Close() {
+	c.cancel()
+}
@@ -0,0 +1,106 @@
+package flaki
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFallbackClientInvalidProbeInterval(t *testing.T) {
+	var g, _ = NewGenerator(1, 2)
+	var _, err = NewFallbackClient(&fakeIDClient{}, g, 0)
+	assert.NotNil(t, err)
+}
+
+func TestFallbackClient(t *testing.T) {
+	var flaki = &fakeIDClient{}
+	var g, err = NewGenerator(1, 2)
+	assert.Nil(t, err)
+	var c *FallbackClient
+	c, err = NewFallbackClient(flaki, g, 10*time.Millisecond)
+	assert.Nil(t, err)
+	defer c.Close()
+
+	// Flaki is up.
+	{
+		flaki.set("123", nil)
+		assert.Equal(t, "123", c.NextValidID(context.Background()))
+
+		var degraded, _, flakiErr = c.Degraded()
+		assert.False(t, degraded)
+		assert.Nil(t, flakiErr)
+	}
+
+	// Flaki is down, the IDs are generated by the generator.
+	{
+		var flakiErr = fmt.Errorf("connection refused")
+		flaki.set("", flakiErr)
+		var id, parseErr = strconv.ParseUint(c.NextValidID(context.Background()), 10, 64)
+		assert.Nil(t, parseErr)
+		assert.Equal(t, uint64(2), id>>nodeIDShift&maxNodeID)
+		assert.Equal(t, uint64(1), id>>componentIDShift&maxComponentID)
+
+		var degraded, since, err = c.Degraded()
+		assert.True(t, degraded)
+		assert.False(t, since.IsZero())
+		assert.Equal(t, flakiErr, err)
+
+		// While degraded, the IDs do not wait for Flaki.
+		var calls = flaki.count()
+		for i := 0; i < 100; i++ {
+			assert.NotEqual(t, strconv.FormatUint(id, 10), c.NextValidID(context.Background()))
+		}
+		assert.True(t, flaki.count() <= calls+1)
+	}
+
+	// Flaki is back, the background probe ends the degraded mode.
+	{
+		flaki.set("123", nil)
+		for i := 0; i < 100; i++ {
+			if degraded, _, _ := c.Degraded(); !degraded {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		var degraded, _, _ = c.Degraded()
+		assert.False(t, degraded)
+		assert.Equal(t, "123", c.NextValidID(context.Background()))
+	}
+}
+
+// fakeIDClient replies with the ID, or fails with the error.
+type fakeIDClient struct {
+	mu    sync.Mutex
+	id    string
+	err   error
+	calls int
+}
+
+func (c *fakeIDClient) set(id string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.id, c.err = id, err
+}
+
+func (c *fakeIDClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *fakeIDClient) NextValidID(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	return c.id, nil
+}
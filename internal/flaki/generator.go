@@ -0,0 +1,102 @@
+// Package flaki generates unique IDs with the Flaki service, or with an embedded
+// generator using the same layout when Flaki is unreachable.
+package flaki
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Layout of the Flaki IDs, from the most significant bit: 1 unused bit, 42 bits of
+// timestamp in milliseconds since the epoch, 2 bits of component ID, 3 bits of node ID and
+// 16 bits of sequence.
+const (
+	componentIDBits = 2
+	nodeIDBits      = 3
+	sequenceBits    = 16
+
+	maxComponentID = 1<<componentIDBits - 1
+	maxNodeID      = 1<<nodeIDBits - 1
+	maxSequence    = 1<<sequenceBits - 1
+
+	nodeIDShift      = sequenceBits
+	componentIDShift = sequenceBits + nodeIDBits
+	timestampShift   = sequenceBits + nodeIDBits + componentIDBits
+)
+
+// epoch is the Flaki epoch, 2017-01-01 00:00:00 UTC.
+var epoch = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Generator is an embedded snowflake generator with the Flaki layout. The IDs of a
+// generator are unique and sortable by time. The IDs of generators with different
+// component and node IDs are unique.
+type Generator struct {
+	componentID uint64
+	nodeID      uint64
+
+	mu            sync.Mutex
+	lastTimestamp int64
+	sequence      uint64
+	now           func() time.Time
+}
+
+// NewGenerator returns a generator with the component and node IDs.
+func NewGenerator(componentID, nodeID uint64) (*Generator, error) {
+	switch {
+	case componentID > maxComponentID:
+		return nil, fmt.Errorf("component ID must be in [0, %d]", maxComponentID)
+	case nodeID > maxNodeID:
+		return nil, fmt.Errorf("node ID must be in [0, %d]", maxNodeID)
+	}
+
+	return &Generator{
+		componentID:   componentID,
+		nodeID:        nodeID,
+		lastTimestamp: -1,
+		now:           time.Now,
+	}, nil
+}
+
+// LocalNodeIDs derives the component and node IDs of the generator from the host name, so
+// the generators of different hosts are likely to have different IDs.
+func LocalNodeIDs(hostname string) (componentID, nodeID uint64) {
+	var h = fnv.New32a()
+	h.Write([]byte(hostname))
+	var id = uint64(h.Sum32()) % (1 << (componentIDBits + nodeIDBits))
+	return id >> nodeIDBits, id & maxNodeID
+}
+
+// NextValidID returns a new ID. When the sequence of the current millisecond is
+// exhausted, it waits for the next millisecond. If the clock goes backward, the IDs keep
+// the last timestamp until the clock catches up, so they stay sortable.
+func (g *Generator) NextValidID() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var timestamp = g.timestamp()
+	if timestamp < g.lastTimestamp {
+		timestamp = g.lastTimestamp
+	}
+
+	if timestamp == g.lastTimestamp {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			for timestamp <= g.lastTimestamp {
+				time.Sleep(100 * time.Microsecond)
+				timestamp = g.timestamp()
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTimestamp = timestamp
+
+	return uint64(timestamp)<<timestampShift | g.componentID<<componentIDShift | g.nodeID<<nodeIDShift | g.sequence
+}
+
+// timestamp returns the milliseconds since the epoch.
+func (g *Generator) timestamp() int64 {
+	return int64(g.now().Sub(epoch) / time.Millisecond)
+}
@@ -0,0 +1,80 @@
+package flaki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGeneratorInvalidIDs(t *testing.T) {
+	var _, err = NewGenerator(4, 0)
+	assert.NotNil(t, err)
+
+	_, err = NewGenerator(0, 8)
+	assert.NotNil(t, err)
+}
+
+func TestGeneratorLayout(t *testing.T) {
+	var g, err = NewGenerator(2, 5)
+	assert.Nil(t, err)
+	g.now = func() time.Time { return epoch.Add(1234 * time.Millisecond) }
+
+	// 1234<<21 | 2<<19 | 5<<16 | 0: timestamp, component ID, node ID and sequence.
+	var id = g.NextValidID()
+	assert.Equal(t, uint64(2589261824), id)
+
+	// Same millisecond, next sequence.
+	assert.Equal(t, id+1, g.NextValidID())
+}
+
+func TestGeneratorSortable(t *testing.T) {
+	var g, err = NewGenerator(0, 0)
+	assert.Nil(t, err)
+	var now = epoch.Add(time.Hour)
+	g.now = func() time.Time { return now }
+
+	var last = g.NextValidID()
+
+	// Next millisecond.
+	now = now.Add(time.Millisecond)
+	var id = g.NextValidID()
+	assert.True(t, id > last)
+	assert.Equal(t, uint64(0), id&maxSequence)
+	last = id
+
+	// The clock goes backward.
+	now = now.Add(-time.Second)
+	id = g.NextValidID()
+	assert.True(t, id > last)
+}
+
+func TestGeneratorSequenceExhausted(t *testing.T) {
+	var g, err = NewGenerator(0, 0)
+	assert.Nil(t, err)
+	var now = epoch.Add(time.Hour)
+	var calls = 0
+	g.now = func() time.Time {
+		calls++
+		if calls > maxSequence+2 {
+			return now.Add(time.Millisecond)
+		}
+		return now
+	}
+
+	var ids = map[uint64]bool{}
+	for i := 0; i <= maxSequence+1; i++ {
+		ids[g.NextValidID()] = true
+	}
+	assert.Equal(t, maxSequence+2, len(ids))
+}
+
+func TestLocalNodeIDs(t *testing.T) {
+	var componentID, nodeID = LocalNodeIDs("elasticsearch-bridge-1")
+	assert.True(t, componentID <= maxComponentID)
+	assert.True(t, nodeID <= maxNodeID)
+
+	var c, n = LocalNodeIDs("elasticsearch-bridge-1")
+	assert.Equal(t, componentID, c)
+	assert.Equal(t, nodeID, n)
+}
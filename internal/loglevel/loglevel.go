@@ -0,0 +1,240 @@
+// Package loglevel filters the logs by level, per unit or svc tag, with levels that can
+// be changed at runtime for a limited time.
+package loglevel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Levels, from the most to the least verbose.
+const (
+	Debug = "debug"
+	Info  = "info"
+	Warn  = "warn"
+	Error = "error"
+)
+
+var ranks = map[string]int{
+	Debug: 0,
+	Info:  1,
+	Warn:  2,
+	Error: 3,
+}
+
+// Tags selecting the logs.
+const (
+	UnitTag = "unit"
+	SvcTag  = "svc"
+)
+
+// override is a level set at runtime, until it expires.
+type override struct {
+	level  string
+	expiry time.Time
+}
+
+// Levels are the log levels: the default level, and the levels of the logs with a given
+// unit or svc tag. The unit level wins over the svc level. The levels set at runtime win
+// over the configured ones until they expire.
+type Levels struct {
+	mu         sync.RWMutex
+	configured map[string]string
+	overrides  map[string]override
+	now        func() time.Time
+}
+
+// New returns the levels. The level maps are keyed by the unit or svc tag values, which
+// are not case sensitive.
+func New(defaultLevel string, unitLevels, svcLevels map[string]string) (*Levels, error) {
+	var l = &Levels{
+		configured: map[string]string{},
+		overrides:  map[string]override{},
+		now:        time.Now,
+	}
+
+	if err := l.configure("", "", defaultLevel); err != nil {
+		return nil, err
+	}
+	for tag, m := range map[string]map[string]string{UnitTag: unitLevels, SvcTag: svcLevels} {
+		for value, lvl := range m {
+			if err := l.configure(tag, value, lvl); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l, nil
+}
+
+func (l *Levels) configure(tag, value, lvl string) error {
+	if _, ok := ranks[lvl]; !ok {
+		return fmt.Errorf("unknown log level '%s'", lvl)
+	}
+	l.configured[levelKey(tag, value)] = lvl
+	return nil
+}
+
+// Set sets the level of the logs with the tag value, or the default level if the tag is
+// empty, for the given time. The previous level is restored when it expires.
+func (l *Levels) Set(tag, value, lvl string, ttl time.Duration) (time.Time, error) {
+	switch {
+	case tag != "" && tag != UnitTag && tag != SvcTag:
+		return time.Time{}, fmt.Errorf("unknown tag '%s'", tag)
+	case ttl <= 0:
+		return time.Time{}, fmt.Errorf("invalid TTL %s", ttl)
+	}
+	if _, ok := ranks[lvl]; !ok {
+		return time.Time{}, fmt.Errorf("unknown log level '%s'", lvl)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var now = l.now()
+	for k, o := range l.overrides {
+		if !now.Before(o.expiry) {
+			delete(l.overrides, k)
+		}
+	}
+
+	var expiry = now.Add(ttl)
+	l.overrides[levelKey(tag, value)] = override{level: lvl, expiry: expiry}
+	return expiry, nil
+}
+
+// Logger returns a logger that only passes the logs at or above their level to the next
+// logger. The logs without level are at info level.
+func (l *Levels) Logger(next log.Logger) log.Logger {
+	return log.LoggerFunc(func(keyvals ...interface{}) error {
+		if !l.allowed(keyvals) {
+			return nil
+		}
+		return next.Log(keyvals...)
+	})
+}
+
+// allowed returns whether the log is at or above its level. The last unit and svc tags of
+// the log select its level.
+func (l *Levels) allowed(keyvals []interface{}) bool {
+	var lvl = Info
+	var unit, svc string
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch v := keyvals[i+1].(type) {
+		case level.Value:
+			lvl = v.String()
+		default:
+			switch keyvals[i] {
+			case UnitTag:
+				unit = fmt.Sprint(v)
+			case SvcTag:
+				svc = fmt.Sprint(v)
+			}
+		}
+	}
+
+	return ranks[lvl] >= ranks[l.level(unit, svc)]
+}
+
+// level returns the level of the logs with the unit and svc tags.
+func (l *Levels) level(unit, svc string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var now = l.now()
+	var keys = []string{levelKey("", "")}
+	if svc != "" {
+		keys = append([]string{levelKey(SvcTag, svc)}, keys...)
+	}
+	if unit != "" {
+		keys = append([]string{levelKey(UnitTag, unit)}, keys...)
+	}
+
+	for _, k := range keys {
+		if o, ok := l.overrides[k]; ok && now.Before(o.expiry) {
+			return o.level
+		}
+		if lvl, ok := l.configured[k]; ok {
+			return lvl
+		}
+	}
+	return Info
+}
+
+func levelKey(tag, value string) string {
+	if tag == "" {
+		return ""
+	}
+	return tag + "=" + strings.ToLower(value)
+}
+
+// levelRequest is the body of the log level requests. At most one of unit and svc is set,
+// the default level is changed if there is none. The TTL is a duration, e.g. "10m".
+type levelRequest struct {
+	Level string `json:"level"`
+	Unit  string `json:"unit,omitempty"`
+	Svc   string `json:"svc,omitempty"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+// levelReply is the reply of the log level requests.
+type levelReply struct {
+	Level   string `json:"level"`
+	Unit    string `json:"unit,omitempty"`
+	Svc     string `json:"svc,omitempty"`
+	Expires string `json:"expires"`
+}
+
+// MakeHandler makes the HTTP handler changing the log levels. The level reverts after the
+// TTL of the request, or the default TTL if there is none.
+func MakeHandler(levels *Levels, defaultTTL time.Duration, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var r levelRequest
+		if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+			http.Error(w, fmt.Sprintf("could not decode request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var tag, value string
+		switch {
+		case r.Unit != "" && r.Svc != "":
+			http.Error(w, "only one of unit and svc can be set", http.StatusBadRequest)
+			return
+		case r.Unit != "":
+			tag, value = UnitTag, r.Unit
+		case r.Svc != "":
+			tag, value = SvcTag, r.Svc
+		}
+
+		var ttl = defaultTTL
+		if r.TTL != "" {
+			var err error
+			ttl, err = time.ParseDuration(r.TTL)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("could not parse TTL: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var expiry, err = levels.Set(tag, value, r.Level, ttl)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level.Info(logger).Log("msg", "log level changed", "log_level", r.Level, "tag", tag, "value", value, "expires", expiry.UTC().Format(time.RFC3339))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelReply{
+			Level:   r.Level,
+			Unit:    r.Unit,
+			Svc:     r.Svc,
+			Expires: expiry.UTC().Format(time.RFC3339),
+		})
+	}
+}
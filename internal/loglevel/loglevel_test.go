@@ -0,0 +1,117 @@
+package loglevel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInvalidLevel(t *testing.T) {
+	var _, err = New("verbose", nil, nil)
+	assert.NotNil(t, err)
+
+	_, err = New(Info, map[string]string{"jaeger": "verbose"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestLogger(t *testing.T) {
+	var levels, err = New(Info, map[string]string{"Jaeger": Debug, "ExecInfluxHealthCheck": Error}, map[string]string{"health": Warn})
+	assert.Nil(t, err)
+
+	var logs []string
+	var logger = levels.Logger(log.LoggerFunc(func(keyvals ...interface{}) error {
+		logs = append(logs, keyvals[len(keyvals)-1].(string))
+		return nil
+	}))
+
+	var allowed = func(logger log.Logger) bool {
+		logs = nil
+		logger.Log("msg", "log")
+		return len(logs) == 1
+	}
+
+	// Default level.
+	assert.False(t, allowed(level.Debug(logger)))
+	assert.True(t, allowed(level.Info(logger)))
+	assert.True(t, allowed(logger))
+
+	// Unit level, not case sensitive.
+	assert.True(t, allowed(level.Debug(log.With(logger, "unit", "jaeger"))))
+
+	// Svc level.
+	var healthLogger = log.With(logger, "svc", "health")
+	assert.False(t, allowed(level.Info(healthLogger)))
+	assert.True(t, allowed(level.Warn(healthLogger)))
+
+	// The unit level wins over the svc level.
+	assert.True(t, allowed(level.Debug(log.With(healthLogger, "unit", "jaeger"))))
+	assert.False(t, allowed(level.Warn(log.With(healthLogger, "mw", "endpoint", "unit", "ExecInfluxHealthCheck"))))
+}
+
+func TestSet(t *testing.T) {
+	var levels, err = New(Info, nil, nil)
+	assert.Nil(t, err)
+	var now = time.Date(2018, 2, 13, 6, 27, 7, 0, time.UTC)
+	levels.now = func() time.Time { return now }
+
+	var debug = []interface{}{level.Key(), level.DebugValue(), "unit", "jaeger"}
+
+	// The level set at runtime wins over the configured level.
+	var expiry time.Time
+	expiry, err = levels.Set(UnitTag, "jaeger", Debug, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(time.Minute), expiry)
+	assert.True(t, levels.allowed(debug))
+
+	// The level reverts after the TTL.
+	now = now.Add(time.Minute)
+	assert.False(t, levels.allowed(debug))
+
+	// Invalid requests.
+	_, err = levels.Set("component", "jaeger", Debug, time.Minute)
+	assert.NotNil(t, err)
+	_, err = levels.Set(UnitTag, "jaeger", "verbose", time.Minute)
+	assert.NotNil(t, err)
+	_, err = levels.Set(UnitTag, "jaeger", Debug, 0)
+	assert.NotNil(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	var levels, err = New(Info, nil, nil)
+	assert.Nil(t, err)
+	var h = MakeHandler(levels, 10*time.Minute, log.NewNopLogger())
+
+	var put = func(body string) *httptest.ResponseRecorder {
+		var w = httptest.NewRecorder()
+		h(w, httptest.NewRequest("PUT", "/debug/loglevel", strings.NewReader(body)))
+		return w
+	}
+
+	// Default level, with default TTL.
+	{
+		var w = put(`{"level":"debug"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"level":"debug"`)
+		assert.True(t, levels.allowed([]interface{}{level.Key(), level.DebugValue()}))
+	}
+
+	// Svc level, with TTL.
+	{
+		var w = put(`{"level":"error","svc":"health","ttl":"1m"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"svc":"health"`)
+		assert.False(t, levels.allowed([]interface{}{level.Key(), level.WarnValue(), "svc", "health"}))
+	}
+
+	// Invalid requests.
+	for _, body := range []string{`{"level":`, `{"level":"debug","unit":"jaeger","svc":"health"}`, `{"level":"debug","ttl":"1 minute"}`, `{"level":"verbose"}`, `{"level":"debug","ttl":"-1m"}`} {
+		var w = put(body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,313 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	deleteHealthStmt = `DELETE FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`
+	selectLockStmt   = `SELECT component_name FROM locks WHERE (component_name = $1) LIMIT 1`
+	selectNodesStmt  = `SELECT node_id, is_live FROM crdb_internal.gossip_nodes`
+)
+
+// CockroachModule is the health check module for Cockroach.
+type CockroachModule struct {
+	healthDB         CockroachClient
+	jobsDB           CockroachClient
+	componentName    string
+	componentID      string
+	latencyThreshold time.Duration
+	enabled          bool
+}
+
+// CockroachClient is the interface of the Cockroach DB.
+type CockroachClient interface {
+	PingContext(ctx context.Context) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// NewCockroachModule returns the Cockroach health module. The round-trip check writes
+// a probe row in the health table, under the component name suffixed by '-probe' so it
+// is not mistaken for a health report. A read or write slower than the latency threshold
+// is degraded.
+func NewCockroachModule(healthDB, jobsDB CockroachClient, componentName, componentID string, latencyThreshold time.Duration, enabled bool) *CockroachModule {
+	return &CockroachModule{
+		healthDB:         healthDB,
+		jobsDB:           jobsDB,
+		componentName:    componentName,
+		componentID:      componentID,
+		latencyThreshold: latencyThreshold,
+		enabled:          enabled,
+	}
+}
+
+// CockroachReport is the health report returned by the Cockroach module.
+type CockroachReport struct {
+	Name     string
+	Duration time.Duration
+	Status   Status
+	Error    error
+	Infos    json.RawMessage
+}
+
+func (i *CockroachReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Name     string          `json:"name"`
+		Duration string          `json:"duration"`
+		Status   string          `json:"status"`
+		Error    string          `json:"error"`
+		Infos    json.RawMessage `json:"infos,omitempty"`
+	}{
+		Name:     i.Name,
+		Duration: i.Duration.String(),
+		Status:   i.Status.String(),
+		Error:    err(i.Error),
+		Infos:    i.Infos,
+	})
+}
+
+// HealthChecks executes all health checks for Cockroach.
+func (m *CockroachModule) HealthChecks(ctx context.Context) []CockroachReport {
+	if !m.enabled {
+		return []CockroachReport{{Name: "cockroach", Status: Deactivated}}
+	}
+
+	var reports = []CockroachReport{}
+	reports = append(reports, m.cockroachPingCheck(ctx))
+	reports = append(reports, m.cockroachWriteCheck(ctx))
+	reports = append(reports, m.cockroachReadCheck(ctx))
+	reports = append(reports, m.cockroachLockCheck(ctx))
+	reports = append(reports, m.cockroachLivenessCheck(ctx))
+	return reports
+}
+
+func (m *CockroachModule) cockroachPingCheck(ctx context.Context) CockroachReport {
+	var healthCheckName = "Ping"
+
+	var now = time.Now()
+	var err = m.healthDB.PingContext(ctx)
+	var duration = time.Since(now)
+
+	var hcErr error
+	var s Status
+	switch {
+	case err != nil:
+		hcErr = errors.Wrap(err, "could not ping cockroach")
+		s = KO
+	default:
+		s = OK
+	}
+
+	return CockroachReport{
+		Name:     healthCheckName,
+		Duration: duration,
+		Status:   s,
+		Error:    hcErr,
+	}
+}
+
+func (m *CockroachModule) cockroachWriteCheck(ctx context.Context) CockroachReport {
+	var healthCheckName = "Health table write"
+
+	var now = time.Now()
+	var _, err = m.healthDB.ExecContext(ctx, upsertHealthStmt, probeComponentName(m.componentName), m.componentID, "cockroach", "{}", now.UTC(), now.UTC())
+	var duration = time.Since(now)
+
+	var hcErr error
+	var s Status
+	switch {
+	case err != nil:
+		hcErr = errors.Wrap(err, "could not write in health table")
+		s = KO
+	case m.latencyThreshold > 0 && duration > m.latencyThreshold:
+		hcErr = errors.Errorf("write latency %s above %s", duration, m.latencyThreshold)
+		s = Degraded
+	default:
+		s = OK
+	}
+
+	return CockroachReport{
+		Name:     healthCheckName,
+		Duration: duration,
+		Status:   s,
+		Error:    hcErr,
+	}
+}
+
+func (m *CockroachModule) cockroachReadCheck(ctx context.Context) CockroachReport {
+	var healthCheckName = "Health table read"
+
+	var now = time.Now()
+	var found, err = m.probeRowFound(ctx)
+	var duration = time.Since(now)
+
+	// The probe row is deleted whatever the outcome of the read. The rows left by a failed
+	// deletion are removed by the clean job.
+	var _, deleteErr = m.healthDB.ExecContext(ctx, deleteHealthStmt, probeComponentName(m.componentName), m.componentID, "cockroach")
+
+	var hcErr error
+	var s Status
+	switch {
+	case err != nil:
+		hcErr = errors.Wrap(err, "could not read health table")
+		s = KO
+	case !found:
+		hcErr = errors.New("probe row not found in health table")
+		s = KO
+	case deleteErr != nil:
+		hcErr = errors.Wrap(deleteErr, "could not delete probe row from health table")
+		s = KO
+	case m.latencyThreshold > 0 && duration > m.latencyThreshold:
+		hcErr = errors.Errorf("read latency %s above %s", duration, m.latencyThreshold)
+		s = Degraded
+	default:
+		s = OK
+	}
+
+	return CockroachReport{
+		Name:     healthCheckName,
+		Duration: duration,
+		Status:   s,
+		Error:    hcErr,
+	}
+}
+
+func (m *CockroachModule) probeRowFound(ctx context.Context) (bool, error) {
+	var rows, err = m.healthDB.QueryContext(ctx, selectHealthStmt, probeComponentName(m.componentName), m.componentID, "cockroach")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var found = rows.Next()
+	return found, rows.Err()
+}
+
+func (m *CockroachModule) cockroachLockCheck(ctx context.Context) CockroachReport {
+	var healthCheckName = "Job lock table"
+
+	var now = time.Now()
+	var rows, err = m.jobsDB.QueryContext(ctx, selectLockStmt, m.componentName)
+	if err == nil {
+		rows.Close()
+	}
+	var duration = time.Since(now)
+
+	var hcErr error
+	var s Status
+	switch {
+	case err != nil:
+		hcErr = errors.Wrap(err, "could not reach job lock table")
+		s = KO
+	default:
+		s = OK
+	}
+
+	return CockroachReport{
+		Name:     healthCheckName,
+		Duration: duration,
+		Status:   s,
+		Error:    hcErr,
+	}
+}
+
+func (m *CockroachModule) cockroachLivenessCheck(ctx context.Context) CockroachReport {
+	var healthCheckName = "Node liveness"
+
+	var now = time.Now()
+	var nodes, liveNodes, err = m.nodesLiveness(ctx)
+	var duration = time.Since(now)
+
+	var hcErr error
+	var s Status
+	switch {
+	case err != nil:
+		hcErr = errors.Wrap(err, "could not read nodes liveness")
+		s = KO
+	case liveNodes == 0:
+		hcErr = errors.New("no live node")
+		s = KO
+	case liveNodes < nodes:
+		hcErr = errors.Errorf("%d of %d nodes are not live", nodes-liveNodes, nodes)
+		s = Degraded
+	default:
+		s = OK
+	}
+
+	var jsonInfos, _ = json.Marshal(map[string]int{"nodes": nodes, "live_nodes": liveNodes})
+
+	return CockroachReport{
+		Name:     healthCheckName,
+		Duration: duration,
+		Status:   s,
+		Error:    hcErr,
+		Infos:    jsonInfos,
+	}
+}
+
+func (m *CockroachModule) nodesLiveness(ctx context.Context) (nodes, liveNodes int, err error) {
+	var rows *sql.Rows
+	rows, err = m.healthDB.QueryContext(ctx, selectNodesStmt)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			nodeID int
+			isLive bool
+		)
+		if err = rows.Scan(&nodeID, &isLive); err != nil {
+			return 0, 0, err
+		}
+		nodes++
+		if isLive {
+			liveNodes++
+		}
+	}
+	return nodes, liveNodes, rows.Err()
+}
+
+// probeComponentName is the component name of the probe row written by the round-trip check.
+func probeComponentName(componentName string) string {
+	return componentName + "-probe"
+}
+
+// ICockroachHealthChecker is the interface of the cockroach health check module.
+type ICockroachHealthChecker interface {
+	HealthChecks(context.Context) []CockroachReport
+}
+
+// Logging middleware at module level.
+type cockroachModuleLoggingMW struct {
+	logger log.Logger
+	next   ICockroachHealthChecker
+}
+
+// MakeCockroachModuleLoggingMW makes a logging middleware at module level.
+func MakeCockroachModuleLoggingMW(logger log.Logger) func(ICockroachHealthChecker) ICockroachHealthChecker {
+	return func(next ICockroachHealthChecker) ICockroachHealthChecker {
+		return &cockroachModuleLoggingMW{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+// cockroachModuleLoggingMW implements Module.
+func (m *cockroachModuleLoggingMW) HealthChecks(ctx context.Context) []CockroachReport {
+	defer func(begin time.Time) {
+		level.Debug(m.logger).Log("unit", "HealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
+	}(time.Now())
+
+	return m.next.HealthChecks(ctx)
+}
@@ -0,0 +1,99 @@
+package health_test
+
+//go:generate mockgen -destination=./mock/cockroach.go -package=mock -mock_names=CockroachClient=CockroachClient  github.com/cloudtrust/elasticsearch-bridge/pkg/health CockroachClient
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachHealthChecksDeactivated(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockHealthDB = mock.NewCockroachClient(mockCtrl)
+	var mockJobsDB = mock.NewCockroachClient(mockCtrl)
+
+	var m = NewCockroachModule(mockHealthDB, mockJobsDB, "elasticsearch-bridge", "1", time.Second, false)
+
+	var reports = m.HealthChecks(context.Background())
+	assert.Equal(t, 1, len(reports))
+	assert.Equal(t, "cockroach", reports[0].Name)
+	assert.Equal(t, Deactivated, reports[0].Status)
+}
+
+func TestCockroachHealthChecksFailure(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockHealthDB = mock.NewCockroachClient(mockCtrl)
+	var mockJobsDB = mock.NewCockroachClient(mockCtrl)
+
+	var m = NewCockroachModule(mockHealthDB, mockJobsDB, "elasticsearch-bridge", "1", time.Second, true)
+
+	// The queries are bound to the context of the health checks.
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	mockHealthDB.EXPECT().PingContext(ctx).Return(fmt.Errorf("connection refused")).Times(1)
+	mockHealthDB.EXPECT().ExecContext(ctx, upsertHealthStmt, "elasticsearch-bridge-probe", "1", "cockroach", "{}", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("connection refused")).Times(1)
+	mockHealthDB.EXPECT().QueryContext(ctx, selectHealthStmt, "elasticsearch-bridge-probe", "1", "cockroach").Return(nil, fmt.Errorf("connection refused")).Times(1)
+	mockHealthDB.EXPECT().ExecContext(ctx, `DELETE FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`, "elasticsearch-bridge-probe", "1", "cockroach").Return(nil, fmt.Errorf("connection refused")).Times(1)
+	mockJobsDB.EXPECT().QueryContext(ctx, `SELECT component_name FROM locks WHERE (component_name = $1) LIMIT 1`, "elasticsearch-bridge").Return(nil, fmt.Errorf("connection refused")).Times(1)
+	mockHealthDB.EXPECT().QueryContext(ctx, `SELECT node_id, is_live FROM crdb_internal.gossip_nodes`).Return(nil, fmt.Errorf("connection refused")).Times(1)
+
+	var reports = m.HealthChecks(ctx)
+	assert.Equal(t, 5, len(reports))
+	for i, name := range []string{"Ping", "Health table write", "Health table read", "Job lock table", "Node liveness"} {
+		assert.Equal(t, name, reports[i].Name)
+		assert.Equal(t, KO, reports[i].Status)
+		assert.NotNil(t, reports[i].Error)
+	}
+	assert.Equal(t, `{"live_nodes":0,"nodes":0}`, string(reports[4].Infos))
+}
+
+func TestCockroachModuleLoggingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var module = NewCockroachModule(nil, nil, "elasticsearch-bridge", "1", time.Second, false)
+	var m = MakeCockroachModuleLoggingMW(mockLogger)(module)
+
+	// Context with correlation ID.
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = correlation.WithID(context.Background(), corrID)
+
+	mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "HealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	m.HealthChecks(ctx)
+
+	// Without correlation ID.
+	var f = func() {
+		m.HealthChecks(context.Background())
+	}
+	assert.Panics(t, f)
+}
+
+func TestCockroachReportMarshalJSON(t *testing.T) {
+	var report = &CockroachReport{
+		Name:     "Ping",
+		Duration: 1 * time.Second,
+		Status:   KO,
+		Error:    fmt.Errorf("Error"),
+	}
+
+	jsonR, err := report.MarshalJSON()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"Ping","duration":"1s","status":"KO","error":"Error"}`, string(jsonR))
+}
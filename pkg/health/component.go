@@ -33,6 +33,7 @@ const (
 	sentryUnitName        = "sentry"
 	flakiUnitName         = "flaki"
 	elasticsearchUnitName = "elasticsearch"
+	cockroachUnitName     = "cockroach"
 )
 
 var statusName = []string{"OK", "KO", "Degraded", "Deactivated", "Unknown"}
@@ -83,11 +84,18 @@ type ElasticsearchHealthChecker interface {
 	HealthChecks(context.Context) []ElasticsearchReport
 }
 
+// CockroachHealthChecker is the interface of the cockroach health check module.
+type CockroachHealthChecker interface {
+	HealthChecks(context.Context) []CockroachReport
+}
+
 // StoreModule is the interface of the module that stores the health reports
 // in the DB.
 type StoreModule interface {
 	Read(name string) (StoredReport, error)
+	ReadAll() ([]StoredReport, error)
 	Update(unit string, validity time.Duration, reports json.RawMessage) error
+	Clean() error
 }
 
 // Component is the Health component.
@@ -98,14 +106,19 @@ type Component struct {
 	sentry              SentryHealthChecker
 	flaki               FlakiHealthChecker
 	elasticsearch       ElasticsearchHealthChecker
+	cockroach           CockroachHealthChecker
 	storage             StoreModule
 	healthCheckValidity map[string]time.Duration
+	healthCheckTimeout  map[string]time.Duration
+	deepTimeout         time.Duration
 }
 
-// NewComponent returns the health component.
+// NewComponent returns the health component. The health checks of a unit run under the
+// unit timeout, and the deep health checks under the deep timeout. A timeout of 0 disables it.
 func NewComponent(influx InfluxHealthChecker, jaeger JaegerHealthChecker, redis RedisHealthChecker,
 	sentry SentryHealthChecker, flaki FlakiHealthChecker, elasticsearch ElasticsearchHealthChecker,
-	storage StoreModule, healthCheckValidity map[string]time.Duration) *Component {
+	cockroach CockroachHealthChecker, storage StoreModule, healthCheckValidity map[string]time.Duration, healthCheckTimeout map[string]time.Duration,
+	deepTimeout time.Duration) *Component {
 	return &Component{
 		influx:              influx,
 		jaeger:              jaeger,
@@ -113,18 +126,19 @@ func NewComponent(influx InfluxHealthChecker, jaeger JaegerHealthChecker, redis
 		sentry:              sentry,
 		flaki:               flaki,
 		elasticsearch:       elasticsearch,
+		cockroach:           cockroach,
 		storage:             storage,
 		healthCheckValidity: healthCheckValidity,
+		healthCheckTimeout:  healthCheckTimeout,
+		deepTimeout:         deepTimeout,
 	}
 }
 
 // ExecInfluxHealthChecks executes the health checks for Influx.
 func (c *Component) ExecInfluxHealthChecks(ctx context.Context) json.RawMessage {
-	var reports = c.influx.HealthChecks(ctx)
-	var jsonReports, _ = json.Marshal(reports)
-
-	c.storage.Update(influxUnitName, c.healthCheckValidity[influxUnitName], jsonReports)
-	return json.RawMessage(jsonReports)
+	return c.execHealthChecks(ctx, influxUnitName, func(ctx context.Context) interface{} {
+		return c.influx.HealthChecks(ctx)
+	})
 }
 
 // ReadInfluxHealthChecks read the health checks status in DB.
@@ -134,11 +148,9 @@ func (c *Component) ReadInfluxHealthChecks(ctx context.Context) json.RawMessage
 
 // ExecJaegerHealthChecks executes the health checks for Jaeger.
 func (c *Component) ExecJaegerHealthChecks(ctx context.Context) json.RawMessage {
-	var reports = c.jaeger.HealthChecks(ctx)
-	var jsonReports, _ = json.Marshal(reports)
-
-	c.storage.Update(jaegerUnitName, c.healthCheckValidity[jaegerUnitName], jsonReports)
-	return json.RawMessage(jsonReports)
+	return c.execHealthChecks(ctx, jaegerUnitName, func(ctx context.Context) interface{} {
+		return c.jaeger.HealthChecks(ctx)
+	})
 }
 
 // ReadJaegerHealthChecks read the health checks status in DB.
@@ -148,12 +160,9 @@ func (c *Component) ReadJaegerHealthChecks(ctx context.Context) json.RawMessage
 
 // ExecRedisHealthChecks executes the health checks for Redis.
 func (c *Component) ExecRedisHealthChecks(ctx context.Context) json.RawMessage {
-	var reports = c.redis.HealthChecks(ctx)
-	var jsonReports, _ = json.Marshal(reports)
-
-	c.storage.Update(redisUnitName, c.healthCheckValidity[redisUnitName], jsonReports)
-	return json.RawMessage(jsonReports)
-
+	return c.execHealthChecks(ctx, redisUnitName, func(ctx context.Context) interface{} {
+		return c.redis.HealthChecks(ctx)
+	})
 }
 
 // ReadRedisHealthChecks read the health checks status in DB.
@@ -163,11 +172,9 @@ func (c *Component) ReadRedisHealthChecks(ctx context.Context) json.RawMessage {
 
 // ExecSentryHealthChecks executes the health checks for Sentry.
 func (c *Component) ExecSentryHealthChecks(ctx context.Context) json.RawMessage {
-	var reports = c.sentry.HealthChecks(ctx)
-	var jsonReports, _ = json.Marshal(reports)
-
-	c.storage.Update(sentryUnitName, c.healthCheckValidity[sentryUnitName], jsonReports)
-	return json.RawMessage(jsonReports)
+	return c.execHealthChecks(ctx, sentryUnitName, func(ctx context.Context) interface{} {
+		return c.sentry.HealthChecks(ctx)
+	})
 }
 
 // ReadSentryHealthChecks read the health checks status in DB.
@@ -177,11 +184,9 @@ func (c *Component) ReadSentryHealthChecks(ctx context.Context) json.RawMessage
 
 // ExecFlakiHealthChecks executes the health checks for Flaki.
 func (c *Component) ExecFlakiHealthChecks(ctx context.Context) json.RawMessage {
-	var reports = c.flaki.HealthChecks(ctx)
-	var jsonReports, _ = json.Marshal(reports)
-
-	c.storage.Update(flakiUnitName, c.healthCheckValidity[flakiUnitName], jsonReports)
-	return json.RawMessage(jsonReports)
+	return c.execHealthChecks(ctx, flakiUnitName, func(ctx context.Context) interface{} {
+		return c.flaki.HealthChecks(ctx)
+	})
 }
 
 // ReadFlakiHealthChecks read the health checks status in DB.
@@ -191,11 +196,9 @@ func (c *Component) ReadFlakiHealthChecks(ctx context.Context) json.RawMessage {
 
 // ExecElasticsearchHealthChecks executes the health checks for Flaki.
 func (c *Component) ExecElasticsearchHealthChecks(ctx context.Context) json.RawMessage {
-	var reports = c.elasticsearch.HealthChecks(ctx)
-	var jsonReports, _ = json.Marshal(reports)
-
-	c.storage.Update(elasticsearchUnitName, c.healthCheckValidity[elasticsearchUnitName], jsonReports)
-	return json.RawMessage(jsonReports)
+	return c.execHealthChecks(ctx, elasticsearchUnitName, func(ctx context.Context) interface{} {
+		return c.elasticsearch.HealthChecks(ctx)
+	})
 }
 
 // ReadElasticsearchHealthChecks read the health checks status in DB.
@@ -203,6 +206,23 @@ func (c *Component) ReadElasticsearchHealthChecks(ctx context.Context) json.RawM
 	return c.readFromDB(elasticsearchUnitName)
 }
 
+// ExecCockroachHealthChecks executes the health checks for Cockroach.
+func (c *Component) ExecCockroachHealthChecks(ctx context.Context) json.RawMessage {
+	return c.execHealthChecks(ctx, cockroachUnitName, func(ctx context.Context) interface{} {
+		return c.cockroach.HealthChecks(ctx)
+	})
+}
+
+// ReadCockroachHealthChecks read the health checks status in DB. The DB may be Cockroach
+// itself, so when it cannot be read the health checks are executed.
+func (c *Component) ReadCockroachHealthChecks(ctx context.Context) json.RawMessage {
+	var storedReport, err = c.storage.Read(cockroachUnitName)
+	if err != nil {
+		return c.ExecCockroachHealthChecks(ctx)
+	}
+	return c.storedReport(cockroachUnitName, storedReport)
+}
+
 // AllHealthChecks call all component checks and build a general health report.
 func (c *Component) AllHealthChecks(ctx context.Context) json.RawMessage {
 	var reports = map[string]json.RawMessage{}
@@ -213,36 +233,192 @@ func (c *Component) AllHealthChecks(ctx context.Context) json.RawMessage {
 	reports[sentryUnitName] = c.ReadSentryHealthChecks(ctx)
 	reports[flakiUnitName] = c.ReadFlakiHealthChecks(ctx)
 	reports[elasticsearchUnitName] = c.ReadElasticsearchHealthChecks(ctx)
+	reports[cockroachUnitName] = c.ReadCockroachHealthChecks(ctx)
 
 	var jsonReports, _ = json.Marshal(reports)
 	return json.RawMessage(jsonReports)
 }
 
+// DeepHealthChecks executes the health checks of all the units concurrently and builds
+// a general health report. It returns once the slowest unit finishes or the deep timeout
+// passes, the units still running are then reported KO.
+func (c *Component) DeepHealthChecks(ctx context.Context) json.RawMessage {
+	if c.deepTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.deepTimeout)
+		defer cancel()
+	}
+
+	var units = map[string]func(context.Context) json.RawMessage{
+		influxUnitName:        c.ExecInfluxHealthChecks,
+		jaegerUnitName:        c.ExecJaegerHealthChecks,
+		redisUnitName:         c.ExecRedisHealthChecks,
+		sentryUnitName:        c.ExecSentryHealthChecks,
+		flakiUnitName:         c.ExecFlakiHealthChecks,
+		elasticsearchUnitName: c.ExecElasticsearchHealthChecks,
+		cockroachUnitName:     c.ExecCockroachHealthChecks,
+	}
+
+	type unitReport struct {
+		unit   string
+		report json.RawMessage
+	}
+
+	var now = time.Now()
+	var reportc = make(chan unitReport, len(units))
+	for unit, exec := range units {
+		go func(unit string, exec func(context.Context) json.RawMessage) {
+			reportc <- unitReport{unit: unit, report: exec(ctx)}
+		}(unit, exec)
+	}
+
+	var reports = map[string]json.RawMessage{}
+	for len(reports) < len(units) {
+		select {
+		case r := <-reportc:
+			reports[r.unit] = r.report
+		case <-ctx.Done():
+			for unit := range units {
+				if _, ok := reports[unit]; !ok {
+					reports[unit] = timeoutReport(unit, time.Since(now), ctx.Err())
+				}
+			}
+		}
+	}
+
+	var jsonReports, _ = json.Marshal(reports)
+	return json.RawMessage(jsonReports)
+}
+
+// FleetHealthChecks reads the health checks status of all the instances of the component in DB
+// and builds the fleet health report.
+func (c *Component) FleetHealthChecks(ctx context.Context) json.RawMessage {
+	var storedReports, err = c.storage.ReadAll()
+
+	var fleet FleetReport
+	if err != nil {
+		fleet = FleetReport{
+			Status:    Unknown.String(),
+			Error:     fmt.Sprintf("could not read reports from DB: %v", err),
+			Instances: []InstanceReport{},
+		}
+	} else {
+		fleet = fleetReport(storedReports, time.Now())
+	}
+
+	var jsonReport, _ = json.Marshal(fleet)
+	return json.RawMessage(jsonReport)
+}
+
+// execHealthChecks executes the health checks of the unit under the unit timeout, stores
+// their reports and returns them in a report envelope. If the timeout passes before the
+// health checks return, the unit is reported KO with a timeout error.
+func (c *Component) execHealthChecks(ctx context.Context, unit string, healthChecks func(context.Context) interface{}) json.RawMessage {
+	if timeout := c.healthCheckTimeout[unit]; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var begin = time.Now()
+	var reportsc = make(chan interface{}, 1)
+	go func() {
+		reportsc <- healthChecks(ctx)
+	}()
+
+	var reports interface{}
+	select {
+	case reports = <-reportsc:
+	case <-ctx.Done():
+		reports = timedOutReports(unit, time.Since(begin), ctx.Err())
+	}
+
+	var jsonReports, _ = json.Marshal(reports)
+	c.storage.Update(unit, c.healthCheckValidity[unit], jsonReports)
+
+	var now = time.Now().UTC()
+	var validUntil = now.Add(c.healthCheckValidity[unit])
+	return marshalReport(Report{
+		Unit:        unit,
+		Status:      unitStatus(jsonReports).String(),
+		LastUpdated: &now,
+		ValidUntil:  &validUntil,
+		Reports:     jsonReports,
+	})
+}
+
+// timedOutReport is the report of the health checks of a unit that timed out.
+type timedOutReport struct {
+	Name     string `json:"name"`
+	Duration string `json:"duration"`
+	Status   string `json:"status"`
+	Error    string `json:"error"`
+}
+
+// timedOutReports returns the reports of a unit whose health checks did not return
+// before the deadline.
+func timedOutReports(unit string, duration time.Duration, err error) []timedOutReport {
+	return []timedOutReport{{
+		Name:     unit,
+		Duration: duration.String(),
+		Status:   KO.String(),
+		Error:    fmt.Sprintf("health checks timed out: %v", err),
+	}}
+}
+
+// timeoutReport returns the report envelope of a unit whose health checks did not return
+// before the deadline.
+func timeoutReport(unit string, duration time.Duration, err error) json.RawMessage {
+	var jsonReports, _ = json.Marshal(timedOutReports(unit, duration, err))
+
+	return marshalReport(Report{
+		Unit:    unit,
+		Status:  KO.String(),
+		Reports: jsonReports,
+	})
+}
+
 func (c *Component) readFromDB(unit string) json.RawMessage {
 	var storedReport, err = c.storage.Read(unit)
 
 	if err != nil {
-		var error = fmt.Sprintf("could not read reports from DB: %v", err)
-		var jsonReport = fmt.Sprintf(`{"Name":"%s", "Status":"Unknown", "Error": "%s"}`, unit, error)
-		return json.RawMessage(jsonReport)
+		return marshalReport(Report{
+			Unit:   unit,
+			Status: Unknown.String(),
+			Error:  fmt.Sprintf("could not read reports from DB: %v", err),
+		})
 	}
 
+	return c.storedReport(unit, storedReport)
+}
+
+// storedReport returns the stored reports of the unit in a report envelope.
+func (c *Component) storedReport(unit string, storedReport StoredReport) json.RawMessage {
 	if storedReport.ComponentID == "" {
-		var error = fmt.Sprintf("no reports stored in DB")
-		var jsonReport = fmt.Sprintf(`{"Name":"%s", "Status":"Unknown", "Error": "%s"}`, unit, error)
-		return json.RawMessage(jsonReport)
+		return marshalReport(Report{
+			Unit:   unit,
+			Status: Unknown.String(),
+			Error:  "no reports stored in DB",
+		})
+	}
+
+	var report = Report{
+		Unit:        unit,
+		Status:      unitStatus(storedReport.Reports).String(),
+		LastUpdated: &storedReport.LastUpdated,
+		ValidUntil:  &storedReport.ValidUntil,
+		Reports:     storedReport.Reports,
 	}
 
 	// If the health check was executed too long ago, the health check report
 	// is considered not pertinant and an error is returned.
 	if time.Now().After(storedReport.ValidUntil) {
-		var error = fmt.Sprintf("the health check results are stale because the test was not executed in the last %s", c.healthCheckValidity[storedReport.HealthcheckUnit])
-		var jsonReport = fmt.Sprintf(`{"Name":"%s", "Error": "%s"}`, unit, error)
-		return json.RawMessage(jsonReport)
+		report.Status = Unknown.String()
+		report.Stale = true
+		report.Error = fmt.Sprintf("the health check results are stale because the test was not executed in the last %s", c.healthCheckValidity[storedReport.HealthcheckUnit])
 	}
 
-	return storedReport.Reports
-
+	return marshalReport(report)
 }
 
 // err return the string error that will be in the health report
@@ -1,6 +1,6 @@
 package health_test
 
-//go:generate mockgen -destination=./mock/module.go -package=mock -mock_names=InfluxHealthChecker=InfluxHealthChecker,JaegerHealthChecker=JaegerHealthChecker,RedisHealthChecker=RedisHealthChecker,SentryHealthChecker=SentryHealthChecker,FlakiHealthChecker=FlakiHealthChecker,ElasticsearchHealthChecker=ElasticsearchHealthChecker,StoreModule=StoreModule  github.com/cloudtrust/elasticsearch-bridge/pkg/health InfluxHealthChecker,JaegerHealthChecker,RedisHealthChecker,SentryHealthChecker,FlakiHealthChecker,ElasticsearchHealthChecker,StoreModule
+//go:generate mockgen -destination=./mock/module.go -package=mock -mock_names=InfluxHealthChecker=InfluxHealthChecker,JaegerHealthChecker=JaegerHealthChecker,RedisHealthChecker=RedisHealthChecker,SentryHealthChecker=SentryHealthChecker,FlakiHealthChecker=FlakiHealthChecker,ElasticsearchHealthChecker=ElasticsearchHealthChecker,CockroachHealthChecker=CockroachHealthChecker,StoreModule=StoreModule  github.com/cloudtrust/elasticsearch-bridge/pkg/health InfluxHealthChecker,JaegerHealthChecker,RedisHealthChecker,SentryHealthChecker,FlakiHealthChecker,ElasticsearchHealthChecker,CockroachHealthChecker,StoreModule
 
 import (
 	"context"
@@ -26,6 +26,7 @@ func TestHealthChecks(t *testing.T) {
 	var mockSentryModule = mock.NewSentryHealthChecker(mockCtrl)
 	var mockFlakiModule = mock.NewFlakiHealthChecker(mockCtrl)
 	var mockElasticsearchModule = mock.NewElasticsearchHealthChecker(mockCtrl)
+	var mockCockroachModule = mock.NewCockroachHealthChecker(mockCtrl)
 	var mockStorage = mock.NewStoreModule(mockCtrl)
 	var m = map[string]time.Duration{
 		"influx":        1 * time.Minute,
@@ -36,7 +37,7 @@ func TestHealthChecks(t *testing.T) {
 		"elasticsearch": 1 * time.Minute,
 	}
 
-	var c = NewComponent(mockInfluxModule, mockJaegerModule, mockRedisModule, mockSentryModule, mockFlakiModule, mockElasticsearchModule, mockStorage, m)
+	var c = NewComponent(mockInfluxModule, mockJaegerModule, mockRedisModule, mockSentryModule, mockFlakiModule, mockElasticsearchModule, mockCockroachModule, mockStorage, m, nil, 0)
 
 	var (
 		influxReports        = []common.InfluxReport{{Name: "influx", Duration: time.Duration(1 * time.Second), Status: common.OK}}
@@ -62,8 +63,7 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Update("influx", m["influx"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecInfluxHealthChecks(context.Background())
-		//	var json, _ = json.Marshal(&report)
-		assert.Equal(t, `[{"name":"influx","duration":"1s","status":"OK","error":""}]`, string(report))
+		assert.Equal(t, `[{"name":"influx","duration":"1s","status":"OK","error":""}]`, string(reportsOf(report)))
 	}
 
 	// Jaeger.
@@ -71,7 +71,7 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Update("jaeger", m["jaeger"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecJaegerHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"jaeger","duration":"1s","status":"OK","error":""}]`, string(json))
 	}
 
@@ -80,7 +80,7 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Update("redis", m["redis"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecRedisHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"redis","duration":"1s","status":"OK","error":""}]`, string(json))
 
 	}
@@ -90,7 +90,7 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Update("sentry", m["sentry"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecSentryHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"sentry","duration":"1s","status":"OK","error":""}]`, string(json))
 	}
 
@@ -99,7 +99,7 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Update("flaki", m["flaki"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecFlakiHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"flaki","duration":"1s","status":"OK","error":""}]`, string(json))
 	}
 
@@ -108,7 +108,7 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Update("elasticsearch", m["elasticsearch"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecElasticsearchHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"elasticsearch","duration":"1s","status":"OK","error":""}]`, string(json))
 	}
 
@@ -119,10 +119,19 @@ func TestHealthChecks(t *testing.T) {
 	mockStorage.EXPECT().Read("sentry").Return(makeStoredReport("sentry"), nil).Times(1)
 	mockStorage.EXPECT().Read("flaki").Return(makeStoredReport("flaki"), nil).Times(1)
 	mockStorage.EXPECT().Read("elasticsearch").Return(makeStoredReport("elasticsearch"), nil).Times(1)
+	mockStorage.EXPECT().Read("cockroach").Return(makeStoredReport("cockroach"), nil).Times(1)
 	{
 		var report = c.AllHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
-		assert.Equal(t, "{\"elasticsearch\":[{\"name\":\"XXX\",\"status\":\"OK\",\"duration\":\"1s\"}],\"flaki\":[{\"name\":\"XXX\",\"status\":\"OK\",\"duration\":\"1s\"}],\"influx\":[{\"name\":\"XXX\",\"status\":\"OK\",\"duration\":\"1s\"}],\"jaeger\":[{\"name\":\"XXX\",\"status\":\"OK\",\"duration\":\"1s\"}],\"redis\":[{\"name\":\"XXX\",\"status\":\"OK\",\"duration\":\"1s\"}],\"sentry\":[{\"name\":\"XXX\",\"status\":\"OK\",\"duration\":\"1s\"}]}", string(json))
+		var m map[string]Report
+		json.Unmarshal(report, &m)
+		assert.Equal(t, 7, len(m))
+		for unit, r := range m {
+			assert.Equal(t, ReportVersion, r.Version)
+			assert.Equal(t, unit, r.Unit)
+			assert.Equal(t, "OK", r.Status)
+			assert.False(t, r.Stale)
+			assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(r.Reports))
+		}
 	}
 
 }
@@ -136,6 +145,7 @@ func TestHealthChecksFail(t *testing.T) {
 	var mockSentryModule = mock.NewSentryHealthChecker(mockCtrl)
 	var mockFlakiModule = mock.NewFlakiHealthChecker(mockCtrl)
 	var mockElasticsearchModule = mock.NewElasticsearchHealthChecker(mockCtrl)
+	var mockCockroachModule = mock.NewCockroachHealthChecker(mockCtrl)
 	var mockStorage = mock.NewStoreModule(mockCtrl)
 	var m = map[string]time.Duration{
 		"influx":        1 * time.Minute,
@@ -146,7 +156,7 @@ func TestHealthChecksFail(t *testing.T) {
 		"elasticsearch": 1 * time.Minute,
 	}
 
-	var c = NewComponent(mockInfluxModule, mockJaegerModule, mockRedisModule, mockSentryModule, mockFlakiModule, mockElasticsearchModule, mockStorage, m)
+	var c = NewComponent(mockInfluxModule, mockJaegerModule, mockRedisModule, mockSentryModule, mockFlakiModule, mockElasticsearchModule, mockCockroachModule, mockStorage, m, nil, 0)
 
 	var (
 		influxReports        = []common.InfluxReport{{Name: "influx", Duration: time.Duration(1 * time.Second), Status: common.Deactivated}}
@@ -172,7 +182,7 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Update("influx", m["influx"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecInfluxHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"influx","duration":"1s","status":"Deactivated","error":""}]`, string(json))
 	}
 
@@ -181,7 +191,7 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Update("jaeger", m["jaeger"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecJaegerHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"jaeger","duration":"1s","status":"KO","error":"fail"}]`, string(json))
 	}
 
@@ -190,7 +200,7 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Update("redis", m["redis"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecRedisHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"redis","duration":"1s","status":"Degraded","error":"fail"}]`, string(json))
 	}
 
@@ -199,7 +209,7 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Update("sentry", m["sentry"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecSentryHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"sentry","duration":"1s","status":"KO","error":"fail"}]`, string(json))
 	}
 
@@ -208,7 +218,7 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Update("flaki", m["flaki"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecFlakiHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"flaki","duration":"1s","status":"KO","error":"fail"}]`, string(json))
 	}
 
@@ -217,7 +227,7 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Update("elasticsearch", m["elasticsearch"], gomock.Any()).Times(1)
 	{
 		var report = c.ExecElasticsearchHealthChecks(context.Background())
-		var json, _ = json.Marshal(&report)
+		var json = reportsOf(report)
 		assert.Equal(t, `[{"name":"elasticsearch","duration":"1s","status":"KO","error":"fail"}]`, string(json))
 	}
 
@@ -228,16 +238,324 @@ func TestHealthChecksFail(t *testing.T) {
 	mockStorage.EXPECT().Read("sentry").Return(makeStoredReport("sentry"), nil).Times(1)
 	mockStorage.EXPECT().Read("flaki").Return(makeStoredReport("flaki"), nil).Times(1)
 	mockStorage.EXPECT().Read("elasticsearch").Return(makeStoredReport("elasticsearch"), nil).Times(1)
+	mockStorage.EXPECT().Read("cockroach").Return(makeStoredReport("cockroach"), nil).Times(1)
 	{
 		var reply = c.AllHealthChecks(context.Background())
 		var m map[string]json.RawMessage
 		json.Unmarshal(reply, &m)
 
-		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["influx"]))
-		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["jaeger"]))
-		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["redis"]))
-		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["sentry"]))
-		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["flaki"]))
-		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(m["elasticsearch"]))
+		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(reportsOf(m["influx"])))
+		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(reportsOf(m["jaeger"])))
+		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(reportsOf(m["redis"])))
+		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(reportsOf(m["sentry"])))
+		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(reportsOf(m["flaki"])))
+		assert.Equal(t, `[{"name":"XXX","status":"OK","duration":"1s"}]`, string(reportsOf(m["elasticsearch"])))
 	}
 }
+
+func TestHealthChecksTimeout(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockRedisModule = mock.NewRedisHealthChecker(mockCtrl)
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var c = NewComponent(nil, nil, mockRedisModule, nil, nil, nil, nil, mockStorage, map[string]time.Duration{"redis": time.Minute},
+		map[string]time.Duration{"redis": 20 * time.Millisecond}, 0)
+
+	// The redis health checks hang until the end of the test.
+	var release = make(chan struct{})
+	defer close(release)
+	mockRedisModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []common.RedisReport {
+		<-release
+		return []common.RedisReport{}
+	}).Times(1)
+	mockStorage.EXPECT().Update("redis", time.Minute, gomock.Any()).Return(nil).Times(1)
+
+	var r Report
+	assert.Nil(t, json.Unmarshal(c.ExecRedisHealthChecks(context.Background()), &r))
+	assert.Equal(t, "KO", r.Status)
+
+	var reports []map[string]string
+	assert.Nil(t, json.Unmarshal(r.Reports, &reports))
+	assert.Equal(t, 1, len(reports))
+	assert.Equal(t, "redis", reports[0]["name"])
+	assert.Equal(t, "KO", reports[0]["status"])
+	assert.Equal(t, "health checks timed out: context deadline exceeded", reports[0]["error"])
+}
+
+func TestDeepHealthChecks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockInfluxModule = mock.NewInfluxHealthChecker(mockCtrl)
+	var mockJaegerModule = mock.NewJaegerHealthChecker(mockCtrl)
+	var mockRedisModule = mock.NewRedisHealthChecker(mockCtrl)
+	var mockSentryModule = mock.NewSentryHealthChecker(mockCtrl)
+	var mockFlakiModule = mock.NewFlakiHealthChecker(mockCtrl)
+	var mockElasticsearchModule = mock.NewElasticsearchHealthChecker(mockCtrl)
+	var mockCockroachModule = mock.NewCockroachHealthChecker(mockCtrl)
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var c = NewComponent(mockInfluxModule, mockJaegerModule, mockRedisModule, mockSentryModule, mockFlakiModule, mockElasticsearchModule, mockCockroachModule,
+		mockStorage, map[string]time.Duration{}, map[string]time.Duration{}, 100*time.Millisecond)
+
+	// The units are executed concurrently.
+	var delay = 40 * time.Millisecond
+	mockInfluxModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []common.InfluxReport {
+		time.Sleep(delay)
+		return []common.InfluxReport{{Name: "influx", Status: common.OK}}
+	}).Times(1)
+	mockJaegerModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []common.JaegerReport {
+		time.Sleep(delay)
+		return []common.JaegerReport{{Name: "jaeger", Status: common.OK}}
+	}).Times(1)
+	mockRedisModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []common.RedisReport {
+		time.Sleep(delay)
+		return []common.RedisReport{{Name: "redis", Status: common.OK}}
+	}).Times(1)
+	mockSentryModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []common.SentryReport {
+		time.Sleep(delay)
+		return []common.SentryReport{{Name: "sentry", Status: common.OK}}
+	}).Times(1)
+	mockFlakiModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []common.FlakiReport {
+		time.Sleep(delay)
+		return []common.FlakiReport{{Name: "flaki", Status: common.OK}}
+	}).Times(1)
+	mockCockroachModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []health.CockroachReport {
+		time.Sleep(delay)
+		return []health.CockroachReport{{Name: "cockroach", Status: health.OK}}
+	}).Times(1)
+	// The elasticsearch health checks do not finish before the deep timeout.
+	var release = make(chan struct{})
+	mockElasticsearchModule.EXPECT().HealthChecks(gomock.Any()).DoAndReturn(func(context.Context) []health.ElasticsearchReport {
+		<-release
+		return []health.ElasticsearchReport{}
+	}).Times(1)
+
+	var stored = make(chan struct{})
+	mockStorage.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(6)
+	mockStorage.EXPECT().Update("elasticsearch", gomock.Any(), gomock.Any()).DoAndReturn(func(string, time.Duration, json.RawMessage) error {
+		close(stored)
+		return nil
+	}).Times(1)
+
+	var now = time.Now()
+	var reply = c.DeepHealthChecks(context.Background())
+	var duration = time.Since(now)
+	close(release)
+	<-stored
+
+	assert.True(t, duration >= 100*time.Millisecond)
+	assert.True(t, duration < 7*delay)
+
+	var m map[string]Report
+	assert.Nil(t, json.Unmarshal(reply, &m))
+	assert.Equal(t, 7, len(m))
+	for _, unit := range []string{"influx", "jaeger", "redis", "sentry", "flaki", "cockroach"} {
+		assert.Equal(t, "OK", m[unit].Status)
+	}
+	assert.Equal(t, "KO", m["elasticsearch"].Status)
+}
+
+func TestCockroachHealthChecks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCockroachModule = mock.NewCockroachHealthChecker(mockCtrl)
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var c = NewComponent(nil, nil, nil, nil, nil, nil, mockCockroachModule, mockStorage, map[string]time.Duration{"cockroach": time.Minute}, nil, 0)
+
+	var cockroachReports = []health.CockroachReport{{Name: "Ping", Duration: time.Second, Status: health.OK}}
+
+	// Exec.
+	mockCockroachModule.EXPECT().HealthChecks(context.Background()).Return(cockroachReports).Times(1)
+	mockStorage.EXPECT().Update("cockroach", time.Minute, gomock.Any()).Return(nil).Times(1)
+	{
+		var report = c.ExecCockroachHealthChecks(context.Background())
+		assert.Equal(t, `[{"name":"Ping","duration":"1s","status":"OK","error":""}]`, string(reportsOf(report)))
+	}
+
+	// Read from the storage.
+	mockStorage.EXPECT().Read("cockroach").Return(StoredReport{
+		ComponentID:     "000-000-000-00",
+		HealthcheckUnit: "cockroach",
+		Reports:         json.RawMessage(`[{"name":"Ping","status":"OK"}]`),
+		LastUpdated:     time.Now(),
+		ValidUntil:      time.Now().Add(time.Minute),
+	}, nil).Times(1)
+	{
+		var report = c.ReadCockroachHealthChecks(context.Background())
+		assert.Equal(t, `[{"name":"Ping","status":"OK"}]`, string(reportsOf(report)))
+	}
+
+	// The storage is down, the health checks are executed.
+	mockStorage.EXPECT().Read("cockroach").Return(StoredReport{}, fmt.Errorf("connection refused")).Times(1)
+	mockCockroachModule.EXPECT().HealthChecks(context.Background()).Return([]health.CockroachReport{{Name: "Ping", Duration: time.Second, Status: health.KO, Error: fmt.Errorf("connection refused")}}).Times(1)
+	mockStorage.EXPECT().Update("cockroach", time.Minute, gomock.Any()).Return(fmt.Errorf("connection refused")).Times(1)
+	{
+		var r Report
+		assert.Nil(t, json.Unmarshal(c.ReadCockroachHealthChecks(context.Background()), &r))
+		assert.Equal(t, "KO", r.Status)
+		assert.Equal(t, `[{"name":"Ping","duration":"1s","status":"KO","error":"connection refused"}]`, string(r.Reports))
+	}
+}
+
+func TestReadHealthChecks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var c = NewComponent(nil, nil, nil, nil, nil, nil, nil, mockStorage, map[string]time.Duration{"redis": time.Minute}, nil, 0)
+
+	// Valid reports.
+	var lastUpdated = time.Now().UTC().Add(-time.Minute)
+	var validUntil = time.Now().UTC().Add(time.Minute)
+	mockStorage.EXPECT().Read("redis").Return(StoredReport{
+		ComponentID:     "000-000-000-00",
+		HealthcheckUnit: "redis",
+		Reports:         json.RawMessage(`[{"name":"ping","status":"OK"},{"name":"info","status":"Degraded"}]`),
+		LastUpdated:     lastUpdated,
+		ValidUntil:      validUntil,
+	}, nil).Times(1)
+	{
+		var r Report
+		assert.Nil(t, json.Unmarshal(c.ReadRedisHealthChecks(context.Background()), &r))
+		assert.Equal(t, ReportVersion, r.Version)
+		assert.Equal(t, "redis", r.Unit)
+		assert.Equal(t, "Degraded", r.Status)
+		assert.True(t, lastUpdated.Equal(*r.LastUpdated))
+		assert.True(t, validUntil.Equal(*r.ValidUntil))
+		assert.False(t, r.Stale)
+		assert.Zero(t, r.Error)
+	}
+
+	// Stale reports.
+	mockStorage.EXPECT().Read("redis").Return(StoredReport{
+		ComponentID:     "000-000-000-00",
+		HealthcheckUnit: "redis",
+		Reports:         json.RawMessage(`[{"name":"ping","status":"OK"}]`),
+		LastUpdated:     lastUpdated.Add(-time.Hour),
+		ValidUntil:      validUntil.Add(-time.Hour),
+	}, nil).Times(1)
+	{
+		var r Report
+		assert.Nil(t, json.Unmarshal(c.ReadRedisHealthChecks(context.Background()), &r))
+		assert.Equal(t, "Unknown", r.Status)
+		assert.True(t, r.Stale)
+		assert.NotZero(t, r.Error)
+		assert.Equal(t, `[{"name":"ping","status":"OK"}]`, string(r.Reports))
+	}
+
+	// No reports.
+	mockStorage.EXPECT().Read("redis").Return(StoredReport{}, nil).Times(1)
+	{
+		var r Report
+		assert.Nil(t, json.Unmarshal(c.ReadRedisHealthChecks(context.Background()), &r))
+		assert.Equal(t, "Unknown", r.Status)
+		assert.Equal(t, "no reports stored in DB", r.Error)
+		assert.Equal(t, `[]`, string(r.Reports))
+	}
+
+	// Storage error with quotes is safely encoded.
+	mockStorage.EXPECT().Read("redis").Return(StoredReport{}, fmt.Errorf(`relation "health" does not exist`)).Times(1)
+	{
+		var r Report
+		assert.Nil(t, json.Unmarshal(c.ReadRedisHealthChecks(context.Background()), &r))
+		assert.Equal(t, "Unknown", r.Status)
+		assert.Equal(t, `could not read reports from DB: relation "health" does not exist`, r.Error)
+	}
+}
+
+func TestFleetHealthChecks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var c = NewComponent(nil, nil, nil, nil, nil, nil, nil, mockStorage, map[string]time.Duration{}, nil, 0)
+
+	var now = time.Now().UTC()
+	var makeStoredReport = func(id, unit, status string, validUntil time.Time) StoredReport {
+		return StoredReport{
+			ComponentID:     id,
+			ComponentName:   "elasticsearch-bridge",
+			HealthcheckUnit: unit,
+			Reports:         json.RawMessage(fmt.Sprintf(`[{"name":"XXX", "status":"%s", "duration":"1s"}]`, status)),
+			LastUpdated:     validUntil.Add(-time.Minute),
+			ValidUntil:      validUntil,
+		}
+	}
+
+	var storedReports = []StoredReport{
+		// Live and healthy instance.
+		makeStoredReport("1", "elasticsearch", "OK", now.Add(time.Minute)),
+		makeStoredReport("1", "redis", "Deactivated", now.Add(time.Minute)),
+		// Live instance with a stale unit.
+		makeStoredReport("2", "elasticsearch", "OK", now.Add(time.Minute)),
+		makeStoredReport("2", "redis", "OK", now.Add(-time.Minute)),
+		// Gone instance.
+		makeStoredReport("3", "elasticsearch", "KO", now.Add(-time.Hour)),
+	}
+
+	// Fleet degraded.
+	{
+		mockStorage.EXPECT().ReadAll().Return(storedReports, nil).Times(1)
+		var fleet FleetReport
+		assert.Nil(t, json.Unmarshal(c.FleetHealthChecks(context.Background()), &fleet))
+
+		assert.Equal(t, "Degraded", fleet.Status)
+		assert.Equal(t, 3, len(fleet.Instances))
+
+		assert.Equal(t, "1", fleet.Instances[0].ComponentID)
+		assert.Equal(t, "OK", fleet.Instances[0].Status)
+		assert.False(t, fleet.Instances[0].Gone)
+		assert.Equal(t, "Deactivated", fleet.Instances[0].Units["redis"].Status)
+
+		assert.Equal(t, "Unknown", fleet.Instances[1].Status)
+		assert.False(t, fleet.Instances[1].Gone)
+		assert.True(t, fleet.Instances[1].Units["redis"].Stale)
+		assert.NotZero(t, fleet.Instances[1].Units["redis"].Staleness)
+		assert.False(t, fleet.Instances[1].Units["elasticsearch"].Stale)
+		assert.Equal(t, now.Add(time.Minute).Add(-time.Minute).Unix(), fleet.Instances[1].LastUpdated.Unix())
+
+		assert.Equal(t, "3", fleet.Instances[2].ComponentID)
+		assert.True(t, fleet.Instances[2].Gone)
+		assert.Equal(t, "KO", fleet.Instances[2].Units["elasticsearch"].Status)
+	}
+
+	// Fleet OK, the gone instance is ignored.
+	{
+		mockStorage.EXPECT().ReadAll().Return([]StoredReport{storedReports[0], storedReports[1], storedReports[4]}, nil).Times(1)
+		var fleet FleetReport
+		assert.Nil(t, json.Unmarshal(c.FleetHealthChecks(context.Background()), &fleet))
+		assert.Equal(t, "OK", fleet.Status)
+	}
+
+	// Fleet KO.
+	{
+		mockStorage.EXPECT().ReadAll().Return([]StoredReport{storedReports[4]}, nil).Times(1)
+		var fleet FleetReport
+		assert.Nil(t, json.Unmarshal(c.FleetHealthChecks(context.Background()), &fleet))
+		assert.Equal(t, "KO", fleet.Status)
+		assert.Equal(t, 1, len(fleet.Instances))
+
+		mockStorage.EXPECT().ReadAll().Return([]StoredReport{}, nil).Times(1)
+		assert.Nil(t, json.Unmarshal(c.FleetHealthChecks(context.Background()), &fleet))
+		assert.Equal(t, "KO", fleet.Status)
+		assert.Equal(t, 0, len(fleet.Instances))
+	}
+
+	// Storage failure.
+	{
+		mockStorage.EXPECT().ReadAll().Return(nil, fmt.Errorf("fail")).Times(1)
+		var fleet FleetReport
+		assert.Nil(t, json.Unmarshal(c.FleetHealthChecks(context.Background()), &fleet))
+		assert.Equal(t, "Unknown", fleet.Status)
+		assert.NotZero(t, fleet.Error)
+	}
+}
+
+// reportsOf returns the reports of the report envelope.
+func reportsOf(jsonReport json.RawMessage) json.RawMessage {
+	var r Report
+	json.Unmarshal(jsonReport, &r)
+	return r.Reports
+}
@@ -3,32 +3,78 @@ package health
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	client "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
+const (
+	highWatermarkSetting       = "cluster.routing.allocation.disk.watermark.high"
+	floodStageWatermarkSetting = "cluster.routing.allocation.disk.watermark.flood_stage"
+	maxShardsPerNodeSetting    = "cluster.max_shards_per_node"
+	readOnlyAllowDeleteSetting = "index.blocks.read_only_allow_delete"
+
+	searchVisibilityPollInterval = 100 * time.Millisecond
+)
+
 // ElasticsearchModule is the health check module for Elasticsearch.
 type ElasticsearchModule struct {
 	elasticsearchClient ElasticsearchClient
+	healthIndex         string
+	thresholds          ElasticsearchThresholds
 }
 
 // ElasticsearchClient is the interface of Elasticsearch.
 type ElasticsearchClient interface {
-	ListIndexes() ([]client.IndexRepresentation, error)
-	GetIndex(indexName string) (client.IndexSettingsRepresentation, error)
-	CreateIndex(indexName string) error
-	DeleteIndex(indexName string) error
-	Health() (client.HealthRepresentation, error)
+	GetIndex(ctx context.Context, indexName string) (client.IndexSettingsRepresentation, error)
+	CreateIndex(ctx context.Context, indexName string) error
+	Health(ctx context.Context) (client.HealthRepresentation, error)
+	NodesStats(ctx context.Context) (client.NodesStatsRepresentation, error)
+	ClusterSettings(ctx context.Context) (client.ClusterSettingsRepresentation, error)
+	Allocation(ctx context.Context) ([]client.AllocationRepresentation, error)
+	GetIndexesSetting(ctx context.Context, setting string) (map[string]client.IndexSettingRepresentation, error)
+	IndexDocument(ctx context.Context, indexName, id string, doc interface{}) error
+	GetDocument(ctx context.Context, indexName, id string) (client.DocumentRepresentation, error)
+	DeleteDocument(ctx context.Context, indexName, id string) error
+	Search(ctx context.Context, indexName string, query interface{}) (client.SearchRepresentation, error)
+	Refresh(ctx context.Context, indexName string) error
 }
 
-// NewElasticsearchModule returns the Elasticsearch health module.
-func NewElasticsearchModule(client ElasticsearchClient) *ElasticsearchModule {
+// ElasticsearchThresholds are the thresholds of the Elasticsearch health checks.
+// The disk usage is checked against the cluster disk watermarks.
+type ElasticsearchThresholds struct {
+	// Heap usage in percent above which a node is degraded, respectively KO.
+	JVMHeapDegraded int
+	JVMHeapKO       int
+	// Number of pending tasks and age of the oldest pending task above which the cluster is degraded.
+	PendingTasks        int
+	PendingTasksMaxWait time.Duration
+	// Ratio of cluster.max_shards_per_node above which a node is degraded.
+	ShardsPerNodeRatio float64
+	// Expected number of data nodes, 0 disables the check.
+	ExpectedDataNodes int
+	// Delay after which a probe document not yet visible to search is refreshed explicitly.
+	SearchVisibilityTimeout time.Duration
+	// Age above which a probe index is stale.
+	ProbeIndexMaxAge time.Duration
+	// Deadline of each health check, 0 disables it.
+	CheckTimeout time.Duration
+}
+
+// NewElasticsearchModule returns the Elasticsearch health module. The probe documents
+// of the round-trip check are written in the long-lived index 'healthIndex'.
+func NewElasticsearchModule(client ElasticsearchClient, healthIndex string, thresholds ElasticsearchThresholds) *ElasticsearchModule {
 	return &ElasticsearchModule{
 		elasticsearchClient: client,
+		healthIndex:         healthIndex,
+		thresholds:          thresholds,
 	}
 }
 
@@ -57,20 +103,106 @@ func (i *ElasticsearchReport) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// HealthChecks executes all health checks for Flaki.
-func (m *ElasticsearchModule) HealthChecks(context.Context) []ElasticsearchReport {
+// elasticsearchSnapshot is the state of the cluster read once per run and shared by the
+// checks, so they see a consistent state.
+type elasticsearchSnapshot struct {
+	health         client.HealthRepresentation
+	healthDuration time.Duration
+	healthErr      error
+	stats          client.NodesStatsRepresentation
+	statsErr       error
+	settings       client.ClusterSettingsRepresentation
+	settingsErr    error
+}
+
+// snapshot calls the cluster health, nodes stats and cluster settings APIs, each under the
+// check timeout.
+func (m *ElasticsearchModule) snapshot(ctx context.Context) *elasticsearchSnapshot {
+	var s = &elasticsearchSnapshot{}
+
+	m.withCheckTimeout(ctx, func(ctx context.Context) {
+		var now = time.Now()
+		s.health, s.healthErr = m.elasticsearchClient.Health(ctx)
+		s.healthDuration = time.Since(now)
+	})
+	m.withCheckTimeout(ctx, func(ctx context.Context) {
+		s.stats, s.statsErr = m.elasticsearchClient.NodesStats(ctx)
+	})
+	m.withCheckTimeout(ctx, func(ctx context.Context) {
+		s.settings, s.settingsErr = m.elasticsearchClient.ClusterSettings(ctx)
+	})
+	return s
+}
+
+func (m *ElasticsearchModule) withCheckTimeout(ctx context.Context, f func(context.Context)) {
+	if m.thresholds.CheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.thresholds.CheckTimeout)
+		defer cancel()
+	}
+	f(ctx)
+}
+
+// HealthChecks executes all health checks for Elasticsearch. The cluster state is read
+// once and shared by the checks. Each check runs under a deadline derived from the context
+// and the check timeout.
+func (m *ElasticsearchModule) HealthChecks(ctx context.Context) []ElasticsearchReport {
+	var snapshot = m.snapshot(ctx)
+
+	var checks = []struct {
+		name  string
+		check func(context.Context, *elasticsearchSnapshot) ElasticsearchReport
+	}{
+		{"Health", m.elasticsearchHealthCheck},
+		{"Disk watermarks", m.elasticsearchDiskCheck},
+		{"JVM heap", m.elasticsearchJVMHeapCheck},
+		{"Pending tasks", m.elasticsearchPendingTasksCheck},
+		{"Shards per node", m.elasticsearchShardsPerNodeCheck},
+		{"Data nodes", m.elasticsearchDataNodesCheck},
+		{"Read-only indexes", m.elasticsearchReadOnlyIndexesCheck},
+		{"Document API", m.elasticsearchDocumentCheck},
+		{"Probe indexes", m.elasticsearchProbeIndexesCheck},
+	}
+
 	var reports = []ElasticsearchReport{}
-	reports = append(reports, m.elasticsearchHealthCheck())
-	reports = append(reports, m.elasticsearchIndexCheck())
+	for _, c := range checks {
+		reports = append(reports, m.execCheck(ctx, c.name, snapshot, c.check))
+	}
 	return reports
 }
 
-func (m *ElasticsearchModule) elasticsearchHealthCheck() ElasticsearchReport {
-	var healthCheckName = "Health"
+// execCheck executes the health check. If the deadline passes before the check returns,
+// the check is reported KO with a timeout error and its result is discarded.
+func (m *ElasticsearchModule) execCheck(ctx context.Context, name string, snapshot *elasticsearchSnapshot, check func(context.Context, *elasticsearchSnapshot) ElasticsearchReport) ElasticsearchReport {
+	if m.thresholds.CheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.thresholds.CheckTimeout)
+		defer cancel()
+	}
 
 	var now = time.Now()
-	var health, err = m.elasticsearchClient.Health()
-	var duration = time.Since(now)
+	var reportc = make(chan ElasticsearchReport, 1)
+	go func() {
+		reportc <- check(ctx, snapshot)
+	}()
+
+	select {
+	case report := <-reportc:
+		return report
+	case <-ctx.Done():
+		return ElasticsearchReport{
+			Name:     name,
+			Duration: time.Since(now),
+			Status:   KO,
+			Error:    errors.Wrap(ctx.Err(), "health check timed out"),
+		}
+	}
+}
+
+func (m *ElasticsearchModule) elasticsearchHealthCheck(ctx context.Context, snapshot *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Health"
+
+	var health, err, duration = snapshot.health, snapshot.healthErr, snapshot.healthDuration
 
 	var hcErr error
 	var s Status
@@ -102,63 +234,460 @@ func (m *ElasticsearchModule) elasticsearchHealthCheck() ElasticsearchReport {
 	}
 }
 
-func (m *ElasticsearchModule) elasticsearchIndexCheck() ElasticsearchReport {
-	var healthCheckName = "Index API"
+func (m *ElasticsearchModule) elasticsearchDiskCheck(ctx context.Context, snapshot *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Disk watermarks"
+	var now = time.Now()
 
-	var UUID string
-	{
-		var UUIDgen, _ = uuid.NewUUID()
-		UUID = UUIDgen.String()
+	var settings, err = snapshot.settings, snapshot.settingsErr
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get cluster settings"), nil)
 	}
 
-	// query flaki next valid ID
-	var now = time.Now()
+	var watermarks = map[Status]string{
+		Degraded: settings.Setting(highWatermarkSetting),
+		KO:       settings.Setting(floodStageWatermarkSetting),
+	}
 
-	var _, err = m.elasticsearchClient.ListIndexes()
+	var stats = snapshot.stats
+	if err = snapshot.statsErr; err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get nodes stats"), nil)
+	}
 
-	if err != nil {
-		var duration = time.Since(now)
-		var hcErr = errors.Wrap(err, "could not query elsaticsearch")
-		return ElasticsearchReport{
-			Name:     healthCheckName,
-			Duration: duration,
-			Status:   KO,
-			Error:    hcErr,
+	type nodeDisk struct {
+		UsedPercent float64 `json:"used_percent"`
+		Available   int64   `json:"available_in_bytes"`
+		Status      string  `json:"status"`
+	}
+
+	var s = OK
+	var infos = map[string]nodeDisk{}
+	for _, node := range stats.Nodes {
+		if !isDataNode(node.Roles) || node.FS.Total.TotalInBytes == 0 {
+			continue
 		}
+
+		var total, available = node.FS.Total.TotalInBytes, node.FS.Total.AvailableInBytes
+		var ns = OK
+		for _, ws := range []Status{Degraded, KO} {
+			var exceeded, err = watermarkExceeded(watermarks[ws], total, available)
+			if err != nil {
+				return makeElasticsearchReport(healthCheckName, now, Unknown, errors.Wrap(err, "could not parse disk watermark"), nil)
+			}
+			if exceeded {
+				ns = ws
+			}
+		}
+
+		s = worst(s, ns)
+		infos[node.Name] = nodeDisk{
+			UsedPercent: 100 * float64(total-available) / float64(total),
+			Available:   available,
+			Status:      ns.String(),
+		}
+	}
+
+	var hcErr error
+	if s != OK {
+		hcErr = fmt.Errorf("disk usage above the high or flood stage watermark on some nodes")
 	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
 
-	err = m.elasticsearchClient.CreateIndex(UUID)
+func (m *ElasticsearchModule) elasticsearchJVMHeapCheck(ctx context.Context, snapshot *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "JVM heap"
+	var now = time.Now()
 
+	var stats, err = snapshot.stats, snapshot.statsErr
 	if err != nil {
-		var duration = time.Since(now)
-		var hcErr = errors.Wrap(err, "could not add index in elsaticsearch")
-		return ElasticsearchReport{
-			Name:     healthCheckName,
-			Duration: duration,
-			Status:   KO,
-			Error:    hcErr,
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get nodes stats"), nil)
+	}
+
+	var s = OK
+	var infos = map[string]int{}
+	for _, node := range stats.Nodes {
+		var heap = node.JVM.Mem.HeapUsedPercent
+		infos[node.Name] = heap
+
+		switch {
+		case m.thresholds.JVMHeapKO > 0 && heap >= m.thresholds.JVMHeapKO:
+			s = worst(s, KO)
+		case m.thresholds.JVMHeapDegraded > 0 && heap >= m.thresholds.JVMHeapDegraded:
+			s = worst(s, Degraded)
 		}
 	}
 
-	err = m.elasticsearchClient.DeleteIndex(UUID)
-	var duration = time.Since(now)
+	var hcErr error
+	if s != OK {
+		hcErr = fmt.Errorf("JVM heap pressure on some nodes")
+	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
+
+func (m *ElasticsearchModule) elasticsearchPendingTasksCheck(ctx context.Context, snapshot *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Pending tasks"
+	var now = time.Now()
+
+	var health, err = snapshot.health, snapshot.healthErr
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not check health of cluster"), nil)
+	}
+
+	var maxWait = time.Duration(health.TaskMaxWaitingInQueueMillis) * time.Millisecond
+	var infos = map[string]interface{}{
+		"pending_tasks":     health.NumberOfPendingTasks,
+		"task_max_wait":     maxWait.String(),
+		"in_flight_fetches": health.NumberOfInFlightFetch,
+	}
 
 	var hcErr error
-	var s Status
+	var s = OK
 	switch {
+	case m.thresholds.PendingTasks > 0 && health.NumberOfPendingTasks > m.thresholds.PendingTasks:
+		hcErr = fmt.Errorf("%d pending tasks, more than %d", health.NumberOfPendingTasks, m.thresholds.PendingTasks)
+		s = Degraded
+	case m.thresholds.PendingTasksMaxWait > 0 && maxWait > m.thresholds.PendingTasksMaxWait:
+		hcErr = fmt.Errorf("oldest pending task waiting for %s, more than %s", maxWait, m.thresholds.PendingTasksMaxWait)
+		s = Degraded
+	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
+
+func (m *ElasticsearchModule) elasticsearchShardsPerNodeCheck(ctx context.Context, snapshot *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Shards per node"
+	var now = time.Now()
+
+	var settings, err = snapshot.settings, snapshot.settingsErr
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get cluster settings"), nil)
+	}
+
+	maxShards, err := strconv.Atoi(settings.Setting(maxShardsPerNodeSetting))
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, Unknown, errors.Wrapf(err, "could not parse %s", maxShardsPerNodeSetting), nil)
+	}
+
+	allocation, err := m.elasticsearchClient.Allocation(ctx)
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get shards allocation"), nil)
+	}
+
+	var s = OK
+	var infos = map[string]int{}
+	for _, a := range allocation {
+		var shards, err = strconv.Atoi(a.Shards)
+		// The unassigned shards are not allocated to a node.
+		if err != nil || a.Node == "UNASSIGNED" {
+			continue
+		}
+		infos[a.Node] = shards
+
+		switch {
+		case shards >= maxShards:
+			s = worst(s, KO)
+		case m.thresholds.ShardsPerNodeRatio > 0 && float64(shards) >= m.thresholds.ShardsPerNodeRatio*float64(maxShards):
+			s = worst(s, Degraded)
+		}
+	}
+
+	var hcErr error
+	if s != OK {
+		hcErr = fmt.Errorf("number of shards close to the limit of %d shards per node on some nodes", maxShards)
+	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
+
+func (m *ElasticsearchModule) elasticsearchDataNodesCheck(ctx context.Context, snapshot *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Data nodes"
+	var now = time.Now()
+
+	if m.thresholds.ExpectedDataNodes <= 0 {
+		return makeElasticsearchReport(healthCheckName, now, Deactivated, nil, nil)
+	}
+
+	var health, err = snapshot.health, snapshot.healthErr
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not check health of cluster"), nil)
+	}
+
+	var infos = map[string]int{
+		"data_nodes":          health.NumberOfDataNodes,
+		"expected_data_nodes": m.thresholds.ExpectedDataNodes,
+	}
+
+	var hcErr error
+	var s = OK
+	if health.NumberOfDataNodes < m.thresholds.ExpectedDataNodes {
+		hcErr = fmt.Errorf("%d data nodes, %d expected", health.NumberOfDataNodes, m.thresholds.ExpectedDataNodes)
+		s = KO
+	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
+
+func (m *ElasticsearchModule) elasticsearchReadOnlyIndexesCheck(ctx context.Context, _ *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Read-only indexes"
+	var now = time.Now()
+
+	var settings, err = m.elasticsearchClient.GetIndexesSetting(ctx, readOnlyAllowDeleteSetting)
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get indexes settings"), nil)
+	}
+
+	var indexes = []string{}
+	for index, setting := range settings {
+		if setting.Settings[readOnlyAllowDeleteSetting] == "true" {
+			indexes = append(indexes, index)
+		}
+	}
+
+	var hcErr error
+	var s = OK
+	if len(indexes) > 0 {
+		hcErr = fmt.Errorf("%d indexes with the %s block", len(indexes), readOnlyAllowDeleteSetting)
+		s = KO
+	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, indexes)
+}
+
+// elasticsearchDocumentCheck writes a probe document in the health index, reads it by ID,
+// searches it with a term query and deletes it. The indexing latency and the search
+// visibility latency are reported separately.
+func (m *ElasticsearchModule) elasticsearchDocumentCheck(ctx context.Context, _ *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Document API"
+	var now = time.Now()
+
+	// The health index is created on first use and never deleted. Only a missing index is
+	// created, the other errors are reported as such.
+	switch _, err := m.elasticsearchClient.GetIndex(ctx, m.healthIndex); {
+	case client.IsNotFound(err):
+		if err = m.elasticsearchClient.CreateIndex(ctx, m.healthIndex); err != nil {
+			return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not create health index"), nil)
+		}
 	case err != nil:
-		hcErr = errors.Wrap(err, "could not query flaki service")
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get health index"), nil)
+	}
+
+	// The probe is a single token for the standard analyzer, so the term query matches it.
+	var probe = strings.Replace(uuid.New().String(), "-", "", -1)
+	var doc = map[string]interface{}{
+		"probe":     probe,
+		"timestamp": time.Now().UTC(),
+	}
+
+	var indexBegin = time.Now()
+	var err = m.elasticsearchClient.IndexDocument(ctx, m.healthIndex, probe, doc)
+	var indexingLatency = time.Since(indexBegin)
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not index probe document"), nil)
+	}
+
+	var infos = map[string]interface{}{
+		"index":            m.healthIndex,
+		"indexing_latency": indexingLatency.String(),
+	}
+
+	var hcErr error
+	var s = OK
+	var found bool
+	{
+		var d client.DocumentRepresentation
+		d, err = m.elasticsearchClient.GetDocument(ctx, m.healthIndex, probe)
+		switch {
+		case err != nil:
+			hcErr = errors.Wrap(err, "could not get probe document")
+			s = KO
+		case !d.Found:
+			hcErr = fmt.Errorf("probe document %s not found by ID", probe)
+			s = KO
+		}
+	}
+
+	if s == OK {
+		var refreshed bool
+		found, refreshed, err = m.waitSearchVisibility(ctx, probe, indexBegin)
+		switch {
+		case err != nil:
+			hcErr = errors.Wrap(err, "could not search probe document")
+			s = KO
+		case !found:
+			hcErr = fmt.Errorf("probe document %s not visible to search", probe)
+			s = KO
+		case refreshed:
+			hcErr = fmt.Errorf("probe document %s visible to search only after an explicit refresh", probe)
+			s = Degraded
+		}
+		if found {
+			infos["search_visibility_latency"] = time.Since(indexBegin).String()
+		}
+		infos["forced_refresh"] = refreshed
+	}
+
+	if err = m.elasticsearchClient.DeleteDocument(ctx, m.healthIndex, probe); err != nil && hcErr == nil {
+		hcErr = errors.Wrap(err, "could not delete probe document")
 		s = KO
-	default:
-		s = OK
+	}
+
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
+
+// waitSearchVisibility polls the search API until the probe document is visible. If it is
+// still not visible after the search visibility timeout, the health index is refreshed
+// explicitly.
+func (m *ElasticsearchModule) waitSearchVisibility(ctx context.Context, probe string, indexBegin time.Time) (found bool, refreshed bool, err error) {
+	var deadline = indexBegin.Add(m.thresholds.SearchVisibilityTimeout)
+	for {
+		found, err = m.searchProbe(ctx, probe)
+		if err != nil || found {
+			return found, false, err
+		}
+		if time.Now().Add(searchVisibilityPollInterval).After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		case <-time.After(searchVisibilityPollInterval):
+		}
+	}
+
+	if err = m.elasticsearchClient.Refresh(ctx, m.healthIndex); err != nil {
+		return false, true, err
+	}
+	found, err = m.searchProbe(ctx, probe)
+	return found, true, err
+}
+
+// searchProbe returns true if the term query on the probe returns the probe document.
+func (m *ElasticsearchModule) searchProbe(ctx context.Context, probe string) (bool, error) {
+	var query = map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"probe": probe},
+		},
+	}
+
+	var resp, err = m.elasticsearchClient.Search(ctx, m.healthIndex, query)
+	if err != nil {
+		return false, err
+	}
+
+	for _, hit := range resp.Hits.Hits {
+		if hit.ID == probe {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// elasticsearchProbeIndexesCheck counts the stale probe indexes left in the cluster by
+// the former Index API health check. They are deleted by the probe indexes cleaning job.
+func (m *ElasticsearchModule) elasticsearchProbeIndexesCheck(ctx context.Context, _ *elasticsearchSnapshot) ElasticsearchReport {
+	var healthCheckName = "Probe indexes"
+	var now = time.Now()
+
+	var settings, err = m.elasticsearchClient.GetIndexesSetting(ctx, ProbeIndexCreationDateSetting)
+	if err != nil {
+		return makeElasticsearchReport(healthCheckName, now, KO, errors.Wrap(err, "could not get indexes creation date"), nil)
+	}
+
+	var stale = StaleProbeIndexes(settings, now.Add(-m.thresholds.ProbeIndexMaxAge))
+	var infos = map[string]int{
+		"probe_indexes":       len(ProbeIndexes(settings)),
+		"stale_probe_indexes": len(stale),
+	}
+
+	var hcErr error
+	var s = OK
+	if len(stale) > 0 {
+		hcErr = fmt.Errorf("%d stale probe indexes", len(stale))
+		s = Degraded
+	}
+	return makeElasticsearchReport(healthCheckName, now, s, hcErr, infos)
+}
+
+// makeElasticsearchReport returns the report of the health check started at 'begin'.
+func makeElasticsearchReport(name string, begin time.Time, s Status, err error, infos interface{}) ElasticsearchReport {
+	var jsonInfos json.RawMessage
+	if infos != nil {
+		jsonInfos, _ = json.Marshal(infos)
 	}
 
 	return ElasticsearchReport{
-		Name:     healthCheckName,
-		Duration: duration,
+		Name:     name,
+		Duration: time.Since(begin),
 		Status:   s,
-		Error:    hcErr,
+		Error:    err,
+		Infos:    jsonInfos,
+	}
+}
+
+// worst returns the most severe status.
+func worst(s1, s2 Status) Status {
+	if statusSeverity[s2] > statusSeverity[s1] {
+		return s2
+	}
+	return s1
+}
+
+// isDataNode returns true if the node holds data. Nodes without roles are
+// considered as data nodes.
+func isDataNode(roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if strings.HasPrefix(r, "data") {
+			return true
+		}
+	}
+	return false
+}
+
+// watermarkExceeded returns true if the disk usage exceeds the watermark. The
+// watermark is either a percentage or ratio of used disk, e.g. "85%" or "0.85",
+// or an amount of free space, e.g. "500mb".
+func watermarkExceeded(watermark string, total, available int64) (bool, error) {
+	var w = strings.ToLower(strings.TrimSpace(watermark))
+
+	switch {
+	case w == "":
+		return false, nil
+	case strings.HasSuffix(w, "%"):
+		var percent, err = strconv.ParseFloat(strings.TrimSuffix(w, "%"), 64)
+		if err != nil {
+			return false, err
+		}
+		return 100*float64(total-available) >= percent*float64(total), nil
+	}
+
+	if ratio, err := strconv.ParseFloat(w, 64); err == nil {
+		return float64(total-available) >= ratio*float64(total), nil
+	}
+
+	var free, err = parseByteSize(w)
+	if err != nil {
+		return false, err
+	}
+	return available <= free, nil
+}
+
+// parseByteSize parses Elasticsearch byte sizes, e.g. "500mb".
+func parseByteSize(s string) (int64, error) {
+	var units = []struct {
+		suffix string
+		factor int64
+	}{
+		{"pb", 1 << 50}, {"tb", 1 << 40}, {"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10}, {"b", 1},
+	}
+
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			var v, err = strconv.ParseFloat(strings.TrimSuffix(s, u.suffix), 64)
+			if err != nil {
+				return 0, err
+			}
+			return int64(v * float64(u.factor)), nil
+		}
 	}
+	return 0, fmt.Errorf("unknown byte size unit in '%s'", s)
 }
 
 // IElasticsearchHealthChecker is the interface of the elasticsearch health check module.
@@ -185,7 +714,7 @@ func MakeElasticsearchModuleLoggingMW(logger log.Logger) func(IElasticsearchHeal
 // elasticsearchModuleLoggingMW implements Module.
 func (m *elasticsearchModuleLoggingMW) HealthChecks(ctx context.Context) []ElasticsearchReport {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "HealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "HealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.HealthChecks(ctx)
@@ -8,15 +8,18 @@ import (
 	"fmt"
 	"math/rand"
 	"strconv"
+	"sync/atomic"
 	// "math/rand"
 	// "strconv"
 	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	internal "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
 	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
-	mock "github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/go-kit/kit/log/level"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,203 +29,508 @@ func TestElasticsearchHealthChecks(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	var m = NewElasticsearchModule(mockElasticsearchClient)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{})
+	expectClusterChecks(mockElasticsearchClient)
+	expectDocumentCheck(mockElasticsearchClient)
 
-	var indexWrongFormat = internal.IndexRepresentation{
-		Index: "wrong-format",
-	}
-	var indexOld = internal.IndexRepresentation{
-		Index: "int-elastic-1800.10.02",
-	}
-	var indexNew = internal.IndexRepresentation{
-		Index: "int-elastic-2018.10.01",
-	}
-
-	var allIndexes = []internal.IndexRepresentation{indexWrongFormat, indexOld, indexNew}
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(allIndexes, nil).Times(1)
-	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().DeleteIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
 
 	var reports = m.HealthChecks(context.Background())
-	assert.Equal(t, 2, len(reports))
+	assert.Equal(t, 9, len(reports))
 	assert.Equal(t, "Health", reports[0].Name)
 	assert.NotZero(t, reports[0].Duration)
 	assert.Equal(t, OK, reports[0].Status)
 	assert.Zero(t, reports[0].Error)
-	assert.Equal(t, "Index API", reports[1].Name)
-	assert.NotZero(t, reports[1].Duration)
-	assert.Equal(t, OK, reports[1].Status)
-	assert.Zero(t, reports[1].Error)
+	assert.Equal(t, "Document API", reports[7].Name)
+	assert.NotZero(t, reports[7].Duration)
+	assert.Equal(t, OK, reports[7].Status)
+	assert.Zero(t, reports[7].Error)
+	for _, r := range reports {
+		assert.NotEqual(t, "Index API", r.Name)
+	}
 }
 
-func TestElasticsearchHealthChecksFailureErrorDeletion(t *testing.T) {
+func TestElasticsearchHealthChecksFailure(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	var m = NewElasticsearchModule(mockElasticsearchClient)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{})
+	expectClusterChecks(mockElasticsearchClient)
+	expectDocumentCheck(mockElasticsearchClient)
 
-	var indexWrongFormat = internal.IndexRepresentation{
-		Index: "wrong-format",
-	}
-	var indexOld = internal.IndexRepresentation{
-		Index: "int-elastic-1800.10.02",
-	}
-	var indexNew = internal.IndexRepresentation{
-		Index: "int-elastic-2018.10.01",
-	}
-
-	var allIndexes = []internal.IndexRepresentation{indexWrongFormat, indexOld, indexNew}
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(allIndexes, nil).Times(1)
-	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().DeleteIndex(gomock.Any()).Return(fmt.Errorf("Fail to delete")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "yellow"}, nil).Times(1)
 
 	var reports = m.HealthChecks(context.Background())
-	assert.Equal(t, 2, len(reports))
 	assert.Equal(t, "Health", reports[0].Name)
 	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, OK, reports[0].Status)
+	assert.Equal(t, Degraded, reports[0].Status)
 	assert.Zero(t, reports[0].Error)
-	assert.Equal(t, "Index API", reports[1].Name)
-	assert.NotZero(t, reports[1].Duration)
-	assert.Equal(t, KO, reports[1].Status)
-	assert.NotZero(t, reports[1].Error)
+
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "red"}, nil).Times(1)
+
+	reports = m.HealthChecks(context.Background())
+	assert.Equal(t, "Health", reports[0].Name)
+	assert.NotZero(t, reports[0].Duration)
+	assert.Equal(t, KO, reports[0].Status)
+	assert.Zero(t, reports[0].Error)
+
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "nope"}, nil).Times(1)
+
+	reports = m.HealthChecks(context.Background())
+	assert.Equal(t, "Health", reports[0].Name)
+	assert.NotZero(t, reports[0].Duration)
+	assert.Equal(t, Unknown, reports[0].Status)
+	assert.Zero(t, reports[0].Error)
+
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{}, fmt.Errorf("Fail to check health")).Times(1)
+
+	reports = m.HealthChecks(context.Background())
+	assert.Equal(t, "Health", reports[0].Name)
+	assert.NotZero(t, reports[0].Duration)
+	assert.Equal(t, KO, reports[0].Status)
+	assert.NotZero(t, reports[0].Error)
 }
 
-func TestElasticsearchHealthChecksFailureErrorCreation(t *testing.T) {
+func TestElasticsearchModuleLoggingMW(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var module = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{})
+	expectClusterChecks(mockElasticsearchClient)
+	expectDocumentCheck(mockElasticsearchClient)
+	var m = MakeElasticsearchModuleLoggingMW(mockLogger)(module)
+
+	// Context with correlation ID.
+	rand.Seed(time.Now().UnixNano())
+	var corrID = strconv.FormatUint(rand.Uint64(), 10)
+	var ctx = correlation.WithID(context.Background(), corrID)
+
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "HealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	m.HealthChecks(ctx)
+
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	// Without correlation ID.
+	var f = func() {
+		m.HealthChecks(context.Background())
+	}
+	assert.Panics(t, f)
+}
 
-	var m = NewElasticsearchModule(mockElasticsearchClient)
+func TestElasticsearchClusterHealthChecks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	var indexWrongFormat = internal.IndexRepresentation{
-		Index: "wrong-format",
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{
+		JVMHeapDegraded:     75,
+		JVMHeapKO:           90,
+		PendingTasks:        10,
+		PendingTasksMaxWait: 10 * time.Second,
+		ShardsPerNodeRatio:  0.8,
+		ExpectedDataNodes:   2,
+	})
+	expectDocumentCheck(mockElasticsearchClient)
+
+	var settings = internal.ClusterSettingsRepresentation{
+		Persistent: map[string]interface{}{"cluster.routing.allocation.disk.watermark.flood_stage": "10gb"},
+		Defaults: map[string]interface{}{
+			"cluster.routing.allocation.disk.watermark.high":        "90%",
+			"cluster.routing.allocation.disk.watermark.flood_stage": "95%",
+			"cluster.max_shards_per_node":                           "100",
+		},
+	}
+	var node1, node2 internal.NodeStatsRepresentation
+	{
+		node1.Name = "node-1"
+		node1.Roles = []string{"master", "data"}
+		node1.FS.Total.TotalInBytes = 100 << 30
+		node1.FS.Total.AvailableInBytes = 50 << 30
+		node1.JVM.Mem.HeapUsedPercent = 50
+
+		node2.Name = "node-2"
+		node2.Roles = []string{"data"}
+		node2.FS.Total.TotalInBytes = 100 << 30
+		node2.FS.Total.AvailableInBytes = 5 << 30
+		node2.JVM.Mem.HeapUsedPercent = 80
 	}
-	var indexOld = internal.IndexRepresentation{
-		Index: "int-elastic-1800.10.02",
+	var stats = internal.NodesStatsRepresentation{
+		Nodes: map[string]internal.NodeStatsRepresentation{"1": node1, "2": node2},
 	}
-	var indexNew = internal.IndexRepresentation{
-		Index: "int-elastic-2018.10.01",
+	var allocation = []internal.AllocationRepresentation{
+		{Node: "node-1", Shards: "10"},
+		{Node: "node-2", Shards: "85"},
+		{Node: "UNASSIGNED", Shards: "200"},
+	}
+	var readOnly = map[string]internal.IndexSettingRepresentation{
+		"int-elastic-2018.10.01": {Settings: map[string]string{"index.blocks.read_only_allow_delete": "true"}},
+		"int-elastic-2018.10.02": {Settings: map[string]string{}},
 	}
 
-	var allIndexes = []internal.IndexRepresentation{indexWrongFormat, indexOld, indexNew}
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(allIndexes, nil).Times(1)
-	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any()).Return(fmt.Errorf("Fail to create")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green", NumberOfDataNodes: 1, NumberOfPendingTasks: 2, TaskMaxWaitingInQueueMillis: 20000}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().ClusterSettings(gomock.Any()).Return(settings, nil).Times(1)
+	mockElasticsearchClient.EXPECT().NodesStats(gomock.Any()).Return(stats, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Allocation(gomock.Any()).Return(allocation, nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.blocks.read_only_allow_delete").Return(readOnly, nil).Times(1)
 
 	var reports = m.HealthChecks(context.Background())
-	assert.Equal(t, 2, len(reports))
-	assert.Equal(t, "Health", reports[0].Name)
-	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, OK, reports[0].Status)
-	assert.Zero(t, reports[0].Error)
-	assert.Equal(t, "Index API", reports[1].Name)
-	assert.NotZero(t, reports[1].Duration)
+	assert.Equal(t, 9, len(reports))
+
+	// Disk: node-2 is above the persistent flood stage watermark.
+	assert.Equal(t, "Disk watermarks", reports[1].Name)
 	assert.Equal(t, KO, reports[1].Status)
 	assert.NotZero(t, reports[1].Error)
+	var disks map[string]map[string]interface{}
+	assert.Nil(t, json.Unmarshal(reports[1].Infos, &disks))
+	assert.Equal(t, "OK", disks["node-1"]["status"])
+	assert.Equal(t, "KO", disks["node-2"]["status"])
+
+	// JVM heap: node-2 is above the degraded threshold.
+	assert.Equal(t, "JVM heap", reports[2].Name)
+	assert.Equal(t, Degraded, reports[2].Status)
+	assert.Equal(t, `{"node-1":50,"node-2":80}`, string(reports[2].Infos))
+
+	// Pending tasks: the oldest task waits for too long.
+	assert.Equal(t, "Pending tasks", reports[3].Name)
+	assert.Equal(t, Degraded, reports[3].Status)
+	assert.NotZero(t, reports[3].Error)
+
+	// Shards per node: node-2 is above 80% of the limit.
+	assert.Equal(t, "Shards per node", reports[4].Name)
+	assert.Equal(t, Degraded, reports[4].Status)
+	assert.Equal(t, `{"node-1":10,"node-2":85}`, string(reports[4].Infos))
+
+	// Data nodes: one data node is missing.
+	assert.Equal(t, "Data nodes", reports[5].Name)
+	assert.Equal(t, KO, reports[5].Status)
+	assert.NotZero(t, reports[5].Error)
+
+	// Read-only indexes.
+	assert.Equal(t, "Read-only indexes", reports[6].Name)
+	assert.Equal(t, KO, reports[6].Status)
+	assert.Equal(t, `["int-elastic-2018.10.01"]`, string(reports[6].Infos))
 }
 
-func TestElasticsearchHealthChecksFailureErrorListing(t *testing.T) {
+func TestElasticsearchClusterHealthChecksFailure(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	var m = NewElasticsearchModule(mockElasticsearchClient)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{ExpectedDataNodes: 1})
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(nil, fmt.Errorf("Fail to list")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), gomock.Any()).Return(internal.IndexSettingsRepresentation{}, errIndexNotFound).Times(1)
+	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any(), gomock.Any()).Return(fmt.Errorf("Fail to create")).Times(1)
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{}, fmt.Errorf("Fail to check health")).Times(1)
+	mockElasticsearchClient.EXPECT().ClusterSettings(gomock.Any()).Return(internal.ClusterSettingsRepresentation{}, fmt.Errorf("Fail to get settings")).Times(1)
+	mockElasticsearchClient.EXPECT().NodesStats(gomock.Any()).Return(internal.NodesStatsRepresentation{}, fmt.Errorf("Fail to get stats")).Times(1)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("Fail to get settings")).Times(2)
 
 	var reports = m.HealthChecks(context.Background())
-	assert.Equal(t, 2, len(reports))
-	assert.Equal(t, "Health", reports[0].Name)
-	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, OK, reports[0].Status)
-	assert.Zero(t, reports[0].Error)
-	assert.Equal(t, "Index API", reports[1].Name)
-	assert.NotZero(t, reports[1].Duration)
-	assert.Equal(t, KO, reports[1].Status)
-	assert.NotZero(t, reports[1].Error)
+	assert.Equal(t, 9, len(reports))
+	for _, r := range reports {
+		assert.Equal(t, KO, r.Status)
+		assert.NotZero(t, r.Error)
+	}
 }
 
-func TestElasticsearchHealthChecksFailure(t *testing.T) {
+func TestElasticsearchDataNodesCheckDeactivated(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	var m = NewElasticsearchModule(mockElasticsearchClient)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{})
+	expectClusterChecks(mockElasticsearchClient)
+	expectDocumentCheck(mockElasticsearchClient)
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(nil, fmt.Errorf("Fail to list")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "yellow"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
 
 	var reports = m.HealthChecks(context.Background())
-	assert.Equal(t, "Health", reports[0].Name)
-	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, Degraded, reports[0].Status)
-	assert.Zero(t, reports[0].Error)
+	assert.Equal(t, "Data nodes", reports[5].Name)
+	assert.Equal(t, Deactivated, reports[5].Status)
+	assert.Zero(t, reports[5].Error)
+	for _, r := range reports[1:] {
+		assert.NotEqual(t, KO, r.Status)
+	}
+}
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(nil, fmt.Errorf("Fail to list")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "red"}, nil).Times(1)
+// expectClusterChecks sets the expectations of a healthy cluster for the cluster health checks.
+func TestElasticsearchHealthChecksTimeout(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	reports = m.HealthChecks(context.Background())
-	assert.Equal(t, "Health", reports[0].Name)
-	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, KO, reports[0].Status)
-	assert.Zero(t, reports[0].Error)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{CheckTimeout: 20 * time.Millisecond})
+	expectDocumentCheck(mockElasticsearchClient)
+
+	// The shards allocation hangs until the end of the test.
+	var release = make(chan struct{})
+	defer close(release)
+	mockElasticsearchClient.EXPECT().Allocation(gomock.Any()).DoAndReturn(func(context.Context) ([]internal.AllocationRepresentation, error) {
+		<-release
+		return nil, fmt.Errorf("Fail to get allocation")
+	}).Times(1)
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().ClusterSettings(gomock.Any()).Return(internal.ClusterSettingsRepresentation{
+		Defaults: map[string]interface{}{"cluster.max_shards_per_node": "1000"},
+	}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().NodesStats(gomock.Any()).Return(internal.NodesStatsRepresentation{}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.blocks.read_only_allow_delete").Return(map[string]internal.IndexSettingRepresentation{}, nil).Times(1)
+
+	var reports = m.HealthChecks(context.Background())
+	assert.Equal(t, 9, len(reports))
+	assert.Equal(t, OK, reports[3].Status)
+	assert.Equal(t, "Shards per node", reports[4].Name)
+	assert.Equal(t, KO, reports[4].Status)
+	assert.Contains(t, reports[4].Error.Error(), "health check timed out")
+	assert.True(t, reports[4].Duration >= 20*time.Millisecond)
+	assert.Equal(t, OK, reports[6].Status)
+}
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(nil, fmt.Errorf("Fail to list")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "nope"}, nil).Times(1)
+// errIndexNotFound is the error of the client when the index does not exist.
+var errIndexNotFound = &internal.ElasticsearchError{StatusCode: 404, Status: "404 Not Found", Type: "index_not_found_exception"}
 
-	reports = m.HealthChecks(context.Background())
-	assert.Equal(t, "Health", reports[0].Name)
-	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, Unknown, reports[0].Status)
-	assert.Zero(t, reports[0].Error)
+func expectClusterChecks(m *mock.ElasticsearchClient) {
+	var settings = internal.ClusterSettingsRepresentation{
+		Defaults: map[string]interface{}{
+			"cluster.routing.allocation.disk.watermark.high":        "90%",
+			"cluster.routing.allocation.disk.watermark.flood_stage": "95%",
+			"cluster.max_shards_per_node":                           "1000",
+		},
+	}
+	m.EXPECT().ClusterSettings(gomock.Any()).Return(settings, nil).AnyTimes()
+	m.EXPECT().NodesStats(gomock.Any()).Return(internal.NodesStatsRepresentation{}, nil).AnyTimes()
+	m.EXPECT().Allocation(gomock.Any()).Return([]internal.AllocationRepresentation{}, nil).AnyTimes()
+	m.EXPECT().GetIndexesSetting(gomock.Any(), "index.blocks.read_only_allow_delete").Return(map[string]internal.IndexSettingRepresentation{}, nil).AnyTimes()
+}
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return(nil, fmt.Errorf("Fail to list")).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{}, fmt.Errorf("Fail to check health")).Times(1)
+func TestElasticsearchDocumentCheck(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	reports = m.HealthChecks(context.Background())
-	assert.Equal(t, "Health", reports[0].Name)
-	assert.NotZero(t, reports[0].Duration)
-	assert.Equal(t, KO, reports[0].Status)
-	assert.NotZero(t, reports[0].Error)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{SearchVisibilityTimeout: time.Second})
+	expectClusterChecks(mockElasticsearchClient)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(map[string]internal.IndexSettingRepresentation{}, nil).AnyTimes()
+
+	// The probe document becomes visible to search after a few polls.
+	var searches = 0
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, errIndexNotFound).Times(1)
+	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any(), "elasticsearch-bridge-health").Return(nil).Times(1)
+	mockElasticsearchClient.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(internal.DocumentRepresentation{Found: true}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Search(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).DoAndReturn(func(ctx context.Context, indexName string, query interface{}) (internal.SearchRepresentation, error) {
+		searches++
+		if searches < 3 {
+			return internal.SearchRepresentation{}, nil
+		}
+		return searchHit(ctx, indexName, query)
+	}).Times(3)
+	mockElasticsearchClient.EXPECT().DeleteDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(nil).Times(1)
 
+	var reports = m.HealthChecks(context.Background())
+	var r = reports[7]
+	assert.Equal(t, "Document API", r.Name)
+	assert.Equal(t, OK, r.Status)
+	assert.Zero(t, r.Error)
+
+	var infos map[string]interface{}
+	assert.Nil(t, json.Unmarshal(r.Infos, &infos))
+	assert.Equal(t, "elasticsearch-bridge-health", infos["index"])
+	assert.Equal(t, false, infos["forced_refresh"])
+	var indexingLatency, _ = time.ParseDuration(infos["indexing_latency"].(string))
+	var visibilityLatency, _ = time.ParseDuration(infos["search_visibility_latency"].(string))
+	assert.True(t, visibilityLatency >= 2*100*time.Millisecond)
+	assert.True(t, visibilityLatency >= indexingLatency)
+}
 
+func TestElasticsearchDocumentCheckForcedRefresh(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
+
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{})
+	expectClusterChecks(mockElasticsearchClient)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(map[string]internal.IndexSettingRepresentation{}, nil).AnyTimes()
+
+	var refreshed = false
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().GetDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(internal.DocumentRepresentation{Found: true}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().Search(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).DoAndReturn(func(ctx context.Context, indexName string, query interface{}) (internal.SearchRepresentation, error) {
+		if !refreshed {
+			return internal.SearchRepresentation{}, nil
+		}
+		return searchHit(ctx, indexName, query)
+	}).AnyTimes()
+	mockElasticsearchClient.EXPECT().DeleteDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(nil).AnyTimes()
+
+	// Visible only after the explicit refresh.
+	mockElasticsearchClient.EXPECT().Refresh(gomock.Any(), "elasticsearch-bridge-health").DoAndReturn(func(context.Context, string) error {
+		refreshed = true
+		return nil
+	}).Times(1)
+	var r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, Degraded, r.Status)
+	assert.NotZero(t, r.Error)
+
+	// Never visible.
+	refreshed = false
+	mockElasticsearchClient.EXPECT().Refresh(gomock.Any(), "elasticsearch-bridge-health").Return(nil).Times(1)
+	r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.NotZero(t, r.Error)
 }
 
-func TestElasticsearchModuleLoggingMW(t *testing.T) {
+func TestElasticsearchDocumentCheckCancelled(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
-	var mockLogger = mock.NewLogger(mockCtrl)
 
-	var module = NewElasticsearchModule(mockElasticsearchClient)
-	var m = MakeElasticsearchModuleLoggingMW(mockLogger)(module)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{CheckTimeout: 50 * time.Millisecond, SearchVisibilityTimeout: 10 * time.Second})
+	expectClusterChecks(mockElasticsearchClient)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(map[string]internal.IndexSettingRepresentation{}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().GetDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(internal.DocumentRepresentation{Found: true}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().DeleteDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(nil).AnyTimes()
+
+	// The probe is never visible, the polling stops when the check times out.
+	var searches int32
+	mockElasticsearchClient.EXPECT().Search(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).DoAndReturn(func(context.Context, string, interface{}) (internal.SearchRepresentation, error) {
+		atomic.AddInt32(&searches, 1)
+		return internal.SearchRepresentation{}, nil
+	}).AnyTimes()
+
+	var r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.Contains(t, r.Error.Error(), "health check timed out")
+
+	time.Sleep(200 * time.Millisecond)
+	var n = atomic.LoadInt32(&searches)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, n, atomic.LoadInt32(&searches))
+}
 
-	// Context with correlation ID.
-	rand.Seed(time.Now().UnixNano())
-	var corrID = strconv.FormatUint(rand.Uint64(), 10)
-	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+func TestElasticsearchDocumentCheckFailure(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return([]internal.IndexRepresentation{}, nil).Times(1)
-	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().DeleteIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
-	mockLogger.EXPECT().Log("unit", "HealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
-	m.HealthChecks(ctx)
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{})
+	expectClusterChecks(mockElasticsearchClient)
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(map[string]internal.IndexSettingRepresentation{}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).AnyTimes()
+
+	// Health index read failure, the index is not created.
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, &internal.ElasticsearchError{StatusCode: 503, Status: "503 Service Unavailable"}).Times(1)
+	var r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.Contains(t, r.Error.Error(), "could not get health index")
+
+	// Health index creation failure.
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, errIndexNotFound).Times(1)
+	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any(), "elasticsearch-bridge-health").Return(fmt.Errorf("Fail to create")).Times(1)
+	r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.Contains(t, r.Error.Error(), "could not create health index")
+
+	// Indexing failure.
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, nil).AnyTimes()
+	mockElasticsearchClient.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(fmt.Errorf("Fail to index")).Times(1)
+	r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.NotZero(t, r.Error)
+
+	// Probe document not found by ID, it is deleted anyway.
+	mockElasticsearchClient.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(internal.DocumentRepresentation{Found: false}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().DeleteDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(nil).Times(1)
+	r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.NotZero(t, r.Error)
+
+	// Deletion failure.
+	mockElasticsearchClient.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockElasticsearchClient.EXPECT().GetDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(internal.DocumentRepresentation{Found: true}, nil).Times(1)
+	mockElasticsearchClient.EXPECT().Search(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).DoAndReturn(searchHit).Times(1)
+	mockElasticsearchClient.EXPECT().DeleteDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(fmt.Errorf("Fail to delete")).Times(1)
+	r = m.HealthChecks(context.Background())[7]
+	assert.Equal(t, KO, r.Status)
+	assert.NotZero(t, r.Error)
+}
 
-	mockElasticsearchClient.EXPECT().ListIndexes().Return([]internal.IndexRepresentation{}, nil).Times(1)
-	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().DeleteIndex(gomock.Any()).Return(nil).Times(1)
-	mockElasticsearchClient.EXPECT().Health().Return(internal.HealthRepresentation{Status: "green"}, nil).Times(1)
-	// Without correlation ID.
-	var f = func() {
-		m.HealthChecks(context.Background())
+func TestElasticsearchProbeIndexesCheck(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockElasticsearchClient = mock.NewElasticsearchClient(mockCtrl)
+
+	var m = NewElasticsearchModule(mockElasticsearchClient, "elasticsearch-bridge-health", ElasticsearchThresholds{ProbeIndexMaxAge: time.Hour})
+	expectClusterChecks(mockElasticsearchClient)
+	mockElasticsearchClient.EXPECT().GetIndex(gomock.Any(), gomock.Any()).Return(internal.IndexSettingsRepresentation{}, errIndexNotFound).AnyTimes()
+	mockElasticsearchClient.EXPECT().CreateIndex(gomock.Any(), gomock.Any()).Return(fmt.Errorf("Fail to create")).AnyTimes()
+	mockElasticsearchClient.EXPECT().Health(gomock.Any()).Return(internal.HealthRepresentation{Status: "green"}, nil).AnyTimes()
+
+	var settings = map[string]internal.IndexSettingRepresentation{
+		"b1f1e38a-c5b4-11e8-a355-529269fb1459": {Settings: map[string]string{"index.creation_date": strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix()*1000, 10)}},
+		"1b8f6b4c-c5b5-11e8-a355-529269fb1459": {Settings: map[string]string{"index.creation_date": strconv.FormatInt(time.Now().Unix()*1000, 10)}},
+		"int-elastic-1800.10.02":               {Settings: map[string]string{"index.creation_date": "0"}},
 	}
-	assert.Panics(t, f)
+
+	// One stale probe index.
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(settings, nil).Times(1)
+	var r = m.HealthChecks(context.Background())[8]
+	assert.Equal(t, "Probe indexes", r.Name)
+	assert.Equal(t, Degraded, r.Status)
+	assert.NotZero(t, r.Error)
+	assert.Equal(t, `{"probe_indexes":2,"stale_probe_indexes":1}`, string(r.Infos))
+
+	// No stale probe index.
+	delete(settings, "b1f1e38a-c5b4-11e8-a355-529269fb1459")
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(settings, nil).Times(1)
+	r = m.HealthChecks(context.Background())[8]
+	assert.Equal(t, OK, r.Status)
+	assert.Zero(t, r.Error)
+	assert.Equal(t, `{"probe_indexes":1,"stale_probe_indexes":0}`, string(r.Infos))
+
+	// Failure.
+	mockElasticsearchClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(nil, fmt.Errorf("Fail to get settings")).Times(1)
+	r = m.HealthChecks(context.Background())[8]
+	assert.Equal(t, KO, r.Status)
+	assert.NotZero(t, r.Error)
+}
+
+// expectDocumentCheck sets the expectations of a healthy cluster for the document round-trip
+// and probe indexes checks.
+func expectDocumentCheck(m *mock.ElasticsearchClient) {
+	m.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(map[string]internal.IndexSettingRepresentation{}, nil).AnyTimes()
+	m.EXPECT().GetIndex(gomock.Any(), "elasticsearch-bridge-health").Return(internal.IndexSettingsRepresentation{}, nil).AnyTimes()
+	m.EXPECT().IndexDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	m.EXPECT().GetDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(internal.DocumentRepresentation{Found: true}, nil).AnyTimes()
+	m.EXPECT().Search(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).DoAndReturn(searchHit).AnyTimes()
+	m.EXPECT().DeleteDocument(gomock.Any(), "elasticsearch-bridge-health", gomock.Any()).Return(nil).AnyTimes()
+}
+
+// searchHit returns the search response containing the probe document of the term query.
+func searchHit(_ context.Context, indexName string, query interface{}) (internal.SearchRepresentation, error) {
+	var q struct {
+		Query struct {
+			Term struct {
+				Probe string `json:"probe"`
+			} `json:"term"`
+		} `json:"query"`
+	}
+	var b, _ = json.Marshal(query)
+	json.Unmarshal(b, &q)
+
+	var resp = internal.SearchRepresentation{}
+	resp.Hits.Hits = []internal.DocumentRepresentation{{Index: indexName, ID: q.Query.Term.Probe, Found: true}}
+	return resp, nil
 }
 
 func TestElasticsearchReportMarshalJSON(t *testing.T) {
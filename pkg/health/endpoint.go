@@ -21,7 +21,11 @@ type Endpoints struct {
 	FlakiReadHealthCheck         endpoint.Endpoint
 	ElasticsearchExecHealthCheck endpoint.Endpoint
 	ElasticsearchReadHealthCheck endpoint.Endpoint
+	CockroachExecHealthCheck     endpoint.Endpoint
+	CockroachReadHealthCheck     endpoint.Endpoint
 	AllHealthChecks              endpoint.Endpoint
+	DeepHealthChecks             endpoint.Endpoint
+	FleetHealthChecks            endpoint.Endpoint
 }
 
 // HealthChecker is the health component interface.
@@ -38,7 +42,11 @@ type HealthChecker interface {
 	ReadFlakiHealthChecks(context.Context) json.RawMessage
 	ExecElasticsearchHealthChecks(context.Context) json.RawMessage
 	ReadElasticsearchHealthChecks(context.Context) json.RawMessage
+	ExecCockroachHealthChecks(context.Context) json.RawMessage
+	ReadCockroachHealthChecks(context.Context) json.RawMessage
 	AllHealthChecks(context.Context) json.RawMessage
+	DeepHealthChecks(context.Context) json.RawMessage
+	FleetHealthChecks(context.Context) json.RawMessage
 }
 
 // MakeExecInfluxHealthCheckEndpoint makes the InfluxHealthCheck endpoint
@@ -137,9 +145,41 @@ func MakeReadFlakiHealthCheckEndpoint(hc HealthChecker) endpoint.Endpoint {
 	}
 }
 
+// MakeExecCockroachHealthCheckEndpoint makes the CockroachHealthCheck endpoint
+// that forces the execution of the health checks.
+func MakeExecCockroachHealthCheckEndpoint(hc HealthChecker) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return hc.ExecCockroachHealthChecks(ctx), nil
+	}
+}
+
+// MakeReadCockroachHealthCheckEndpoint makes the CockroachHealthCheck endpoint
+// that read the last health check status in DB.
+func MakeReadCockroachHealthCheckEndpoint(hc HealthChecker) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return hc.ReadCockroachHealthChecks(ctx), nil
+	}
+}
+
 // MakeAllHealthChecksEndpoint makes an endpoint that does all health checks.
 func MakeAllHealthChecksEndpoint(hc HealthChecker) endpoint.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		return hc.AllHealthChecks(ctx), nil
 	}
 }
+
+// MakeDeepHealthChecksEndpoint makes an endpoint that forces the execution of all
+// health checks concurrently.
+func MakeDeepHealthChecksEndpoint(hc HealthChecker) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return hc.DeepHealthChecks(ctx), nil
+	}
+}
+
+// MakeFleetHealthChecksEndpoint makes an endpoint that reads the health checks
+// of all the instances of the component.
+func MakeFleetHealthChecksEndpoint(hc HealthChecker) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return hc.FleetHealthChecks(ctx), nil
+	}
+}
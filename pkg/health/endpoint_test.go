@@ -131,6 +131,33 @@ func TestSentryHealthCheckEndpoint(t *testing.T) {
 
 }
 
+func TestCockroachHealthCheckEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var execEndpoint = MakeExecCockroachHealthCheckEndpoint(mockComponent)
+	var readEndpoint = MakeReadCockroachHealthCheckEndpoint(mockComponent)
+
+	var j = json.RawMessage(`{"unit":"cockroach","status":"OK"}`)
+
+	mockComponent.EXPECT().ExecCockroachHealthChecks(context.Background()).Return(j).Times(1)
+	{
+		var reports, err = execEndpoint(context.Background(), nil)
+		assert.Nil(t, err)
+		var json, _ = json.Marshal(&reports)
+		assert.Equal(t, `{"unit":"cockroach","status":"OK"}`, string(json))
+	}
+
+	mockComponent.EXPECT().ReadCockroachHealthChecks(context.Background()).Return(j).Times(1)
+	{
+		var reports, err = readEndpoint(context.Background(), nil)
+		assert.Nil(t, err)
+		var json, _ = json.Marshal(&reports)
+		assert.Equal(t, `{"unit":"cockroach","status":"OK"}`, string(json))
+	}
+}
+
 func TestAllHealthCheckEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -148,3 +175,33 @@ func TestAllHealthCheckEndpoint(t *testing.T) {
 	}
 
 }
+
+func TestDeepHealthCheckEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var e = MakeDeepHealthChecksEndpoint(mockComponent)
+
+	var j = json.RawMessage(`{"redis":{"status":"OK"}}`)
+	mockComponent.EXPECT().DeepHealthChecks(context.Background()).Return(j).Times(1)
+	var reports, err = e(context.Background(), nil)
+	assert.Nil(t, err)
+	var json, _ = json.Marshal(&reports)
+	assert.Equal(t, `{"redis":{"status":"OK"}}`, string(json))
+}
+
+func TestFleetHealthCheckEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var e = MakeFleetHealthChecksEndpoint(mockComponent)
+
+	var j = json.RawMessage(`{"status":"OK","instances":[]}`)
+	mockComponent.EXPECT().FleetHealthChecks(context.Background()).Return(j).Times(1)
+	var reports, err = e(context.Background(), nil)
+	assert.Nil(t, err)
+	var json, _ = json.Marshal(&reports)
+	assert.Equal(t, `{"status":"OK","instances":[]}`, string(json))
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
+	sentry "github.com/getsentry/raven-go"
+	"github.com/go-kit/kit/endpoint"
+)
+
+// ErrorTracker is the interface of the Sentry client capturing the errors.
+type ErrorTracker interface {
+	CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string
+}
+
+// MakeEndpointErrorTrackingMW makes an error tracking middleware that captures the errors
+// of the endpoint, tagged with the unit and the correlation ID.
+func MakeEndpointErrorTrackingMW(tracker ErrorTracker, unit string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var reply, err = next(ctx, req)
+			if err != nil {
+				var tags = map[string]string{"unit": unit}
+				if id, ok := correlation.ID(ctx); ok {
+					tags["correlation_id"] = id
+				}
+				tracker.CaptureError(err, tags)
+			}
+			return reply, err
+		}
+	}
+}
+
+// Error tracking middleware for the storage of the component.
+type storeModuleErrorTrackingMW struct {
+	tracker ErrorTracker
+	next    StoreModule
+}
+
+// MakeStoreModuleErrorTrackingMW makes an error tracking middleware for the storage of the
+// health component. The component does not return the errors of the storage, e.g. when it
+// fails to update the reports, so they are captured here, tagged with the unit.
+func MakeStoreModuleErrorTrackingMW(tracker ErrorTracker) func(StoreModule) StoreModule {
+	return func(next StoreModule) StoreModule {
+		return &storeModuleErrorTrackingMW{
+			tracker: tracker,
+			next:    next,
+		}
+	}
+}
+
+// storeModuleErrorTrackingMW implements StoreModule.
+func (m *storeModuleErrorTrackingMW) Read(name string) (StoredReport, error) {
+	var report, err = m.next.Read(name)
+	m.capture(err, name)
+	return report, err
+}
+
+// storeModuleErrorTrackingMW implements StoreModule.
+func (m *storeModuleErrorTrackingMW) ReadAll() ([]StoredReport, error) {
+	var reports, err = m.next.ReadAll()
+	m.capture(err, "")
+	return reports, err
+}
+
+// storeModuleErrorTrackingMW implements StoreModule.
+func (m *storeModuleErrorTrackingMW) Update(unit string, validity time.Duration, reports json.RawMessage) error {
+	var err = m.next.Update(unit, validity, reports)
+	m.capture(err, unit)
+	return err
+}
+
+// storeModuleErrorTrackingMW implements StoreModule.
+func (m *storeModuleErrorTrackingMW) Clean() error {
+	var err = m.next.Clean()
+	m.capture(err, "")
+	return err
+}
+
+func (m *storeModuleErrorTrackingMW) capture(err error, unit string) {
+	if err == nil {
+		return
+	}
+	var tags = map[string]string{"module": "storage"}
+	if unit != "" {
+		tags["unit"] = unit
+	}
+	m.tracker.CaptureError(err, tags)
+}
@@ -0,0 +1,88 @@
+package health_test
+
+//go:generate mockgen -destination=./mock/error_tracking.go -package=mock -mock_names=ErrorTracker=ErrorTracker github.com/cloudtrust/elasticsearch-bridge/pkg/health ErrorTracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointErrorTrackingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+
+	var endpointErr error
+	var e = MakeEndpointErrorTrackingMW(mockTracker, "ExecInfluxHealthCheck")(func(context.Context, interface{}) (interface{}, error) {
+		return "reply", endpointErr
+	})
+	var ctx = correlation.WithID(context.Background(), "123")
+
+	// No error.
+	{
+		var reply, err = e(ctx, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "reply", reply)
+	}
+
+	// Error.
+	{
+		endpointErr = fmt.Errorf("fail")
+		mockTracker.EXPECT().CaptureError(endpointErr, map[string]string{"unit": "ExecInfluxHealthCheck", "correlation_id": "123"}).Return("").Times(1)
+		var _, err = e(ctx, nil)
+		assert.Equal(t, endpointErr, err)
+	}
+}
+
+func TestStoreModuleErrorTrackingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = MakeStoreModuleErrorTrackingMW(mockTracker)(mockStorage)
+	var reports = json.RawMessage(`[]`)
+
+	// No error.
+	{
+		mockStorage.EXPECT().Update("influx", time.Minute, reports).Return(nil).Times(1)
+		assert.Nil(t, m.Update("influx", time.Minute, reports))
+	}
+
+	// Update error, ignored by the component.
+	{
+		var updateErr = fmt.Errorf("fail")
+		mockStorage.EXPECT().Update("influx", time.Minute, reports).Return(updateErr).Times(1)
+		mockTracker.EXPECT().CaptureError(updateErr, map[string]string{"module": "storage", "unit": "influx"}).Return("").Times(1)
+		assert.Equal(t, updateErr, m.Update("influx", time.Minute, reports))
+	}
+
+	// Clean error.
+	{
+		var cleanErr = fmt.Errorf("fail")
+		mockStorage.EXPECT().Clean().Return(cleanErr).Times(1)
+		mockTracker.EXPECT().CaptureError(cleanErr, map[string]string{"module": "storage"}).Return("").Times(1)
+		assert.Equal(t, cleanErr, m.Clean())
+	}
+
+	// Read errors.
+	{
+		var readErr = fmt.Errorf("fail")
+		mockStorage.EXPECT().Read("influx").Return(StoredReport{}, readErr).Times(1)
+		mockStorage.EXPECT().ReadAll().Return(nil, readErr).Times(1)
+		mockTracker.EXPECT().CaptureError(readErr, gomock.Any()).Return("").Times(2)
+		var _, err = m.Read("influx")
+		assert.Equal(t, readErr, err)
+		_, err = m.ReadAll()
+		assert.Equal(t, readErr, err)
+	}
+}
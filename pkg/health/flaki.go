@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/connectivity"
+)
+
+// FlakiFallback is the interface of the Flaki client that generates the IDs with the
+// embedded generator when Flaki is unreachable.
+type FlakiFallback interface {
+	Degraded() (bool, time.Time, error)
+}
+
+// Fallback middleware at module level.
+type flakiModuleFallbackMW struct {
+	fallback FlakiFallback
+	next     FlakiHealthChecker
+}
+
+// MakeFlakiModuleFallbackMW makes a middleware that adds the report of the fallback to the
+// Flaki reports. It is degraded while the IDs are generated by the embedded generator.
+func MakeFlakiModuleFallbackMW(fallback FlakiFallback) func(FlakiHealthChecker) FlakiHealthChecker {
+	return func(next FlakiHealthChecker) FlakiHealthChecker {
+		return &flakiModuleFallbackMW{
+			fallback: fallback,
+			next:     next,
+		}
+	}
+}
+
+// flakiModuleFallbackMW implements Module.
+func (m *flakiModuleFallbackMW) HealthChecks(ctx context.Context) []common.FlakiReport {
+	var reports = m.next.HealthChecks(ctx)
+
+	var report = common.FlakiReport{Name: "fallback", Status: common.OK}
+	if degraded, since, err := m.fallback.Degraded(); degraded {
+		report.Status = common.Degraded
+		report.Error = errors.Wrapf(err, "IDs generated by the embedded generator since %s", since.UTC().Format(time.RFC3339))
+	}
+	return append(reports, report)
+}
+
+// FlakiConnectivity is the interface of the gRPC connection to Flaki.
+type FlakiConnectivity interface {
+	GetState() connectivity.State
+}
+
+// Connectivity middleware at module level.
+type flakiModuleConnectivityMW struct {
+	conn FlakiConnectivity
+	next FlakiHealthChecker
+}
+
+// MakeFlakiModuleConnectivityMW makes a middleware that adds the connectivity state of the
+// gRPC connection to the Flaki reports. It is KO while the connection is failing, and
+// degraded while it is connecting.
+func MakeFlakiModuleConnectivityMW(conn FlakiConnectivity) func(FlakiHealthChecker) FlakiHealthChecker {
+	return func(next FlakiHealthChecker) FlakiHealthChecker {
+		return &flakiModuleConnectivityMW{
+			conn: conn,
+			next: next,
+		}
+	}
+}
+
+// flakiModuleConnectivityMW implements Module.
+func (m *flakiModuleConnectivityMW) HealthChecks(ctx context.Context) []common.FlakiReport {
+	var reports = m.next.HealthChecks(ctx)
+
+	var state = m.conn.GetState()
+	var report = common.FlakiReport{Name: "connectivity", Status: common.OK}
+	switch state {
+	case connectivity.Idle, connectivity.Ready:
+	case connectivity.Connecting:
+		report.Status = common.Degraded
+		report.Error = fmt.Errorf("connection state %s", state)
+	default:
+		report.Status = common.KO
+		report.Error = fmt.Errorf("connection state %s", state)
+	}
+	return append(reports, report)
+}
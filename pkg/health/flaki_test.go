@@ -0,0 +1,85 @@
+package health_test
+
+//go:generate mockgen -destination=./mock/flaki.go -package=mock -mock_names=FlakiFallback=FlakiFallback,FlakiConnectivity=FlakiConnectivity github.com/cloudtrust/elasticsearch-bridge/pkg/health FlakiFallback,FlakiConnectivity
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestFlakiModuleFallbackMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockFallback = mock.NewFlakiFallback(mockCtrl)
+	var mockModule = mock.NewFlakiHealthChecker(mockCtrl)
+
+	var m = MakeFlakiModuleFallbackMW(mockFallback)(mockModule)
+	var flakiReport = common.FlakiReport{Name: "ping", Status: common.OK}
+	mockModule.EXPECT().HealthChecks(gomock.Any()).Return([]common.FlakiReport{flakiReport}).Times(2)
+
+	// IDs from Flaki.
+	{
+		mockFallback.EXPECT().Degraded().Return(false, time.Time{}, nil).Times(1)
+		var reports = m.HealthChecks(context.Background())
+		assert.Equal(t, 2, len(reports))
+		assert.Equal(t, flakiReport, reports[0])
+		assert.Equal(t, "fallback", reports[1].Name)
+		assert.Equal(t, common.OK, reports[1].Status)
+		assert.Nil(t, reports[1].Error)
+	}
+
+	// IDs from the embedded generator.
+	{
+		mockFallback.EXPECT().Degraded().Return(true, time.Date(2018, 2, 13, 6, 27, 7, 0, time.UTC), fmt.Errorf("connection refused")).Times(1)
+		var reports = m.HealthChecks(context.Background())
+		assert.Equal(t, 2, len(reports))
+		assert.Equal(t, common.Degraded, reports[1].Status)
+		assert.Contains(t, reports[1].Error.Error(), "2018-02-13T06:27:07Z")
+		assert.Contains(t, reports[1].Error.Error(), "connection refused")
+	}
+}
+
+func TestFlakiModuleConnectivityMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockConn = mock.NewFlakiConnectivity(mockCtrl)
+	var mockModule = mock.NewFlakiHealthChecker(mockCtrl)
+
+	var m = MakeFlakiModuleConnectivityMW(mockConn)(mockModule)
+	var flakiReport = common.FlakiReport{Name: "ping", Status: common.OK}
+
+	var tsts = []struct {
+		state  connectivity.State
+		status common.Status
+	}{
+		{connectivity.Idle, common.OK},
+		{connectivity.Ready, common.OK},
+		{connectivity.Connecting, common.Degraded},
+		{connectivity.TransientFailure, common.KO},
+		{connectivity.Shutdown, common.KO},
+	}
+
+	for _, tst := range tsts {
+		mockModule.EXPECT().HealthChecks(gomock.Any()).Return([]common.FlakiReport{flakiReport}).Times(1)
+		mockConn.EXPECT().GetState().Return(tst.state).Times(1)
+		var reports = m.HealthChecks(context.Background())
+		assert.Equal(t, 2, len(reports))
+		assert.Equal(t, flakiReport, reports[0])
+		assert.Equal(t, "connectivity", reports[1].Name)
+		assert.Equal(t, tst.status, reports[1].Status)
+		if tst.status == common.OK {
+			assert.Nil(t, reports[1].Error)
+		} else {
+			assert.Contains(t, reports[1].Error.Error(), tst.state.String())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"sort"
+	"time"
+)
+
+// FleetReport is the health report of all the instances of the component.
+type FleetReport struct {
+	Status    string           `json:"status"`
+	Error     string           `json:"error,omitempty"`
+	Instances []InstanceReport `json:"instances"`
+}
+
+// InstanceReport is the health report of one instance of the component. An instance
+// whose reports are all stale is gone. The storage keeps the stale reports for a retention
+// period, so a gone instance is reported until then.
+type InstanceReport struct {
+	ComponentID string                `json:"component_id"`
+	Status      string                `json:"status"`
+	Gone        bool                  `json:"gone"`
+	LastUpdated time.Time             `json:"last_updated"`
+	Units       map[string]UnitReport `json:"units"`
+}
+
+// UnitReport is the status of a unit of an instance.
+type UnitReport struct {
+	Status      string    `json:"status"`
+	LastUpdated time.Time `json:"last_updated"`
+	ValidUntil  time.Time `json:"valid_until"`
+	Stale       bool      `json:"stale"`
+	Staleness   string    `json:"staleness,omitempty"`
+}
+
+// fleetReport builds the fleet report from the reports stored by all the instances.
+// The fleet is OK when all the live instances are OK, KO when no live instance is OK,
+// and degraded otherwise. Gone instances are ignored.
+func fleetReport(storedReports []StoredReport, now time.Time) FleetReport {
+	var instances = map[string]*InstanceReport{}
+	var instanceStatus = map[string]Status{}
+
+	for _, r := range storedReports {
+		var instance, ok = instances[r.ComponentID]
+		if !ok {
+			instance = &InstanceReport{
+				ComponentID: r.ComponentID,
+				Gone:        true,
+				Units:       map[string]UnitReport{},
+			}
+			instances[r.ComponentID] = instance
+			instanceStatus[r.ComponentID] = Deactivated
+		}
+
+		var s = unitStatus(r.Reports)
+		var u = UnitReport{
+			Status:      s.String(),
+			LastUpdated: r.LastUpdated,
+			ValidUntil:  r.ValidUntil,
+		}
+		// The status of a stale unit is not pertinent.
+		if now.After(r.ValidUntil) {
+			u.Stale = true
+			u.Staleness = now.Sub(r.ValidUntil).String()
+			s = Unknown
+		} else {
+			instance.Gone = false
+		}
+		instance.Units[r.HealthcheckUnit] = u
+		instanceStatus[r.ComponentID] = worst(instanceStatus[r.ComponentID], s)
+
+		if r.LastUpdated.After(instance.LastUpdated) {
+			instance.LastUpdated = r.LastUpdated
+		}
+	}
+
+	var ids = []string{}
+	for id := range instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var fleet = FleetReport{Instances: []InstanceReport{}}
+	var live, ok int
+	for _, id := range ids {
+		var instance = instances[id]
+		instance.Status = instanceStatus[id].String()
+		if instance.Gone {
+			instance.Status = Unknown.String()
+		} else {
+			live++
+			if statusSeverity[instanceStatus[id]] <= statusSeverity[OK] {
+				ok++
+			}
+		}
+		fleet.Instances = append(fleet.Instances, *instance)
+	}
+
+	switch {
+	case live > 0 && ok == live:
+		fleet.Status = OK.String()
+	case ok == 0:
+		fleet.Status = KO.String()
+	default:
+		fleet.Status = Degraded.String()
+	}
+	return fleet
+}
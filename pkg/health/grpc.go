@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpc_status "google.golang.org/grpc/status"
+)
+
+// WatchedStore is the storage of the health reports that notifies their updates.
+type WatchedStore interface {
+	Read(unit string) (StoredReport, error)
+	Watch(unit string, c chan<- struct{}) func()
+}
+
+// GRPCHealthServer implements the gRPC health checking protocol grpc.health.v1. The
+// services are the health units, and the empty service is the whole component. The
+// statuses come from the stored health reports.
+type GRPCHealthServer struct {
+	storage WatchedStore
+	units   []string
+}
+
+// NewGRPCHealthServer returns the gRPC health server for the units.
+func NewGRPCHealthServer(storage WatchedStore, units []string) *GRPCHealthServer {
+	return &GRPCHealthServer{
+		storage: storage,
+		units:   units,
+	}
+}
+
+// Check returns the serving status of the service.
+func (s *GRPCHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	var units, ok = s.serviceUnits(req.Service)
+	if !ok {
+		return nil, grpc_status.Error(codes.NotFound, "unknown service")
+	}
+
+	var st, _ = s.servingStatus(units)
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch streams the serving status of the service, then each of its transitions. The
+// status is computed again each time the reports of the units are updated, and when
+// they become stale.
+func (s *GRPCHealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	var units, ok = s.serviceUnits(req.Service)
+	if !ok {
+		var err = stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+		if err != nil {
+			return err
+		}
+		<-stream.Context().Done()
+		return grpc_status.Error(codes.Canceled, "stream has ended")
+	}
+
+	var updatec = make(chan struct{}, 1)
+	for _, unit := range units {
+		var cancel = s.storage.Watch(unit, updatec)
+		defer cancel()
+	}
+
+	var last = healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		var st, validUntil = s.servingStatus(units)
+		if st != last {
+			var err = stream.Send(&healthpb.HealthCheckResponse{Status: st})
+			if err != nil {
+				return err
+			}
+			last = st
+		}
+
+		var expiry <-chan time.Time
+		var timer *time.Timer
+		if !validUntil.IsZero() {
+			timer = time.NewTimer(time.Until(validUntil))
+			expiry = timer.C
+		}
+
+		select {
+		case <-updatec:
+		case <-expiry:
+		case <-stream.Context().Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return grpc_status.Error(codes.Canceled, "stream has ended")
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// serviceUnits returns the units of the service.
+func (s *GRPCHealthServer) serviceUnits(service string) ([]string, bool) {
+	if service == "" {
+		return s.units, true
+	}
+	for _, unit := range s.units {
+		if unit == service {
+			return []string{unit}, true
+		}
+	}
+	return nil, false
+}
+
+// servingStatus returns the serving status of the units, with the time at which it may
+// change because a report becomes stale. The zero time means no report becomes stale.
+func (s *GRPCHealthServer) servingStatus(units []string) (healthpb.HealthCheckResponse_ServingStatus, time.Time) {
+	var now = time.Now()
+	var st = Deactivated
+	var validUntil time.Time
+
+	for _, unit := range units {
+		var storedReport, err = s.storage.Read(unit)
+		switch {
+		case err != nil, storedReport.ComponentID == "", now.After(storedReport.ValidUntil):
+			st = worst(st, Unknown)
+		default:
+			st = worst(st, unitStatus(storedReport.Reports))
+			if validUntil.IsZero() || storedReport.ValidUntil.Before(validUntil) {
+				validUntil = storedReport.ValidUntil
+			}
+		}
+	}
+
+	switch st {
+	case OK, Degraded, Deactivated:
+		return healthpb.HealthCheckResponse_SERVING, validUntil
+	case KO:
+		return healthpb.HealthCheckResponse_NOT_SERVING, validUntil
+	default:
+		return healthpb.HealthCheckResponse_UNKNOWN, validUntil
+	}
+}
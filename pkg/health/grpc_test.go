@@ -0,0 +1,118 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCHealthServerCheck(t *testing.T) {
+	var storage = NewWatchModule(NewMemoryStorageModule("elasticsearch-bridge", "1", time.Hour))
+	var s = NewGRPCHealthServer(storage, []string{"redis", "elasticsearch"})
+
+	var check = func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		var rep, err = s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		assert.Nil(t, err)
+		return rep.Status
+	}
+
+	// No reports.
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, check("redis"))
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, check(""))
+
+	storage.Update("redis", time.Minute, json.RawMessage(`[{"name":"ping","status":"OK"}]`))
+	storage.Update("elasticsearch", time.Minute, json.RawMessage(`[{"name":"Health","status":"Degraded"}]`))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("redis"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("elasticsearch"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+
+	storage.Update("elasticsearch", time.Minute, json.RawMessage(`[{"name":"Health","status":"KO"}]`))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check("elasticsearch"))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	// Stale reports.
+	storage.Update("redis", -time.Minute, json.RawMessage(`[{"name":"ping","status":"OK"}]`))
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, check("redis"))
+
+	// Unknown service.
+	var _, err = s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// watchStream is a health watch stream that forwards the responses to a channel.
+type watchStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (s *watchStream) Send(rep *healthpb.HealthCheckResponse) error {
+	s.responses <- rep.Status
+	return nil
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestGRPCHealthServerWatch(t *testing.T) {
+	var storage = NewWatchModule(NewMemoryStorageModule("elasticsearch-bridge", "1", time.Hour))
+	var s = NewGRPCHealthServer(storage, []string{"redis", "elasticsearch"})
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var stream = &watchStream{ctx: ctx, responses: make(chan healthpb.HealthCheckResponse_ServingStatus, 10)}
+	var errc = make(chan error)
+	go func() {
+		errc <- s.Watch(&healthpb.HealthCheckRequest{Service: "redis"}, stream)
+	}()
+
+	var next = func() healthpb.HealthCheckResponse_ServingStatus {
+		select {
+		case st := <-stream.responses:
+			return st
+		case <-time.After(time.Second):
+			t.Fatal("no status received")
+			return -1
+		}
+	}
+
+	// Current status.
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, next())
+
+	// Transitions.
+	storage.Update("redis", 200*time.Millisecond, json.RawMessage(`[{"name":"ping","status":"OK"}]`))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, next())
+	storage.Update("redis", 200*time.Millisecond, json.RawMessage(`[{"name":"ping","status":"KO"}]`))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, next())
+
+	// The updates of other units or without transition are not streamed.
+	storage.Update("elasticsearch", time.Minute, json.RawMessage(`[{"name":"Health","status":"OK"}]`))
+	storage.Update("redis", 200*time.Millisecond, json.RawMessage(`[{"name":"ping","status":"KO"}]`))
+
+	// The reports become stale.
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, next())
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-errc))
+}
+
+func TestGRPCHealthServerWatchUnknownService(t *testing.T) {
+	var storage = NewWatchModule(NewMemoryStorageModule("elasticsearch-bridge", "1", time.Hour))
+	var s = NewGRPCHealthServer(storage, []string{"redis"})
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var stream = &watchStream{ctx: ctx, responses: make(chan healthpb.HealthCheckResponse_ServingStatus, 10)}
+	cancel()
+
+	var err = s.Watch(&healthpb.HealthCheckRequest{Service: "unknown"}, stream)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, <-stream.responses)
+}
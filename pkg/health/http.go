@@ -4,19 +4,124 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"regexp"
+	"strconv"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	"github.com/go-kit/kit/endpoint"
 	http_transport "github.com/go-kit/kit/transport/http"
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
 )
 
-// MakeHealthCheckHandler make an HTTP handler for an HealthCheck endpoint.
-func MakeHealthCheckHandler(e endpoint.Endpoint) *http_transport.Server {
+const (
+	// uberTraceIDHeader is the header of the Jaeger trace context.
+	uberTraceIDHeader = "uber-trace-id"
+	// traceparentHeader is the header of the W3C trace context.
+	traceparentHeader = "traceparent"
+)
+
+// traceparentRegexp matches the version 00 of the W3C traceparent header.
+var traceparentRegexp = regexp.MustCompile("^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$")
+
+// reportVersionKey is the context key of the report version requested with the query
+// parameter 'version'.
+type reportVersionKey struct{}
+
+// correlationIDEchoKey is the context key of the correlation ID echoed in the response.
+type correlationIDEchoKey struct{}
+
+// correlationIDEcho receives the correlation ID of the request. The correlation ID middleware
+// fills it, because the context it modifies is not the one passed to the response encoders.
+type correlationIDEcho struct {
+	id string
+}
+
+// MakeHealthCheckHandler make an HTTP handler for an HealthCheck endpoint. The correlation ID
+// and the trace context of the incoming request are put in the context, and the correlation
+// ID is echoed in the response headers. Each request is traced in a server span.
+func MakeHealthCheckHandler(e endpoint.Endpoint, tracer opentracing.Tracer) *http_transport.Server {
 	return http_transport.NewServer(e,
 		decodeHealthCheckRequest,
 		encodeHealthCheckReply,
 		http_transport.ServerErrorEncoder(healthCheckErrorHandler),
+		http_transport.ServerBefore(fetchReportVersion, fetchCorrelationID, makeFetchTraceContext(tracer)),
+		http_transport.ServerFinalizer(finishServerSpan),
 	)
-} 
+}
+
+// fetchCorrelationID puts the correlation ID of the header X-Correlation-ID in the context.
+// The correlation ID middleware generates one if there is none.
+func fetchCorrelationID(ctx context.Context, r *http.Request) context.Context {
+	ctx = context.WithValue(ctx, correlationIDEchoKey{}, &correlationIDEcho{})
+
+	if id := r.Header.Get(correlation.Header); id != "" {
+		ctx = correlation.WithID(ctx, id)
+	}
+	return ctx
+}
+
+// makeFetchTraceContext returns a request function that starts the server span of the
+// request. The span is the child of the trace context propagated in the header
+// uber-trace-id, or in the W3C header traceparent if there is no Jaeger header.
+func makeFetchTraceContext(tracer opentracing.Tracer) http_transport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		var header = r.Header
+		if header.Get(uberTraceIDHeader) == "" {
+			if id, ok := uberTraceID(header.Get(traceparentHeader)); ok {
+				header = http.Header{}
+				header.Set(uberTraceIDHeader, id)
+			}
+		}
+
+		var opts = []opentracing.StartSpanOption{otag.SpanKindRPCServer}
+		if parent, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err == nil {
+			opts = append(opts, opentracing.ChildOf(parent))
+		}
+
+		var span = tracer.StartSpan(r.Method+" "+r.URL.Path, opts...)
+		otag.HTTPMethod.Set(span, r.Method)
+		otag.HTTPUrl.Set(span, r.URL.String())
+		return opentracing.ContextWithSpan(ctx, span)
+	}
+}
+
+// uberTraceID converts the W3C traceparent header to the Jaeger uber-trace-id format
+// {trace-id}:{span-id}:{parent-span-id}:{flags}.
+func uberTraceID(traceparent string) (string, bool) {
+	var m = traceparentRegexp.FindStringSubmatch(traceparent)
+	if m == nil {
+		return "", false
+	}
+
+	var flags, _ = strconv.ParseUint(m[3], 16, 8)
+	return m[1] + ":" + m[2] + ":0:" + strconv.FormatUint(flags&1, 10), true
+}
+
+// finishServerSpan sets the status code of the response on the server span and finishes it.
+func finishServerSpan(ctx context.Context, code int, r *http.Request) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		otag.HTTPStatusCode.Set(span, uint16(code))
+		span.Finish()
+	}
+}
+
+// echoCorrelationID sets the correlation ID of the request in the response headers.
+func echoCorrelationID(ctx context.Context, w http.ResponseWriter) {
+	if echo, ok := ctx.Value(correlationIDEchoKey{}).(*correlationIDEcho); ok && echo.id != "" {
+		w.Header().Set(correlation.Header, echo.id)
+	}
+}
+
+// fetchReportVersion puts the requested report version in the context. The current
+// version is used by default.
+func fetchReportVersion(ctx context.Context, r *http.Request) context.Context {
+	var version, err = strconv.Atoi(r.URL.Query().Get("version"))
+	if err != nil {
+		version = ReportVersion
+	}
+	return context.WithValue(ctx, reportVersionKey{}, version)
+}
 
 // decodeHealthCheckRequest decodes the health check request.
 func decodeHealthCheckRequest(_ context.Context, r *http.Request) (rep interface{}, err error) {
@@ -37,8 +142,13 @@ type healthCheck struct {
 }
 
 // encodeHealthCheckReply encodes the health check reply.
-func encodeHealthCheckReply(_ context.Context, w http.ResponseWriter, rep interface{}) error {
+func encodeHealthCheckReply(ctx context.Context, w http.ResponseWriter, rep interface{}) error {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	echoCorrelationID(ctx, w)
+
+	if jsonRep, ok := rep.(json.RawMessage); ok && ctx.Value(reportVersionKey{}) == LegacyReportVersion {
+		rep = legacyReply(jsonRep)
+	}
 
 	var data, err = json.MarshalIndent(&rep, "", "  ")
 
@@ -55,6 +165,7 @@ func encodeHealthCheckReply(_ context.Context, w http.ResponseWriter, rep interf
 // healthCheckErrorHandler encodes the health check reply when there is an error.
 func healthCheckErrorHandler(ctx context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	echoCorrelationID(ctx, w)
 
 	// Write error.
 	var reply, _ = json.MarshalIndent(map[string]string{"error": err.Error()}, "", "  ")
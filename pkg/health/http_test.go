@@ -10,9 +10,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
 	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
 	"github.com/golang/mock/gomock"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,11 +24,11 @@ func TestInfluxHealthCheckHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewHealthChecker(mockCtrl)
 
-	var h = MakeHealthCheckHandler(MakeExecInfluxHealthCheckEndpoint(mockComponent))
+	var h = MakeHealthCheckHandler(MakeExecInfluxHealthCheckEndpoint(mockComponent), opentracing.NoopTracer{})
 
 	// Health success.
 	var report = json.RawMessage(`[{"Name":"influx","Duration":"1s","Status":"OK"}]`)
-	mockComponent.EXPECT().ExecInfluxHealthChecks(context.Background()).Return(report).Times(1)
+	mockComponent.EXPECT().ExecInfluxHealthChecks(gomock.Any()).Return(report).Times(1)
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/influx", nil)
@@ -57,11 +60,11 @@ func TestJaegerHealthCheckHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewHealthChecker(mockCtrl)
 
-	var h = MakeHealthCheckHandler(MakeExecJaegerHealthCheckEndpoint(mockComponent))
+	var h = MakeHealthCheckHandler(MakeExecJaegerHealthCheckEndpoint(mockComponent), opentracing.NoopTracer{})
 
 	// Health success.
 	var report = json.RawMessage(`[{"Name":"jaeger","Duration":"1s","Status":"OK"}]`)
-	mockComponent.EXPECT().ExecJaegerHealthChecks(context.Background()).Return(report).Times(1)
+	mockComponent.EXPECT().ExecJaegerHealthChecks(gomock.Any()).Return(report).Times(1)
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/jaeger", nil)
@@ -92,11 +95,11 @@ func TestRedisHealthCheckHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewHealthChecker(mockCtrl)
 
-	var h = MakeHealthCheckHandler(MakeExecRedisHealthCheckEndpoint(mockComponent))
+	var h = MakeHealthCheckHandler(MakeExecRedisHealthCheckEndpoint(mockComponent), opentracing.NoopTracer{})
 
 	// Health success.
 	var report = json.RawMessage(`[{"Name":"redis","Duration":"1s","Status":"OK","Error":"Error occured"}]`)
-	mockComponent.EXPECT().ExecRedisHealthChecks(context.Background()).Return(report).Times(1)
+	mockComponent.EXPECT().ExecRedisHealthChecks(gomock.Any()).Return(report).Times(1)
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil)
@@ -127,11 +130,11 @@ func TestSentryHealthCheckHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewHealthChecker(mockCtrl)
 
-	var h = MakeHealthCheckHandler(MakeExecSentryHealthCheckEndpoint(mockComponent))
+	var h = MakeHealthCheckHandler(MakeExecSentryHealthCheckEndpoint(mockComponent), opentracing.NoopTracer{})
 
 	// Health success.
 	var report = json.RawMessage(`[{"Name":"sentry","Duration":"1s","Status":"OK","Error":"Unexpected error"}]`)
-	mockComponent.EXPECT().ExecSentryHealthChecks(context.Background()).Return(report).Times(1)
+	mockComponent.EXPECT().ExecSentryHealthChecks(gomock.Any()).Return(report).Times(1)
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/sentry", nil)
@@ -162,11 +165,11 @@ func TestHealthChecksHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewHealthChecker(mockCtrl)
 
-	var h = MakeHealthCheckHandler(MakeAllHealthChecksEndpoint(mockComponent))
+	var h = MakeHealthCheckHandler(MakeAllHealthChecksEndpoint(mockComponent), opentracing.NoopTracer{})
 
 	// Health success.
 	var report = json.RawMessage(`{"influx":[{"Name":"sentry","Duration":"1s","Status":"OK","Error":""}], "redis":[{"Name":"redis","Duration":"1s","Status":"OK","Error":""}]}`)
-	mockComponent.EXPECT().AllHealthChecks(context.Background()).Return(report).Times(1)
+	mockComponent.EXPECT().AllHealthChecks(gomock.Any()).Return(report).Times(1)
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health", nil)
@@ -198,11 +201,11 @@ func TestHealthChecksHandlerFail(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockComponent = mock.NewHealthChecker(mockCtrl)
 
-	var h = MakeHealthCheckHandler(MakeAllHealthChecksEndpoint(mockComponent))
+	var h = MakeHealthCheckHandler(MakeAllHealthChecksEndpoint(mockComponent), opentracing.NoopTracer{})
 
 	// Health success.
 	var report = json.RawMessage(`{"influx":[{"Name":"sentry","Duration":"1s","Status":"Deactivated","Error":""}], "redis":[{"Name":"redis","Duration":"1s","Status":"KO","Error":"Unexpected error"}]}`)
-	mockComponent.EXPECT().AllHealthChecks(context.Background()).Return(report).Times(1)
+	mockComponent.EXPECT().AllHealthChecks(gomock.Any()).Return(report).Times(1)
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health", nil)
@@ -238,12 +241,67 @@ func TestHealthChecksHandlerFail(t *testing.T) {
 	}
 }
 
+func TestHealthChecksHandlerLegacyVersion(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var h = MakeHealthCheckHandler(MakeAllHealthChecksEndpoint(mockComponent), opentracing.NoopTracer{})
+
+	var report = json.RawMessage(`{
+		"influx":{"version":2,"unit":"influx","status":"OK","stale":false,"reports":[{"name":"ping","status":"OK"}]},
+		"redis":{"version":2,"unit":"redis","status":"Unknown","stale":true,"error":"stale","reports":[{"name":"ping","status":"OK"}]},
+		"sentry":{"version":2,"unit":"sentry","status":"Unknown","stale":false,"error":"could not read \"health\"","reports":[]}
+	}`)
+	mockComponent.EXPECT().AllHealthChecks(gomock.Any()).Return(report).Times(2)
+
+	// Current version.
+	{
+		var w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "http://cloudtrust.io/health", nil))
+
+		var m map[string]Report
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &m))
+		assert.Equal(t, "Unknown", m["sentry"].Status)
+	}
+
+	// Legacy version.
+	{
+		var w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "http://cloudtrust.io/health?version=1", nil))
+
+		var m map[string]interface{}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &m))
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "ping", "status": "OK"}}, m["influx"])
+		assert.Equal(t, map[string]interface{}{"Name": "redis", "Error": "stale"}, m["redis"])
+		assert.Equal(t, map[string]interface{}{"Name": "sentry", "Status": "Unknown", "Error": `could not read "health"`}, m["sentry"])
+	}
+}
+
+func TestHealthCheckHandlerLegacyVersion(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var h = MakeHealthCheckHandler(MakeExecRedisHealthCheckEndpoint(mockComponent), opentracing.NoopTracer{})
+
+	var report = json.RawMessage(`{"version":2,"unit":"redis","status":"OK","stale":false,"reports":[{"name":"ping","status":"OK"}]}`)
+	mockComponent.EXPECT().ExecRedisHealthChecks(gomock.Any()).Return(report).Times(1)
+
+	var w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "http://cloudtrust.io/health/redis?version=1", nil))
+
+	var m []map[string]string
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, []map[string]string{{"name": "ping", "status": "OK"}}, m)
+}
+
 func TestHTTPErrorHandler(t *testing.T) {
 	var e = func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		return nil, fmt.Errorf("fail")
 	}
 
-	var h = MakeHealthCheckHandler(e)
+	var h = MakeHealthCheckHandler(e, opentracing.NoopTracer{})
 
 	// HTTP request.
 	var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/sentry", nil)
@@ -264,3 +322,126 @@ func TestHTTPErrorHandler(t *testing.T) {
 		assert.Equal(t, "fail", m["error"])
 	}
 }
+
+func TestHealthCheckHandlerCorrelationID(t *testing.T) {
+	var tracer = opentracing.NoopTracer{}
+	var corrID string
+	var e = func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		corrID, _ = correlation.ID(ctx)
+		return json.RawMessage(`[]`), nil
+	}
+
+	var h = MakeHealthCheckHandler(MakeEndpointCorrelationIDMW(staticIDGenerator("456"), tracer)(e), tracer)
+
+	// Correlation ID in the request.
+	{
+		var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil)
+		req.Header.Set("X-Correlation-ID", "123")
+		var w = httptest.NewRecorder()
+
+		h.ServeHTTP(w, req)
+		assert.Equal(t, "123", corrID)
+		assert.Equal(t, "123", w.Result().Header.Get("X-Correlation-ID"))
+	}
+
+	// Without correlation ID, the generated one is echoed.
+	{
+		var w = httptest.NewRecorder()
+
+		h.ServeHTTP(w, httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil))
+		assert.Equal(t, "456", corrID)
+		assert.Equal(t, corrID, w.Result().Header.Get("X-Correlation-ID"))
+	}
+
+	// The correlation ID is echoed on errors.
+	{
+		var e = func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			return nil, fmt.Errorf("fail")
+		}
+		var h = MakeHealthCheckHandler(MakeEndpointCorrelationIDMW(staticIDGenerator("456"), tracer)(e), tracer)
+
+		var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil)
+		req.Header.Set("X-Correlation-ID", "123")
+		var w = httptest.NewRecorder()
+
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "123", w.Result().Header.Get("X-Correlation-ID"))
+	}
+}
+
+func TestHealthCheckHandlerTraceContext(t *testing.T) {
+	var tracer = mocktracer.New()
+	var parent = tracer.StartSpan("parent").(*mocktracer.MockSpan)
+
+	var span *mocktracer.MockSpan
+	var e = func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		span, _ = opentracing.SpanFromContext(ctx).(*mocktracer.MockSpan)
+		return json.RawMessage(`[]`), nil
+	}
+	var h = MakeHealthCheckHandler(e, tracer)
+
+	// Propagated trace context.
+	{
+		var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil)
+		tracer.Inject(parent.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, "GET /health/redis", span.OperationName)
+		assert.Equal(t, parent.SpanContext.TraceID, span.SpanContext.TraceID)
+		assert.Equal(t, parent.SpanContext.SpanID, span.ParentID)
+		assert.Equal(t, "server", fmt.Sprint(span.Tag("span.kind")))
+		assert.Equal(t, uint16(http.StatusOK), span.Tag("http.status_code"))
+		assert.False(t, span.FinishTime.IsZero())
+	}
+
+	// Without trace context, a new trace is started.
+	{
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil))
+		assert.NotEqual(t, parent.SpanContext.TraceID, span.SpanContext.TraceID)
+		assert.Zero(t, span.ParentID)
+	}
+}
+
+func TestUberTraceIDFromTraceparent(t *testing.T) {
+	var tracer = &headerRecordingTracer{}
+	var h = MakeHealthCheckHandler(func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return json.RawMessage(`[]`), nil
+	}, tracer)
+
+	var serve = func(header map[string]string) string {
+		var req = httptest.NewRequest("GET", "http://cloudtrust.io/health/redis", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		return tracer.header.Get("uber-trace-id")
+	}
+
+	// Sampled.
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", serve(map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}))
+	// Not sampled.
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:0", serve(map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}))
+	// Invalid.
+	assert.Equal(t, "", serve(map[string]string{"traceparent": "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}))
+	// The Jaeger header wins.
+	assert.Equal(t, "1:2:0:1", serve(map[string]string{"uber-trace-id": "1:2:0:1", "traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}))
+}
+
+// staticIDGenerator always returns the same ID.
+type staticIDGenerator string
+
+func (g staticIDGenerator) NextValidID(context.Context) string {
+	return string(g)
+}
+
+// headerRecordingTracer records the headers from which the trace context is extracted.
+type headerRecordingTracer struct {
+	opentracing.NoopTracer
+	header http.Header
+}
+
+func (t *headerRecordingTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	t.header = http.Header(carrier.(opentracing.HTTPHeadersCarrier))
+	return nil, opentracing.ErrSpanContextNotFound
+}
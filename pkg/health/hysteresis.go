@@ -0,0 +1,188 @@
+package health
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// HysteresisConfig is the hysteresis configuration of a unit.
+type HysteresisConfig struct {
+	// ConsecutiveResults is the number of consecutive results in a new status
+	// needed before the new status is published.
+	ConsecutiveResults int
+	// MinDuration is the duration a new status must last before it is published.
+	MinDuration time.Duration
+	// FlapWindow and FlapThreshold: a unit whose status changed at least
+	// FlapThreshold times in the last FlapWindow is flapping. A threshold of 0
+	// disables the flap detection.
+	FlapWindow    time.Duration
+	FlapThreshold int
+}
+
+// HysteresisModule sits between the health checks and the storage module. It only
+// publishes a status change when the new status is confirmed, and flags the units
+// that are flapping.
+type HysteresisModule struct {
+	storage StoreModule
+	configs map[string]HysteresisConfig
+	states  map[string]*unitState
+	mutex   *sync.Mutex
+}
+
+// unitState is the hysteresis state of a unit.
+type unitState struct {
+	published        Status
+	publishedReports json.RawMessage
+	last             Status
+	candidate        Status
+	candidateCount   int
+	candidateSince   time.Time
+	changes          []time.Time
+}
+
+// NewHysteresisModule returns the hysteresis module.
+func NewHysteresisModule(storage StoreModule, configs map[string]HysteresisConfig) *HysteresisModule {
+	return &HysteresisModule{
+		storage: storage,
+		configs: configs,
+		states:  map[string]*unitState{},
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// Update applies the hysteresis to the health checks reports 'jsonReports' and
+// stores the published reports.
+func (m *HysteresisModule) Update(unit string, validity time.Duration, jsonReports json.RawMessage) error {
+	m.mutex.Lock()
+	var reports, flapping = m.apply(unit, jsonReports)
+	m.mutex.Unlock()
+
+	if flapping {
+		reports = flagFlapping(reports)
+	}
+
+	return m.storage.Update(unit, validity, reports)
+}
+
+// Read reads the reports in DB.
+func (m *HysteresisModule) Read(unit string) (StoredReport, error) {
+	return m.storage.Read(unit)
+}
+
+// ReadAll reads the reports of all the instances in DB.
+func (m *HysteresisModule) ReadAll() ([]StoredReport, error) {
+	return m.storage.ReadAll()
+}
+
+// Clean deletes the old test reports that are no longer valid.
+func (m *HysteresisModule) Clean() error {
+	return m.storage.Clean()
+}
+
+// apply updates the state of the unit with the new reports. It returns the reports
+// to publish and whether the unit is flapping.
+func (m *HysteresisModule) apply(unit string, jsonReports json.RawMessage) (json.RawMessage, bool) {
+	var config = m.configs[unit]
+	var now = time.Now()
+	var s = unitStatus(jsonReports)
+
+	var state, ok = m.states[unit]
+	if !ok {
+		state = &unitState{
+			published:        s,
+			publishedReports: jsonReports,
+			last:             s,
+		}
+		m.states[unit] = state
+	}
+
+	// Flap detection.
+	if s != state.last {
+		state.changes = append(state.changes, now)
+		state.last = s
+	}
+	var changes = state.changes[:0]
+	for _, t := range state.changes {
+		if now.Sub(t) <= config.FlapWindow {
+			changes = append(changes, t)
+		}
+	}
+	state.changes = changes
+	var flapping = config.FlapThreshold > 0 && len(state.changes) >= config.FlapThreshold
+
+	// Hysteresis.
+	switch {
+	case s == state.published:
+		state.publishedReports = jsonReports
+		state.candidateCount = 0
+	case state.candidateCount == 0 || s != state.candidate:
+		state.candidate = s
+		state.candidateCount = 1
+		state.candidateSince = now
+	default:
+		state.candidateCount++
+	}
+
+	if s != state.published && confirmed(config, state.candidateCount, now.Sub(state.candidateSince)) {
+		state.published = s
+		state.publishedReports = jsonReports
+		state.candidateCount = 0
+	}
+
+	return state.publishedReports, flapping
+}
+
+// confirmed returns true if a new status seen 'count' times during 'duration' can be published.
+func confirmed(config HysteresisConfig, count int, duration time.Duration) bool {
+	switch {
+	case config.ConsecutiveResults <= 1 && config.MinDuration <= 0:
+		return true
+	case config.ConsecutiveResults > 0 && count >= config.ConsecutiveResults:
+		return true
+	case config.MinDuration > 0 && duration >= config.MinDuration:
+		return true
+	default:
+		return false
+	}
+}
+
+// statusSeverity orders the status from the least to the most severe.
+var statusSeverity = map[Status]int{Deactivated: 0, OK: 1, Unknown: 2, Degraded: 3, KO: 4}
+
+// unitStatus returns the most severe status of the health checks reports.
+func unitStatus(jsonReports json.RawMessage) Status {
+	var reports []struct {
+		Status string `json:"status"`
+	}
+
+	var err = json.Unmarshal(jsonReports, &reports)
+	if err != nil || len(reports) == 0 {
+		return Unknown
+	}
+
+	var s = Deactivated
+	for _, r := range reports {
+		if rs := status(r.Status); statusSeverity[rs] > statusSeverity[s] {
+			s = rs
+		}
+	}
+	return s
+}
+
+// flagFlapping adds the flapping flag to each health check report.
+func flagFlapping(jsonReports json.RawMessage) json.RawMessage {
+	var reports []map[string]json.RawMessage
+
+	var err = json.Unmarshal(jsonReports, &reports)
+	if err != nil {
+		return jsonReports
+	}
+
+	for _, r := range reports {
+		r["flapping"] = json.RawMessage(`true`)
+	}
+
+	var flagged, _ = json.Marshal(reports)
+	return flagged
+}
@@ -0,0 +1,117 @@
+package health_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	okReports       = json.RawMessage(`[{"name":"Health","status":"OK"},{"name":"Index API","status":"OK"}]`)
+	degradedReports = json.RawMessage(`[{"name":"Health","status":"Degraded"},{"name":"Index API","status":"OK"}]`)
+)
+
+func TestHysteresisWithoutConfig(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = NewHysteresisModule(mockStorage, map[string]HysteresisConfig{})
+
+	// Status changes are published immediately.
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, okReports).Return(nil).Times(1)
+	assert.Nil(t, m.Update("elasticsearch", time.Minute, okReports))
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, degradedReports).Return(nil).Times(1)
+	assert.Nil(t, m.Update("elasticsearch", time.Minute, degradedReports))
+}
+
+func TestHysteresisConsecutiveResults(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = NewHysteresisModule(mockStorage, map[string]HysteresisConfig{
+		"elasticsearch": {ConsecutiveResults: 3},
+	})
+
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, okReports).Return(nil).Times(4)
+	m.Update("elasticsearch", time.Minute, okReports)
+
+	// The new status is interrupted before being confirmed.
+	m.Update("elasticsearch", time.Minute, degradedReports)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+	m.Update("elasticsearch", time.Minute, okReports)
+
+	// The new status is published after 3 consecutive results.
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, okReports).Return(nil).Times(2)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, degradedReports).Return(nil).Times(1)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+}
+
+func TestHysteresisMinDuration(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = NewHysteresisModule(mockStorage, map[string]HysteresisConfig{
+		"elasticsearch": {MinDuration: 50 * time.Millisecond},
+	})
+
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, okReports).Return(nil).Times(2)
+	m.Update("elasticsearch", time.Minute, okReports)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+
+	time.Sleep(60 * time.Millisecond)
+
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, degradedReports).Return(nil).Times(1)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+}
+
+func TestHysteresisFlapping(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = NewHysteresisModule(mockStorage, map[string]HysteresisConfig{
+		"elasticsearch": {ConsecutiveResults: 10, FlapWindow: time.Minute, FlapThreshold: 2},
+	})
+
+	var flaggedReports = json.RawMessage(`[{"flapping":true,"name":"Health","status":"OK"},{"flapping":true,"name":"Index API","status":"OK"}]`)
+
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, okReports).Return(nil).Times(2)
+	m.Update("elasticsearch", time.Minute, okReports)
+	m.Update("elasticsearch", time.Minute, degradedReports)
+
+	// Second status change in the window: the unit is flapping.
+	mockStorage.EXPECT().Update("elasticsearch", time.Minute, flaggedReports).Return(nil).Times(1)
+	m.Update("elasticsearch", time.Minute, okReports)
+}
+
+func TestHysteresisStorage(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = NewHysteresisModule(mockStorage, map[string]HysteresisConfig{})
+
+	var storedReport = StoredReport{HealthcheckUnit: "elasticsearch", Reports: okReports}
+	mockStorage.EXPECT().Read("elasticsearch").Return(storedReport, nil).Times(1)
+	var r, err = m.Read("elasticsearch")
+	assert.Nil(t, err)
+	assert.Equal(t, storedReport, r)
+
+	mockStorage.EXPECT().ReadAll().Return([]StoredReport{storedReport}, nil).Times(1)
+	var rs, errAll = m.ReadAll()
+	assert.Nil(t, errAll)
+	assert.Equal(t, []StoredReport{storedReport}, rs)
+
+	mockStorage.EXPECT().Clean().Return(nil).Times(1)
+	assert.Nil(t, m.Clean())
+}
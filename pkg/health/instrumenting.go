@@ -0,0 +1,339 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+)
+
+// MakeEndpointInstrumentingMW makes an instrumenting middleware that counts the calls of
+// the endpoint and records their durations.
+func MakeEndpointInstrumentingMW(counter metrics.Counter, histogram metrics.Histogram) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			defer func(begin time.Time) {
+				counter.Add(1)
+				histogram.Observe(time.Since(begin).Seconds())
+			}(time.Now())
+
+			return next(ctx, req)
+		}
+	}
+}
+
+// Instrumenting middleware at component level.
+type componentInstrumentingMW struct {
+	counter   metrics.Counter
+	histogram metrics.Histogram
+	next      HealthChecker
+}
+
+// MakeComponentInstrumentingMW makes an instrumenting middleware at component level. The
+// calls and durations are labeled by unit, the name of the method.
+func MakeComponentInstrumentingMW(counter metrics.Counter, histogram metrics.Histogram) func(HealthChecker) HealthChecker {
+	return func(next HealthChecker) HealthChecker {
+		return &componentInstrumentingMW{
+			counter:   counter,
+			histogram: histogram,
+			next:      next,
+		}
+	}
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecInfluxHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecInfluxHealthChecks", time.Now())
+	return m.next.ExecInfluxHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadInfluxHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadInfluxHealthChecks", time.Now())
+	return m.next.ReadInfluxHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecJaegerHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecJaegerHealthChecks", time.Now())
+	return m.next.ExecJaegerHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadJaegerHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadJaegerHealthChecks", time.Now())
+	return m.next.ReadJaegerHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecRedisHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecRedisHealthChecks", time.Now())
+	return m.next.ExecRedisHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadRedisHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadRedisHealthChecks", time.Now())
+	return m.next.ReadRedisHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecSentryHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecSentryHealthChecks", time.Now())
+	return m.next.ExecSentryHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadSentryHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadSentryHealthChecks", time.Now())
+	return m.next.ReadSentryHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecFlakiHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecFlakiHealthChecks", time.Now())
+	return m.next.ExecFlakiHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadFlakiHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadFlakiHealthChecks", time.Now())
+	return m.next.ReadFlakiHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecElasticsearchHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecElasticsearchHealthChecks", time.Now())
+	return m.next.ExecElasticsearchHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadElasticsearchHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadElasticsearchHealthChecks", time.Now())
+	return m.next.ReadElasticsearchHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ExecCockroachHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ExecCockroachHealthChecks", time.Now())
+	return m.next.ExecCockroachHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) ReadCockroachHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("ReadCockroachHealthChecks", time.Now())
+	return m.next.ReadCockroachHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) AllHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("AllHealthChecks", time.Now())
+	return m.next.AllHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) DeepHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("DeepHealthChecks", time.Now())
+	return m.next.DeepHealthChecks(ctx)
+}
+
+// componentInstrumentingMW implements Component.
+func (m *componentInstrumentingMW) FleetHealthChecks(ctx context.Context) json.RawMessage {
+	defer m.observe("FleetHealthChecks", time.Now())
+	return m.next.FleetHealthChecks(ctx)
+}
+
+func (m *componentInstrumentingMW) observe(unit string, begin time.Time) {
+	m.counter.With("unit", unit).Add(1)
+	m.histogram.With("unit", unit).Observe(time.Since(begin).Seconds())
+}
+
+// moduleInstrumenting records the duration of the health checks of a unit, and sets the
+// gauge of the unit to its status, the most severe status of its health checks.
+type moduleInstrumenting struct {
+	unit      string
+	histogram metrics.Histogram
+	gauge     metrics.Gauge
+}
+
+func (m *moduleInstrumenting) observe(begin time.Time, reports interface{}) {
+	m.histogram.With("unit", m.unit).Observe(time.Since(begin).Seconds())
+
+	var jsonReports, err = json.Marshal(reports)
+	var s = Unknown
+	if err == nil {
+		s = unitStatus(jsonReports)
+	}
+	m.gauge.With("unit", m.unit).Set(float64(s))
+}
+
+// Instrumenting middleware at module level.
+type influxModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next InfluxHealthChecker
+}
+
+// MakeInfluxModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeInfluxModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(InfluxHealthChecker) InfluxHealthChecker {
+	return func(next InfluxHealthChecker) InfluxHealthChecker {
+		return &influxModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: influxUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// influxModuleInstrumentingMW implements Module.
+func (m *influxModuleInstrumentingMW) HealthChecks(ctx context.Context) []common.InfluxReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
+
+// Instrumenting middleware at module level.
+type jaegerModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next JaegerHealthChecker
+}
+
+// MakeJaegerModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeJaegerModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(JaegerHealthChecker) JaegerHealthChecker {
+	return func(next JaegerHealthChecker) JaegerHealthChecker {
+		return &jaegerModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: jaegerUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// jaegerModuleInstrumentingMW implements Module.
+func (m *jaegerModuleInstrumentingMW) HealthChecks(ctx context.Context) []common.JaegerReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
+
+// Instrumenting middleware at module level.
+type redisModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next RedisHealthChecker
+}
+
+// MakeRedisModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeRedisModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(RedisHealthChecker) RedisHealthChecker {
+	return func(next RedisHealthChecker) RedisHealthChecker {
+		return &redisModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: redisUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// redisModuleInstrumentingMW implements Module.
+func (m *redisModuleInstrumentingMW) HealthChecks(ctx context.Context) []common.RedisReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
+
+// Instrumenting middleware at module level.
+type sentryModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next SentryHealthChecker
+}
+
+// MakeSentryModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeSentryModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(SentryHealthChecker) SentryHealthChecker {
+	return func(next SentryHealthChecker) SentryHealthChecker {
+		return &sentryModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: sentryUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// sentryModuleInstrumentingMW implements Module.
+func (m *sentryModuleInstrumentingMW) HealthChecks(ctx context.Context) []common.SentryReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
+
+// Instrumenting middleware at module level.
+type flakiModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next FlakiHealthChecker
+}
+
+// MakeFlakiModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeFlakiModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(FlakiHealthChecker) FlakiHealthChecker {
+	return func(next FlakiHealthChecker) FlakiHealthChecker {
+		return &flakiModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: flakiUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// flakiModuleInstrumentingMW implements Module.
+func (m *flakiModuleInstrumentingMW) HealthChecks(ctx context.Context) []common.FlakiReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
+
+// Instrumenting middleware at module level.
+type elasticsearchModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next ElasticsearchHealthChecker
+}
+
+// MakeElasticsearchModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeElasticsearchModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(ElasticsearchHealthChecker) ElasticsearchHealthChecker {
+	return func(next ElasticsearchHealthChecker) ElasticsearchHealthChecker {
+		return &elasticsearchModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: elasticsearchUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// elasticsearchModuleInstrumentingMW implements Module.
+func (m *elasticsearchModuleInstrumentingMW) HealthChecks(ctx context.Context) []ElasticsearchReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
+
+// Instrumenting middleware at module level.
+type cockroachModuleInstrumentingMW struct {
+	moduleInstrumenting
+	next CockroachHealthChecker
+}
+
+// MakeCockroachModuleInstrumentingMW makes an instrumenting middleware at module level.
+func MakeCockroachModuleInstrumentingMW(histogram metrics.Histogram, gauge metrics.Gauge) func(CockroachHealthChecker) CockroachHealthChecker {
+	return func(next CockroachHealthChecker) CockroachHealthChecker {
+		return &cockroachModuleInstrumentingMW{
+			moduleInstrumenting: moduleInstrumenting{unit: cockroachUnitName, histogram: histogram, gauge: gauge},
+			next:                next,
+		}
+	}
+}
+
+// cockroachModuleInstrumentingMW implements Module.
+func (m *cockroachModuleInstrumentingMW) HealthChecks(ctx context.Context) []CockroachReport {
+	var begin = time.Now()
+	var reports = m.next.HealthChecks(ctx)
+	m.observe(begin, reports)
+	return reports
+}
@@ -0,0 +1,126 @@
+package health_test
+
+//go:generate mockgen -destination=./mock/instrumenting.go -package=mock -mock_names=Counter=Counter,Gauge=Gauge,Histogram=Histogram github.com/go-kit/kit/metrics Counter,Gauge,Histogram
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCounter = mock.NewCounter(mockCtrl)
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var m = MakeEndpointInstrumentingMW(mockCounter, mockHistogram)(MakeExecInfluxHealthCheckEndpoint(mockComponent))
+
+	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
+
+	mockComponent.EXPECT().ExecInfluxHealthChecks(context.Background()).Return(rep).Times(1)
+	mockCounter.EXPECT().Add(1.0).Times(1)
+	mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+	var r, err = m(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, rep, r)
+}
+
+func TestComponentInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCounter = mock.NewCounter(mockCtrl)
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+	var mockComponent = mock.NewHealthChecker(mockCtrl)
+
+	var m = MakeComponentInstrumentingMW(mockCounter, mockHistogram)(mockComponent)
+
+	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
+
+	var expectObserve = func(unit string) {
+		mockCounter.EXPECT().With("unit", unit).Return(mockCounter).Times(1)
+		mockCounter.EXPECT().Add(1.0).Times(1)
+		mockHistogram.EXPECT().With("unit", unit).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+	}
+
+	// Exec and read.
+	{
+		mockComponent.EXPECT().ExecRedisHealthChecks(context.Background()).Return(rep).Times(1)
+		expectObserve("ExecRedisHealthChecks")
+		assert.Equal(t, rep, m.ExecRedisHealthChecks(context.Background()))
+
+		mockComponent.EXPECT().ReadRedisHealthChecks(context.Background()).Return(rep).Times(1)
+		expectObserve("ReadRedisHealthChecks")
+		assert.Equal(t, rep, m.ReadRedisHealthChecks(context.Background()))
+	}
+
+	// All, deep and fleet.
+	{
+		mockComponent.EXPECT().AllHealthChecks(context.Background()).Return(rep).Times(1)
+		expectObserve("AllHealthChecks")
+		assert.Equal(t, rep, m.AllHealthChecks(context.Background()))
+
+		mockComponent.EXPECT().DeepHealthChecks(context.Background()).Return(rep).Times(1)
+		expectObserve("DeepHealthChecks")
+		assert.Equal(t, rep, m.DeepHealthChecks(context.Background()))
+
+		mockComponent.EXPECT().FleetHealthChecks(context.Background()).Return(rep).Times(1)
+		expectObserve("FleetHealthChecks")
+		assert.Equal(t, rep, m.FleetHealthChecks(context.Background()))
+	}
+}
+
+func TestModuleInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+	var mockGauge = mock.NewGauge(mockCtrl)
+	var mockInfluxModule = mock.NewInfluxHealthChecker(mockCtrl)
+	var mockCockroachModule = mock.NewCockroachHealthChecker(mockCtrl)
+
+	// The gauge is set to the most severe status of the unit.
+	{
+		var m = MakeInfluxModuleInstrumentingMW(mockHistogram, mockGauge)(mockInfluxModule)
+		var reports = []common.InfluxReport{{Name: "ping", Status: common.OK}, {Name: "write", Status: common.KO}}
+
+		mockInfluxModule.EXPECT().HealthChecks(context.Background()).Return(reports).Times(1)
+		mockHistogram.EXPECT().With("unit", "influx").Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+		mockGauge.EXPECT().With("unit", "influx").Return(mockGauge).Times(1)
+		mockGauge.EXPECT().Set(float64(KO)).Times(1)
+		assert.Equal(t, reports, m.HealthChecks(context.Background()))
+	}
+
+	// Deactivated unit.
+	{
+		var m = MakeCockroachModuleInstrumentingMW(mockHistogram, mockGauge)(mockCockroachModule)
+		var reports = []CockroachReport{{Name: "cockroach", Status: Deactivated}}
+
+		mockCockroachModule.EXPECT().HealthChecks(context.Background()).Return(reports).Times(1)
+		mockHistogram.EXPECT().With("unit", "cockroach").Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+		mockGauge.EXPECT().With("unit", "cockroach").Return(mockGauge).Times(1)
+		mockGauge.EXPECT().Set(float64(Deactivated)).Times(1)
+		assert.Equal(t, reports, m.HealthChecks(context.Background()))
+	}
+
+	// No report.
+	{
+		var m = MakeCockroachModuleInstrumentingMW(mockHistogram, mockGauge)(mockCockroachModule)
+
+		mockCockroachModule.EXPECT().HealthChecks(context.Background()).Return([]CockroachReport{}).Times(1)
+		mockHistogram.EXPECT().With("unit", "cockroach").Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+		mockGauge.EXPECT().With("unit", "cockroach").Return(mockGauge).Times(1)
+		mockGauge.EXPECT().Set(float64(Unknown)).Times(1)
+		m.HealthChecks(context.Background())
+	}
+}
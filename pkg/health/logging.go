@@ -5,16 +5,28 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 )
 
+// correlationID returns the correlation ID of the context. The correlation ID middleware
+// puts one in the context of each request, so it panics if there is none.
+func correlationID(ctx context.Context) string {
+	var id, ok = correlation.ID(ctx)
+	if !ok {
+		panic("no correlation ID in the context")
+	}
+	return id
+}
+
 // MakeEndpointLoggingMW makes a logging middleware.
 func MakeEndpointLoggingMW(logger log.Logger) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
 			defer func(begin time.Time) {
-				logger.Log("correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+				level.Info(logger).Log("correlation_id", correlationID(ctx), "took", time.Since(begin))
 			}(time.Now())
 
 			return next(ctx, req)
@@ -41,7 +53,7 @@ func MakeComponentLoggingMW(logger log.Logger) func(HealthChecker) HealthChecker
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecInfluxHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecInfluxHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ExecInfluxHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecInfluxHealthChecks(ctx)
@@ -50,7 +62,7 @@ func (m *componentLoggingMW) ExecInfluxHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadInfluxHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadInfluxHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ReadInfluxHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadInfluxHealthChecks(ctx)
@@ -59,7 +71,7 @@ func (m *componentLoggingMW) ReadInfluxHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecJaegerHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecJaegerHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ExecJaegerHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecJaegerHealthChecks(ctx)
@@ -68,7 +80,7 @@ func (m *componentLoggingMW) ExecJaegerHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadJaegerHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadJaegerHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ReadJaegerHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadJaegerHealthChecks(ctx)
@@ -77,7 +89,7 @@ func (m *componentLoggingMW) ReadJaegerHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecRedisHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecRedisHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ExecRedisHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecRedisHealthChecks(ctx)
@@ -86,7 +98,7 @@ func (m *componentLoggingMW) ExecRedisHealthChecks(ctx context.Context) json.Raw
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadRedisHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadRedisHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ReadRedisHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadRedisHealthChecks(ctx)
@@ -95,7 +107,7 @@ func (m *componentLoggingMW) ReadRedisHealthChecks(ctx context.Context) json.Raw
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecSentryHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecSentryHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ExecSentryHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecSentryHealthChecks(ctx)
@@ -104,7 +116,7 @@ func (m *componentLoggingMW) ExecSentryHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadSentryHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadSentryHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ReadSentryHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadSentryHealthChecks(ctx)
@@ -113,7 +125,7 @@ func (m *componentLoggingMW) ReadSentryHealthChecks(ctx context.Context) json.Ra
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecFlakiHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecFlakiHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ExecFlakiHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecFlakiHealthChecks(ctx)
@@ -122,7 +134,7 @@ func (m *componentLoggingMW) ExecFlakiHealthChecks(ctx context.Context) json.Raw
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadFlakiHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadFlakiHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ReadFlakiHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadFlakiHealthChecks(ctx)
@@ -131,7 +143,7 @@ func (m *componentLoggingMW) ReadFlakiHealthChecks(ctx context.Context) json.Raw
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ExecElasticsearchHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ExecElasticsearchHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ExecElasticsearchHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ExecElasticsearchHealthChecks(ctx)
@@ -140,17 +152,53 @@ func (m *componentLoggingMW) ExecElasticsearchHealthChecks(ctx context.Context)
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) ReadElasticsearchHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "ReadElasticsearchHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "ReadElasticsearchHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.ReadElasticsearchHealthChecks(ctx)
 }
 
+// componentLoggingMW implements Component.
+func (m *componentLoggingMW) ExecCockroachHealthChecks(ctx context.Context) json.RawMessage {
+	defer func(begin time.Time) {
+		level.Debug(m.logger).Log("unit", "ExecCockroachHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
+	}(time.Now())
+
+	return m.next.ExecCockroachHealthChecks(ctx)
+}
+
+// componentLoggingMW implements Component.
+func (m *componentLoggingMW) ReadCockroachHealthChecks(ctx context.Context) json.RawMessage {
+	defer func(begin time.Time) {
+		level.Debug(m.logger).Log("unit", "ReadCockroachHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
+	}(time.Now())
+
+	return m.next.ReadCockroachHealthChecks(ctx)
+}
+
 // componentLoggingMW implements Component.
 func (m *componentLoggingMW) AllHealthChecks(ctx context.Context) json.RawMessage {
 	defer func(begin time.Time) {
-		m.logger.Log("unit", "AllHealthChecks", "correlation_id", ctx.Value("correlation_id").(string), "took", time.Since(begin))
+		level.Debug(m.logger).Log("unit", "AllHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
 	}(time.Now())
 
 	return m.next.AllHealthChecks(ctx)
 }
+
+// componentLoggingMW implements Component.
+func (m *componentLoggingMW) DeepHealthChecks(ctx context.Context) json.RawMessage {
+	defer func(begin time.Time) {
+		level.Debug(m.logger).Log("unit", "DeepHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
+	}(time.Now())
+
+	return m.next.DeepHealthChecks(ctx)
+}
+
+// componentLoggingMW implements Component.
+func (m *componentLoggingMW) FleetHealthChecks(ctx context.Context) json.RawMessage {
+	defer func(begin time.Time) {
+		level.Debug(m.logger).Log("unit", "FleetHealthChecks", "correlation_id", correlationID(ctx), "took", time.Since(begin))
+	}(time.Now())
+
+	return m.next.FleetHealthChecks(ctx)
+}
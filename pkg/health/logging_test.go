@@ -10,8 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
 	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/go-kit/kit/log/level"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,11 +29,11 @@ func TestEndpointLoggingMW(t *testing.T) {
 	// Context with correlation ID.
 	rand.Seed(time.Now().UnixNano())
 	var corrID = strconv.FormatUint(rand.Uint64(), 10)
-	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+	var ctx = correlation.WithID(context.Background(), corrID)
 	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
 
 	// With correlation ID.
-	mockLogger.EXPECT().Log("correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+	mockLogger.EXPECT().Log("level", level.InfoValue(), "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 	mockComponent.EXPECT().ExecInfluxHealthChecks(ctx).Return(rep).Times(1)
 	m(ctx, nil)
 
@@ -53,17 +55,17 @@ func TestComponentLoggingMW(t *testing.T) {
 
 	rand.Seed(time.Now().UnixNano())
 	var corrID = strconv.FormatUint(rand.Uint64(), 10)
-	var ctx = context.WithValue(context.Background(), "correlation_id", corrID)
+	var ctx = correlation.WithID(context.Background(), corrID)
 	var rep = json.RawMessage(`{"JSON":"MOCK_CONTENT"}`)
 
 	// InfluxHealthChecks.
 	{
 		mockComponent.EXPECT().ExecInfluxHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecInfluxHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ExecInfluxHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecInfluxHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadInfluxHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadInfluxHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ReadInfluxHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadInfluxHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -83,11 +85,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// JaegerHealthChecks.
 	{
 		mockComponent.EXPECT().ExecJaegerHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecJaegerHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ExecJaegerHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecJaegerHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadJaegerHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadJaegerHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ReadJaegerHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadJaegerHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -107,11 +109,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// RedisHealthChecks.
 	{
 		mockComponent.EXPECT().ExecRedisHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecRedisHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ExecRedisHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecRedisHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadRedisHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadRedisHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ReadRedisHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadRedisHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -131,11 +133,11 @@ func TestComponentLoggingMW(t *testing.T) {
 	// SentryHealthChecks.
 	{
 		mockComponent.EXPECT().ExecSentryHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ExecSentryHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ExecSentryHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ExecSentryHealthChecks(ctx)
 
 		mockComponent.EXPECT().ReadSentryHealthChecks(ctx).Return(rep).Times(1)
-		mockLogger.EXPECT().Log("unit", "ReadSentryHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ReadSentryHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.ReadSentryHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -152,11 +154,41 @@ func TestComponentLoggingMW(t *testing.T) {
 		assert.Panics(t, g)
 	}
 
+	// ExecCockroachHealthChecks.
+	{
+		var report = json.RawMessage(`{"unit":"cockroach","status":"OK"}`)
+		mockComponent.EXPECT().ExecCockroachHealthChecks(ctx).Return(report).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ExecCockroachHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		m.ExecCockroachHealthChecks(ctx)
+
+		// Without correlation ID.
+		mockComponent.EXPECT().ExecCockroachHealthChecks(context.Background()).Return(report).Times(1)
+		var f = func() {
+			m.ExecCockroachHealthChecks(context.Background())
+		}
+		assert.Panics(t, f)
+	}
+
+	// ReadCockroachHealthChecks.
+	{
+		var report = json.RawMessage(`{"unit":"cockroach","status":"OK"}`)
+		mockComponent.EXPECT().ReadCockroachHealthChecks(ctx).Return(report).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "ReadCockroachHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		m.ReadCockroachHealthChecks(ctx)
+
+		// Without correlation ID.
+		mockComponent.EXPECT().ReadCockroachHealthChecks(context.Background()).Return(report).Times(1)
+		var f = func() {
+			m.ReadCockroachHealthChecks(context.Background())
+		}
+		assert.Panics(t, f)
+	}
+
 	// AllHealthChecks.
 	{
 		var report = json.RawMessage(`{"influx":[{"Name":"sentry","Duration":"1s","Status":"OK","Error":""}], "redis":[{"Name":"redis","Duration":"1s","Status":"OK","Error":""}]}`)
 		mockComponent.EXPECT().AllHealthChecks(ctx).Return(report).Times(1)
-		mockLogger.EXPECT().Log("unit", "AllHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "AllHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
 		m.AllHealthChecks(ctx)
 
 		// Without correlation ID.
@@ -166,4 +198,34 @@ func TestComponentLoggingMW(t *testing.T) {
 		}
 		assert.Panics(t, f)
 	}
+
+	// DeepHealthChecks.
+	{
+		var report = json.RawMessage(`{"redis":{"status":"OK"}}`)
+		mockComponent.EXPECT().DeepHealthChecks(ctx).Return(report).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "DeepHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		m.DeepHealthChecks(ctx)
+
+		// Without correlation ID.
+		mockComponent.EXPECT().DeepHealthChecks(context.Background()).Return(report).Times(1)
+		var f = func() {
+			m.DeepHealthChecks(context.Background())
+		}
+		assert.Panics(t, f)
+	}
+
+	// FleetHealthChecks.
+	{
+		var report = json.RawMessage(`{"status":"OK","instances":[]}`)
+		mockComponent.EXPECT().FleetHealthChecks(ctx).Return(report).Times(1)
+		mockLogger.EXPECT().Log("level", level.DebugValue(), "unit", "FleetHealthChecks", "correlation_id", corrID, "took", gomock.Any()).Return(nil).Times(1)
+		m.FleetHealthChecks(ctx)
+
+		// Without correlation ID.
+		mockComponent.EXPECT().FleetHealthChecks(context.Background()).Return(report).Times(1)
+		var f = func() {
+			m.FleetHealthChecks(context.Background())
+		}
+		assert.Panics(t, f)
+	}
 }
@@ -3,14 +3,10 @@ package health
 
 import (
 	"context"
-	"math/rand"
-	"time"
-	"strconv"
 
 	"github.com/go-kit/kit/endpoint"
-	"github.com/cloudtrust/elasticsearch-bridge/api/fb"
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
 	opentracing "github.com/opentracing/opentracing-go"
-	"github.com/google/flatbuffers/go"
 	otag "github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/metadata"
@@ -23,13 +19,15 @@ type IDGenerator interface {
 
 
 // MakeEndpointCorrelationIDMW makes a middleware that adds a correlation ID
-// in the context if there is not already one.
-func MakeEndpointCorrelationIDMW(flaki fb.FlakiClient, tracer opentracing.Tracer) endpoint.Middleware {
+// in the context if there is not already one. The correlation ID is echoed in the
+// HTTP response headers. The IDs come from the Flaki client, which falls back to the
+// embedded generator when Flaki is unreachable.
+func MakeEndpointCorrelationIDMW(flaki IDGenerator, tracer opentracing.Tracer) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, req interface{}) (interface{}, error) {
-			var id = ctx.Value("correlation_id")
+			var id, ok = correlation.ID(ctx)
 
-			if id == nil {
+			if !ok {
 				if span := opentracing.SpanFromContext(ctx); span != nil {
 					span = tracer.StartSpan("get_correlation_id", opentracing.ChildOf(span.Context()))
 					otag.SpanKindRPCClient.Set(span)
@@ -47,22 +45,12 @@ func MakeEndpointCorrelationIDMW(flaki fb.FlakiClient, tracer opentracing.Tracer
 					ctx = metadata.NewOutgoingContext(ctx, md)
 				}
 
-				// Flaki request.
-				var b = flatbuffers.NewBuilder(0)
-				fb.FlakiRequestStart(b)
-				b.Finish(fb.FlakiRequestEnd(b))
-
-				var reply, err = flaki.NextValidID(ctx, b)
-				var corrID string
-				// If we cannot get ID from Flaki, we generate a random one.
-				if err != nil {
-					rand.Seed(time.Now().UnixNano())
-					corrID = "degraded-" + strconv.FormatUint(rand.Uint64(), 10)
-				} else {
-					corrID = string(reply.Id())
-				}
+				id = flaki.NextValidID(ctx)
+				ctx = correlation.WithID(ctx, id)
+			}
 
-				ctx = context.WithValue(ctx, "correlation_id", corrID)
+			if echo, ok := ctx.Value(correlationIDEchoKey{}).(*correlationIDEcho); ok {
+				echo.id = id
 			}
 			return next(ctx, req)
 		}
@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cloudtrust/elasticsearch-bridge/pkg/health (interfaces: CockroachClient)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	sql "database/sql"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// CockroachClient is a mock of CockroachClient interface
+type CockroachClient struct {
+	ctrl     *gomock.Controller
+	recorder *CockroachClientMockRecorder
+}
+
+// CockroachClientMockRecorder is the mock recorder for CockroachClient
+type CockroachClientMockRecorder struct {
+	mock *CockroachClient
+}
+
+// NewCockroachClient creates a new mock instance
+func NewCockroachClient(ctrl *gomock.Controller) *CockroachClient {
+	mock := &CockroachClient{ctrl: ctrl}
+	mock.recorder = &CockroachClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *CockroachClient) EXPECT() *CockroachClientMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method
+func (m *CockroachClient) ExecContext(arg0 context.Context, arg1 string, arg2 ...interface{}) (sql.Result, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext
+func (mr *CockroachClientMockRecorder) ExecContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*CockroachClient)(nil).ExecContext), varargs...)
+}
+
+// PingContext mocks base method
+func (m *CockroachClient) PingContext(arg0 context.Context) error {
+	ret := m.ctrl.Call(m, "PingContext", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PingContext indicates an expected call of PingContext
+func (mr *CockroachClientMockRecorder) PingContext(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingContext", reflect.TypeOf((*CockroachClient)(nil).PingContext), arg0)
+}
+
+// QueryContext mocks base method
+func (m *CockroachClient) QueryContext(arg0 context.Context, arg1 string, arg2 ...interface{}) (*sql.Rows, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(*sql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext
+func (mr *CockroachClientMockRecorder) QueryContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*CockroachClient)(nil).QueryContext), varargs...)
+}
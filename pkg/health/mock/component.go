@@ -46,6 +46,30 @@ func (mr *HealthCheckerMockRecorder) AllHealthChecks(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllHealthChecks", reflect.TypeOf((*HealthChecker)(nil).AllHealthChecks), arg0)
 }
 
+// DeepHealthChecks mocks base method
+func (m *HealthChecker) DeepHealthChecks(arg0 context.Context) json.RawMessage {
+	ret := m.ctrl.Call(m, "DeepHealthChecks", arg0)
+	ret0, _ := ret[0].(json.RawMessage)
+	return ret0
+}
+
+// DeepHealthChecks indicates an expected call of DeepHealthChecks
+func (mr *HealthCheckerMockRecorder) DeepHealthChecks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeepHealthChecks", reflect.TypeOf((*HealthChecker)(nil).DeepHealthChecks), arg0)
+}
+
+// ExecCockroachHealthChecks mocks base method
+func (m *HealthChecker) ExecCockroachHealthChecks(arg0 context.Context) json.RawMessage {
+	ret := m.ctrl.Call(m, "ExecCockroachHealthChecks", arg0)
+	ret0, _ := ret[0].(json.RawMessage)
+	return ret0
+}
+
+// ExecCockroachHealthChecks indicates an expected call of ExecCockroachHealthChecks
+func (mr *HealthCheckerMockRecorder) ExecCockroachHealthChecks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCockroachHealthChecks", reflect.TypeOf((*HealthChecker)(nil).ExecCockroachHealthChecks), arg0)
+}
+
 // ExecElasticsearchHealthChecks mocks base method
 func (m *HealthChecker) ExecElasticsearchHealthChecks(arg0 context.Context) json.RawMessage {
 	ret := m.ctrl.Call(m, "ExecElasticsearchHealthChecks", arg0)
@@ -118,6 +142,30 @@ func (mr *HealthCheckerMockRecorder) ExecSentryHealthChecks(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecSentryHealthChecks", reflect.TypeOf((*HealthChecker)(nil).ExecSentryHealthChecks), arg0)
 }
 
+// FleetHealthChecks mocks base method
+func (m *HealthChecker) FleetHealthChecks(arg0 context.Context) json.RawMessage {
+	ret := m.ctrl.Call(m, "FleetHealthChecks", arg0)
+	ret0, _ := ret[0].(json.RawMessage)
+	return ret0
+}
+
+// FleetHealthChecks indicates an expected call of FleetHealthChecks
+func (mr *HealthCheckerMockRecorder) FleetHealthChecks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FleetHealthChecks", reflect.TypeOf((*HealthChecker)(nil).FleetHealthChecks), arg0)
+}
+
+// ReadCockroachHealthChecks mocks base method
+func (m *HealthChecker) ReadCockroachHealthChecks(arg0 context.Context) json.RawMessage {
+	ret := m.ctrl.Call(m, "ReadCockroachHealthChecks", arg0)
+	ret0, _ := ret[0].(json.RawMessage)
+	return ret0
+}
+
+// ReadCockroachHealthChecks indicates an expected call of ReadCockroachHealthChecks
+func (mr *HealthCheckerMockRecorder) ReadCockroachHealthChecks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCockroachHealthChecks", reflect.TypeOf((*HealthChecker)(nil).ReadCockroachHealthChecks), arg0)
+}
+
 // ReadElasticsearchHealthChecks mocks base method
 func (m *HealthChecker) ReadElasticsearchHealthChecks(arg0 context.Context) json.RawMessage {
 	ret := m.ctrl.Call(m, "ReadElasticsearchHealthChecks", arg0)
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	elasticsearch_bridge "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -33,65 +34,154 @@ func (m *ElasticsearchClient) EXPECT() *ElasticsearchClientMockRecorder {
 	return m.recorder
 }
 
+// Allocation mocks base method
+func (m *ElasticsearchClient) Allocation(arg0 context.Context) ([]elasticsearch_bridge.AllocationRepresentation, error) {
+	ret := m.ctrl.Call(m, "Allocation", arg0)
+	ret0, _ := ret[0].([]elasticsearch_bridge.AllocationRepresentation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allocation indicates an expected call of Allocation
+func (mr *ElasticsearchClientMockRecorder) Allocation(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allocation", reflect.TypeOf((*ElasticsearchClient)(nil).Allocation), arg0)
+}
+
+// ClusterSettings mocks base method
+func (m *ElasticsearchClient) ClusterSettings(arg0 context.Context) (elasticsearch_bridge.ClusterSettingsRepresentation, error) {
+	ret := m.ctrl.Call(m, "ClusterSettings", arg0)
+	ret0, _ := ret[0].(elasticsearch_bridge.ClusterSettingsRepresentation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClusterSettings indicates an expected call of ClusterSettings
+func (mr *ElasticsearchClientMockRecorder) ClusterSettings(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClusterSettings", reflect.TypeOf((*ElasticsearchClient)(nil).ClusterSettings), arg0)
+}
+
 // CreateIndex mocks base method
-func (m *ElasticsearchClient) CreateIndex(arg0 string) error {
-	ret := m.ctrl.Call(m, "CreateIndex", arg0)
+func (m *ElasticsearchClient) CreateIndex(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "CreateIndex", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateIndex indicates an expected call of CreateIndex
-func (mr *ElasticsearchClientMockRecorder) CreateIndex(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndex", reflect.TypeOf((*ElasticsearchClient)(nil).CreateIndex), arg0)
+func (mr *ElasticsearchClientMockRecorder) CreateIndex(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndex", reflect.TypeOf((*ElasticsearchClient)(nil).CreateIndex), arg0, arg1)
 }
 
-// DeleteIndex mocks base method
-func (m *ElasticsearchClient) DeleteIndex(arg0 string) error {
-	ret := m.ctrl.Call(m, "DeleteIndex", arg0)
+// DeleteDocument mocks base method
+func (m *ElasticsearchClient) DeleteDocument(arg0 context.Context, arg1, arg2 string) error {
+	ret := m.ctrl.Call(m, "DeleteDocument", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteIndex indicates an expected call of DeleteIndex
-func (mr *ElasticsearchClientMockRecorder) DeleteIndex(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIndex", reflect.TypeOf((*ElasticsearchClient)(nil).DeleteIndex), arg0)
+// DeleteDocument indicates an expected call of DeleteDocument
+func (mr *ElasticsearchClientMockRecorder) DeleteDocument(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDocument", reflect.TypeOf((*ElasticsearchClient)(nil).DeleteDocument), arg0, arg1, arg2)
+}
+
+// GetDocument mocks base method
+func (m *ElasticsearchClient) GetDocument(arg0 context.Context, arg1, arg2 string) (elasticsearch_bridge.DocumentRepresentation, error) {
+	ret := m.ctrl.Call(m, "GetDocument", arg0, arg1, arg2)
+	ret0, _ := ret[0].(elasticsearch_bridge.DocumentRepresentation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocument indicates an expected call of GetDocument
+func (mr *ElasticsearchClientMockRecorder) GetDocument(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*ElasticsearchClient)(nil).GetDocument), arg0, arg1, arg2)
 }
 
 // GetIndex mocks base method
-func (m *ElasticsearchClient) GetIndex(arg0 string) (elasticsearch_bridge.IndexSettingsRepresentation, error) {
-	ret := m.ctrl.Call(m, "GetIndex", arg0)
+func (m *ElasticsearchClient) GetIndex(arg0 context.Context, arg1 string) (elasticsearch_bridge.IndexSettingsRepresentation, error) {
+	ret := m.ctrl.Call(m, "GetIndex", arg0, arg1)
 	ret0, _ := ret[0].(elasticsearch_bridge.IndexSettingsRepresentation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetIndex indicates an expected call of GetIndex
-func (mr *ElasticsearchClientMockRecorder) GetIndex(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndex", reflect.TypeOf((*ElasticsearchClient)(nil).GetIndex), arg0)
+func (mr *ElasticsearchClientMockRecorder) GetIndex(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndex", reflect.TypeOf((*ElasticsearchClient)(nil).GetIndex), arg0, arg1)
+}
+
+// GetIndexesSetting mocks base method
+func (m *ElasticsearchClient) GetIndexesSetting(arg0 context.Context, arg1 string) (map[string]elasticsearch_bridge.IndexSettingRepresentation, error) {
+	ret := m.ctrl.Call(m, "GetIndexesSetting", arg0, arg1)
+	ret0, _ := ret[0].(map[string]elasticsearch_bridge.IndexSettingRepresentation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIndexesSetting indicates an expected call of GetIndexesSetting
+func (mr *ElasticsearchClientMockRecorder) GetIndexesSetting(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndexesSetting", reflect.TypeOf((*ElasticsearchClient)(nil).GetIndexesSetting), arg0, arg1)
 }
 
 // Health mocks base method
-func (m *ElasticsearchClient) Health() (elasticsearch_bridge.HealthRepresentation, error) {
-	ret := m.ctrl.Call(m, "Health")
+func (m *ElasticsearchClient) Health(arg0 context.Context) (elasticsearch_bridge.HealthRepresentation, error) {
+	ret := m.ctrl.Call(m, "Health", arg0)
 	ret0, _ := ret[0].(elasticsearch_bridge.HealthRepresentation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Health indicates an expected call of Health
-func (mr *ElasticsearchClientMockRecorder) Health() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*ElasticsearchClient)(nil).Health))
+func (mr *ElasticsearchClientMockRecorder) Health(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*ElasticsearchClient)(nil).Health), arg0)
+}
+
+// IndexDocument mocks base method
+func (m *ElasticsearchClient) IndexDocument(arg0 context.Context, arg1, arg2 string, arg3 interface{}) error {
+	ret := m.ctrl.Call(m, "IndexDocument", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IndexDocument indicates an expected call of IndexDocument
+func (mr *ElasticsearchClientMockRecorder) IndexDocument(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexDocument", reflect.TypeOf((*ElasticsearchClient)(nil).IndexDocument), arg0, arg1, arg2, arg3)
+}
+
+// NodesStats mocks base method
+func (m *ElasticsearchClient) NodesStats(arg0 context.Context) (elasticsearch_bridge.NodesStatsRepresentation, error) {
+	ret := m.ctrl.Call(m, "NodesStats", arg0)
+	ret0, _ := ret[0].(elasticsearch_bridge.NodesStatsRepresentation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodesStats indicates an expected call of NodesStats
+func (mr *ElasticsearchClientMockRecorder) NodesStats(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodesStats", reflect.TypeOf((*ElasticsearchClient)(nil).NodesStats), arg0)
+}
+
+// Refresh mocks base method
+func (m *ElasticsearchClient) Refresh(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "Refresh", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refresh indicates an expected call of Refresh
+func (mr *ElasticsearchClientMockRecorder) Refresh(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*ElasticsearchClient)(nil).Refresh), arg0, arg1)
 }
 
-// ListIndexes mocks base method
-func (m *ElasticsearchClient) ListIndexes() ([]elasticsearch_bridge.IndexRepresentation, error) {
-	ret := m.ctrl.Call(m, "ListIndexes")
-	ret0, _ := ret[0].([]elasticsearch_bridge.IndexRepresentation)
+// Search mocks base method
+func (m *ElasticsearchClient) Search(arg0 context.Context, arg1 string, arg2 interface{}) (elasticsearch_bridge.SearchRepresentation, error) {
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2)
+	ret0, _ := ret[0].(elasticsearch_bridge.SearchRepresentation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListIndexes indicates an expected call of ListIndexes
-func (mr *ElasticsearchClientMockRecorder) ListIndexes() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIndexes", reflect.TypeOf((*ElasticsearchClient)(nil).ListIndexes))
+// Search indicates an expected call of Search
+func (mr *ElasticsearchClientMockRecorder) Search(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*ElasticsearchClient)(nil).Search), arg0, arg1, arg2)
 }
@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cloudtrust/elasticsearch-bridge/pkg/health (interfaces: FlakiFallback,FlakiConnectivity)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	connectivity "google.golang.org/grpc/connectivity"
+	reflect "reflect"
+	time "time"
+)
+
+// FlakiFallback is a mock of FlakiFallback interface
+type FlakiFallback struct {
+	ctrl     *gomock.Controller
+	recorder *FlakiFallbackMockRecorder
+}
+
+// FlakiFallbackMockRecorder is the mock recorder for FlakiFallback
+type FlakiFallbackMockRecorder struct {
+	mock *FlakiFallback
+}
+
+// NewFlakiFallback creates a new mock instance
+func NewFlakiFallback(ctrl *gomock.Controller) *FlakiFallback {
+	mock := &FlakiFallback{ctrl: ctrl}
+	mock.recorder = &FlakiFallbackMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *FlakiFallback) EXPECT() *FlakiFallbackMockRecorder {
+	return m.recorder
+}
+
+// Degraded mocks base method
+func (m *FlakiFallback) Degraded() (bool, time.Time, error) {
+	ret := m.ctrl.Call(m, "Degraded")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Degraded indicates an expected call of Degraded
+func (mr *FlakiFallbackMockRecorder) Degraded() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Degraded", reflect.TypeOf((*FlakiFallback)(nil).Degraded))
+}
+
+// FlakiConnectivity is a mock of FlakiConnectivity interface
+type FlakiConnectivity struct {
+	ctrl     *gomock.Controller
+	recorder *FlakiConnectivityMockRecorder
+}
+
+// FlakiConnectivityMockRecorder is the mock recorder for FlakiConnectivity
+type FlakiConnectivityMockRecorder struct {
+	mock *FlakiConnectivity
+}
+
+// NewFlakiConnectivity creates a new mock instance
+func NewFlakiConnectivity(ctrl *gomock.Controller) *FlakiConnectivity {
+	mock := &FlakiConnectivity{ctrl: ctrl}
+	mock.recorder = &FlakiConnectivityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *FlakiConnectivity) EXPECT() *FlakiConnectivityMockRecorder {
+	return m.recorder
+}
+
+// GetState mocks base method
+func (m *FlakiConnectivity) GetState() connectivity.State {
+	ret := m.ctrl.Call(m, "GetState")
+	ret0, _ := ret[0].(connectivity.State)
+	return ret0
+}
+
+// GetState indicates an expected call of GetState
+func (mr *FlakiConnectivityMockRecorder) GetState() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*FlakiConnectivity)(nil).GetState))
+}
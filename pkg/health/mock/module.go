@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/cloudtrust/elasticsearch-bridge/pkg/health (interfaces: InfluxHealthChecker,JaegerHealthChecker,RedisHealthChecker,SentryHealthChecker,FlakiHealthChecker,ElasticsearchHealthChecker,StoreModule)
+// Source: github.com/cloudtrust/elasticsearch-bridge/pkg/health (interfaces: InfluxHealthChecker,JaegerHealthChecker,RedisHealthChecker,SentryHealthChecker,FlakiHealthChecker,ElasticsearchHealthChecker,CockroachHealthChecker,StoreModule)
 
 // Package mock is a generated GoMock package.
 package mock
@@ -224,6 +224,41 @@ func (mr *ElasticsearchHealthCheckerMockRecorder) HealthChecks(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthChecks", reflect.TypeOf((*ElasticsearchHealthChecker)(nil).HealthChecks), arg0)
 }
 
+// CockroachHealthChecker is a mock of CockroachHealthChecker interface
+type CockroachHealthChecker struct {
+	ctrl     *gomock.Controller
+	recorder *CockroachHealthCheckerMockRecorder
+}
+
+// CockroachHealthCheckerMockRecorder is the mock recorder for CockroachHealthChecker
+type CockroachHealthCheckerMockRecorder struct {
+	mock *CockroachHealthChecker
+}
+
+// NewCockroachHealthChecker creates a new mock instance
+func NewCockroachHealthChecker(ctrl *gomock.Controller) *CockroachHealthChecker {
+	mock := &CockroachHealthChecker{ctrl: ctrl}
+	mock.recorder = &CockroachHealthCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *CockroachHealthChecker) EXPECT() *CockroachHealthCheckerMockRecorder {
+	return m.recorder
+}
+
+// HealthChecks mocks base method
+func (m *CockroachHealthChecker) HealthChecks(arg0 context.Context) []health.CockroachReport {
+	ret := m.ctrl.Call(m, "HealthChecks", arg0)
+	ret0, _ := ret[0].([]health.CockroachReport)
+	return ret0
+}
+
+// HealthChecks indicates an expected call of HealthChecks
+func (mr *CockroachHealthCheckerMockRecorder) HealthChecks(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthChecks", reflect.TypeOf((*CockroachHealthChecker)(nil).HealthChecks), arg0)
+}
+
 // StoreModule is a mock of StoreModule interface
 type StoreModule struct {
 	ctrl     *gomock.Controller
@@ -247,6 +282,18 @@ func (m *StoreModule) EXPECT() *StoreModuleMockRecorder {
 	return m.recorder
 }
 
+// Clean mocks base method
+func (m *StoreModule) Clean() error {
+	ret := m.ctrl.Call(m, "Clean")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clean indicates an expected call of Clean
+func (mr *StoreModuleMockRecorder) Clean() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clean", reflect.TypeOf((*StoreModule)(nil).Clean))
+}
+
 // Read mocks base method
 func (m *StoreModule) Read(arg0 string) (health.StoredReport, error) {
 	ret := m.ctrl.Call(m, "Read", arg0)
@@ -260,6 +307,19 @@ func (mr *StoreModuleMockRecorder) Read(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*StoreModule)(nil).Read), arg0)
 }
 
+// ReadAll mocks base method
+func (m *StoreModule) ReadAll() ([]health.StoredReport, error) {
+	ret := m.ctrl.Call(m, "ReadAll")
+	ret0, _ := ret[0].([]health.StoredReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadAll indicates an expected call of ReadAll
+func (mr *StoreModuleMockRecorder) ReadAll() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAll", reflect.TypeOf((*StoreModule)(nil).ReadAll))
+}
+
 // Update mocks base method
 func (m *StoreModule) Update(arg0 string, arg1 time.Duration, arg2 json.RawMessage) error {
 	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
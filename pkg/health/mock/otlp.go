@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cloudtrust/elasticsearch-bridge/pkg/health (interfaces: OTLPCollector)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// OTLPCollector is a mock of OTLPCollector interface
+type OTLPCollector struct {
+	ctrl     *gomock.Controller
+	recorder *OTLPCollectorMockRecorder
+}
+
+// OTLPCollectorMockRecorder is the mock recorder for OTLPCollector
+type OTLPCollectorMockRecorder struct {
+	mock *OTLPCollector
+}
+
+// NewOTLPCollector creates a new mock instance
+func NewOTLPCollector(ctrl *gomock.Controller) *OTLPCollector {
+	mock := &OTLPCollector{ctrl: ctrl}
+	mock.recorder = &OTLPCollectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *OTLPCollector) EXPECT() *OTLPCollectorMockRecorder {
+	return m.recorder
+}
+
+// Ping mocks base method
+func (m *OTLPCollector) Ping(arg0 context.Context) error {
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping
+func (mr *OTLPCollectorMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*OTLPCollector)(nil).Ping), arg0)
+}
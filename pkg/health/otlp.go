@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	"github.com/pkg/errors"
+)
+
+// OTLPCollector is the interface of the OTLP transport exporting the traces.
+type OTLPCollector interface {
+	Ping(context.Context) error
+}
+
+// OTLPModule is the health check module for the OTLP collector. It is the equivalent of
+// the Jaeger module when the traces are exported with OTLP, so it returns Jaeger reports
+// and takes the place of the Jaeger module in the tracing unit.
+type OTLPModule struct {
+	collector OTLPCollector
+	enabled   bool
+}
+
+// NewOTLPModule returns the OTLP collector health module.
+func NewOTLPModule(collector OTLPCollector, enabled bool) *OTLPModule {
+	return &OTLPModule{
+		collector: collector,
+		enabled:   enabled,
+	}
+}
+
+// HealthChecks executes all health checks for the OTLP collector.
+func (m *OTLPModule) HealthChecks(ctx context.Context) []common.JaegerReport {
+	if !m.enabled {
+		return []common.JaegerReport{{Name: "otlp", Status: common.Deactivated}}
+	}
+
+	var reports = []common.JaegerReport{}
+	reports = append(reports, m.otlpExportCheck(ctx))
+	return reports
+}
+
+// otlpExportCheck exports an empty request to the collector.
+func (m *OTLPModule) otlpExportCheck(ctx context.Context) common.JaegerReport {
+	var healthCheckName = "export"
+
+	var now = time.Now()
+	var err = m.collector.Ping(ctx)
+	var duration = time.Since(now)
+
+	var hcErr error
+	var s common.Status
+	switch {
+	case err != nil:
+		hcErr = errors.Wrap(err, "could not reach OTLP collector")
+		s = common.KO
+	default:
+		s = common.OK
+	}
+
+	return common.JaegerReport{
+		Name:     healthCheckName,
+		Duration: duration,
+		Status:   s,
+		Error:    hcErr,
+	}
+}
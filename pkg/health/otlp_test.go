@@ -0,0 +1,57 @@
+package health_test
+
+//go:generate mockgen -destination=./mock/otlp.go -package=mock -mock_names=OTLPCollector=OTLPCollector  github.com/cloudtrust/elasticsearch-bridge/pkg/health OTLPCollector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	common "github.com/cloudtrust/common-healthcheck"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPHealthChecksDeactivated(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCollector = mock.NewOTLPCollector(mockCtrl)
+
+	var m = NewOTLPModule(mockCollector, false)
+
+	var reports = m.HealthChecks(context.Background())
+	assert.Equal(t, 1, len(reports))
+	assert.Equal(t, "otlp", reports[0].Name)
+	assert.Equal(t, common.Deactivated, reports[0].Status)
+}
+
+func TestOTLPHealthChecks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCollector = mock.NewOTLPCollector(mockCtrl)
+
+	var m = NewOTLPModule(mockCollector, true)
+
+	// Collector reachable.
+	{
+		mockCollector.EXPECT().Ping(gomock.Any()).Return(nil).Times(1)
+
+		var reports = m.HealthChecks(context.Background())
+		assert.Equal(t, 1, len(reports))
+		assert.Equal(t, "export", reports[0].Name)
+		assert.Equal(t, common.OK, reports[0].Status)
+		assert.Nil(t, reports[0].Error)
+	}
+
+	// Collector unreachable.
+	{
+		mockCollector.EXPECT().Ping(gomock.Any()).Return(fmt.Errorf("connection refused")).Times(1)
+
+		var reports = m.HealthChecks(context.Background())
+		assert.Equal(t, 1, len(reports))
+		assert.Equal(t, common.KO, reports[0].Status)
+		assert.NotNil(t, reports[0].Error)
+	}
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	client "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
+)
+
+// The former Index API health check created an index named by a time-based UUID and
+// deleted it right away. When the deletion failed, the probe index was left behind.
+
+// ProbeIndexCreationDateSetting is the index setting with the creation date of the index,
+// in milliseconds since the epoch.
+const ProbeIndexCreationDateSetting = "index.creation_date"
+
+// probeIndexName matches a version 1 UUID in canonical form, as generated by uuid.NewUUID.
+var probeIndexName = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-1[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// IsProbeIndex returns true if the index name is the name of a probe index.
+func IsProbeIndex(indexName string) bool {
+	return probeIndexName.MatchString(indexName)
+}
+
+// ProbeIndexes returns the probe indexes among the indexes of the settings, sorted by name.
+func ProbeIndexes(settings map[string]client.IndexSettingRepresentation) []string {
+	var probes = []string{}
+	for index := range settings {
+		if IsProbeIndex(index) {
+			probes = append(probes, index)
+		}
+	}
+	sort.Strings(probes)
+	return probes
+}
+
+// StaleProbeIndexes returns the probe indexes created before 'limit', sorted by name. The
+// settings are the index.creation_date settings of the indexes. A probe index without a
+// valid creation date is not stale.
+func StaleProbeIndexes(settings map[string]client.IndexSettingRepresentation, limit time.Time) []string {
+	var stale = []string{}
+	for _, index := range ProbeIndexes(settings) {
+		var ms, err = strconv.ParseInt(settings[index].Settings[ProbeIndexCreationDateSetting], 10, 64)
+		if err != nil {
+			continue
+		}
+		if time.Unix(0, ms*int64(time.Millisecond)).Before(limit) {
+			stale = append(stale, index)
+		}
+	}
+	return stale
+}
@@ -0,0 +1,51 @@
+package health_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	internal "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsProbeIndex(t *testing.T) {
+	var id, _ = uuid.NewUUID()
+	assert.True(t, IsProbeIndex(id.String()))
+	assert.True(t, IsProbeIndex("b1f1e38a-c5b4-11e8-a355-529269fb1459"))
+
+	var invalidNames = []string{
+		"int-elastic-2018.10.01",
+		"elasticsearch-bridge-health",
+		// Random UUID.
+		uuid.New().String(),
+		"B1F1E38A-C5B4-11E8-A355-529269FB1459",
+		"b1f1e38ac5b411e8a355529269fb1459",
+		"b1f1e38a-c5b4-11e8-a355-529269fb1459-1",
+	}
+	for _, name := range invalidNames {
+		assert.False(t, IsProbeIndex(name), name)
+	}
+}
+
+func TestStaleProbeIndexes(t *testing.T) {
+	var now = time.Now()
+	var creationDate = func(t time.Time) internal.IndexSettingRepresentation {
+		return internal.IndexSettingRepresentation{Settings: map[string]string{ProbeIndexCreationDateSetting: strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)}}
+	}
+
+	var settings = map[string]internal.IndexSettingRepresentation{
+		"b1f1e38a-c5b4-11e8-a355-529269fb1459": creationDate(now.Add(-2 * time.Hour)),
+		"0e4c9d2e-c5b5-11e8-a355-529269fb1459": creationDate(now.Add(-3 * time.Hour)),
+		"1b8f6b4c-c5b5-11e8-a355-529269fb1459": creationDate(now),
+		// Indexes without the probe name or a valid creation date are never stale.
+		"int-elastic-1800.10.02":               creationDate(now.Add(-2 * time.Hour)),
+		"2c3a7f0e-c5b5-11e8-a355-529269fb1459": {Settings: map[string]string{}},
+	}
+
+	assert.Equal(t, []string{"0e4c9d2e-c5b5-11e8-a355-529269fb1459", "1b8f6b4c-c5b5-11e8-a355-529269fb1459", "2c3a7f0e-c5b5-11e8-a355-529269fb1459", "b1f1e38a-c5b4-11e8-a355-529269fb1459"}, ProbeIndexes(settings))
+	assert.Equal(t, []string{"0e4c9d2e-c5b5-11e8-a355-529269fb1459", "b1f1e38a-c5b4-11e8-a355-529269fb1459"}, StaleProbeIndexes(settings, now.Add(-time.Hour)))
+	assert.Equal(t, []string{}, StaleProbeIndexes(nil, now))
+}
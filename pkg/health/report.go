@@ -0,0 +1,78 @@
+package health
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// ReportVersion is the version of the report envelope returned by the health endpoints.
+	ReportVersion = 2
+	// LegacyReportVersion is the version of the reports returned before the envelope.
+	LegacyReportVersion = 1
+)
+
+// Report is the envelope of the health checks reports of a unit. The status is the most
+// severe status of the reports, or Unknown if the reports are missing or stale.
+type Report struct {
+	Version     int             `json:"version"`
+	Unit        string          `json:"unit"`
+	Status      string          `json:"status"`
+	LastUpdated *time.Time      `json:"last_updated,omitempty"`
+	ValidUntil  *time.Time      `json:"valid_until,omitempty"`
+	Stale       bool            `json:"stale"`
+	Error       string          `json:"error,omitempty"`
+	Reports     json.RawMessage `json:"reports"`
+}
+
+// marshalReport returns the JSON encoding of the report envelope.
+func marshalReport(r Report) json.RawMessage {
+	r.Version = ReportVersion
+	if r.Reports == nil {
+		r.Reports = json.RawMessage(`[]`)
+	}
+
+	var jsonReport, _ = json.Marshal(r)
+	return json.RawMessage(jsonReport)
+}
+
+// legacy returns the report in the legacy format: the reports when they are valid,
+// an error object otherwise.
+func (r Report) legacy() json.RawMessage {
+	if r.Error == "" {
+		return r.Reports
+	}
+
+	var legacyReport = map[string]string{"Name": r.Unit, "Error": r.Error}
+	if !r.Stale {
+		legacyReport["Status"] = Unknown.String()
+	}
+
+	var jsonReport, _ = json.Marshal(legacyReport)
+	return json.RawMessage(jsonReport)
+}
+
+// legacyReply converts the report envelope, or the map of report envelopes, to the legacy
+// format. Other replies are returned unchanged.
+func legacyReply(reply json.RawMessage) json.RawMessage {
+	var r Report
+	if err := json.Unmarshal(reply, &r); err == nil && r.Version > 0 {
+		return r.legacy()
+	}
+
+	var reports map[string]Report
+	if err := json.Unmarshal(reply, &reports); err != nil || len(reports) == 0 {
+		return reply
+	}
+
+	var legacyReports = map[string]json.RawMessage{}
+	for unit, r := range reports {
+		if r.Version == 0 {
+			return reply
+		}
+		legacyReports[unit] = r.legacy()
+	}
+
+	var jsonReply, _ = json.Marshal(legacyReports)
+	return json.RawMessage(jsonReply)
+}
@@ -27,7 +27,8 @@ const (
 		valid_until)
 		VALUES ($1, $2, $3, $4, $5, $6)`
 	selectHealthStmt = `SELECT * FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`
-	cleanHealthStmt  = `DELETE from health WHERE (component_name = $1 AND valid_until < $2)`
+	selectAllHealthStmt = `SELECT * FROM health WHERE (component_name = $1) ORDER BY component_id, unit`
+	cleanHealthStmt  = `DELETE from health WHERE ((component_name = $1 OR component_name = $2) AND valid_until < $3)`
 )
 
 type StoredReport struct{
@@ -43,6 +44,7 @@ type StoredReport struct{
 type StorageModule struct {
 	componentName string
 	componentID   string
+	retention     time.Duration
 	db            Storage
 }
 
@@ -51,14 +53,16 @@ type Storage interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 }
 
-// NewStorageModule returns the cockroach storage module.
-func NewStorageModule(componentName, componentID string, db Storage) *StorageModule {
+// NewStorageModule returns the cockroach storage module. The reports are kept for 'retention'
+// after they are no longer valid, so the fleet health shows the instances that are gone.
+func NewStorageModule(componentName, componentID string, retention time.Duration, db Storage) *StorageModule {
 	// Init DB: create health table.
 	db.Exec(createHealthTblStmt)
 
 	return &StorageModule{
 		componentName: componentName,
 		componentID:   componentID,
+		retention:     retention,
 		db:            db,
 	}
 }
@@ -109,9 +113,44 @@ func (c *StorageModule) Read(unit string) (StoredReport, error) {
 	return StoredReport{}, nil
 }
 
-// Clean deletes the old test reports that are no longer valid from the health DB table.
+// ReadAll reads the reports of all the instances of the component in DB.
+func (c *StorageModule) ReadAll() ([]StoredReport, error) {
+	var rows, err = c.db.Query(selectAllHealthStmt, c.componentName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "component '%s' with id '%s' could not read health checks of all instances", c.componentName, c.componentID)
+	}
+	defer rows.Close()
+
+	var storedReports = []StoredReport{}
+	for rows.Next() {
+		var (
+			cName, cID, hcUnit      string
+			reports                 json.RawMessage
+			lastUpdated, validUntil time.Time
+		)
+
+		var err = rows.Scan(&cName, &cID, &hcUnit, &reports, &lastUpdated, &validUntil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "component '%s' with id '%s' could not read health checks of all instances", c.componentName, c.componentID)
+		}
+
+		storedReports = append(storedReports, StoredReport{
+			ComponentName:   cName,
+			ComponentID:     cID,
+			HealthcheckUnit: hcUnit,
+			Reports:         reports,
+			LastUpdated:     lastUpdated.UTC(),
+			ValidUntil:      validUntil.UTC(),
+		})
+	}
+
+	return storedReports, rows.Err()
+}
+
+// Clean deletes the old test reports that are no longer valid since the retention from the health DB table,
+// with the probe rows left by the cockroach health check.
 func (c *StorageModule) Clean() error {
-	var _, err = c.db.Exec(cleanHealthStmt, c.componentName, time.Now().UTC())
+	var _, err = c.db.Exec(cleanHealthStmt, c.componentName, probeComponentName(c.componentName), time.Now().Add(-c.retention).UTC())
 
 	if err != nil {
 		return errors.Wrapf(err, "component '%s' with id '%s' could not clean health checks", c.componentName, c.componentID)
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+)
+
+var healthBucket = []byte("health")
+
+// BoltStorageModule is the module that saves the health checks results in an embedded
+// BoltDB file. The keys are <component name>/<component ID>/<unit>.
+type BoltStorageModule struct {
+	componentName string
+	componentID   string
+	retention     time.Duration
+	db            *bolt.DB
+}
+
+// NewBoltStorageModule returns the BoltDB storage module. The reports are kept for 'retention'
+// after they are no longer valid, so the fleet health shows the instances that are gone.
+func NewBoltStorageModule(componentName, componentID string, retention time.Duration, db *bolt.DB) (*BoltStorageModule, error) {
+	// Init DB: create health bucket.
+	var err = db.Update(func(tx *bolt.Tx) error {
+		var _, err = tx.CreateBucketIfNotExists(healthBucket)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create health bucket")
+	}
+
+	return &BoltStorageModule{
+		componentName: componentName,
+		componentID:   componentID,
+		retention:     retention,
+		db:            db,
+	}, nil
+}
+
+// Update updates the health checks reports stored in DB with the values 'jsonReports'.
+func (c *BoltStorageModule) Update(unit string, validity time.Duration, jsonReports json.RawMessage) error {
+	var now = time.Now()
+	var value, err = json.Marshal(StoredReport{
+		ComponentName:   c.componentName,
+		ComponentID:     c.componentID,
+		HealthcheckUnit: unit,
+		Reports:         jsonReports,
+		LastUpdated:     now.UTC(),
+		ValidUntil:      now.Add(validity).UTC(),
+	})
+	if err == nil {
+		err = c.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(healthBucket).Put(c.key(unit), value)
+		})
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "component '%s' with id '%s' could not update health check for unit '%s'", c.componentName, c.componentID, unit)
+	}
+
+	return nil
+}
+
+// Read reads the reports in DB.
+func (c *BoltStorageModule) Read(unit string) (StoredReport, error) {
+	var storedReport = StoredReport{}
+	var err = c.db.View(func(tx *bolt.Tx) error {
+		var value = tx.Bucket(healthBucket).Get(c.key(unit))
+		if value == nil {
+			return nil
+		}
+		return json.Unmarshal(value, &storedReport)
+	})
+
+	if err != nil {
+		return StoredReport{}, errors.Wrapf(err, "component '%s' with id '%s' could not read health check '%s'", c.componentName, c.componentID, unit)
+	}
+
+	return storedReport, nil
+}
+
+// ReadAll reads the reports of all the instances of the component in DB.
+func (c *BoltStorageModule) ReadAll() ([]StoredReport, error) {
+	var storedReports = []StoredReport{}
+	var err = c.db.View(func(tx *bolt.Tx) error {
+		var prefix = []byte(c.componentName + "/")
+		var cursor = tx.Bucket(healthBucket).Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var r StoredReport
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			storedReports = append(storedReports, r)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "component '%s' with id '%s' could not read health checks of all instances", c.componentName, c.componentID)
+	}
+
+	return storedReports, nil
+}
+
+// Clean deletes the old test reports that are no longer valid since the retention from the health bucket.
+func (c *BoltStorageModule) Clean() error {
+	var limit = time.Now().Add(-c.retention)
+	var err = c.db.Update(func(tx *bolt.Tx) error {
+		var prefix = []byte(c.componentName + "/")
+		var bucket = tx.Bucket(healthBucket)
+
+		// The keys are deleted after the iteration, deleting with the cursor may skip keys.
+		var staleKeys [][]byte
+		var cursor = bucket.Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var r StoredReport
+			if err := json.Unmarshal(v, &r); err != nil || r.ValidUntil.Before(limit) {
+				staleKeys = append(staleKeys, k)
+			}
+		}
+
+		for _, k := range staleKeys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "component '%s' with id '%s' could not clean health checks", c.componentName, c.componentID)
+	}
+
+	return nil
+}
+
+func (c *BoltStorageModule) key(unit string) []byte {
+	return []byte(fmt.Sprintf("%s/%s/%s", c.componentName, c.componentID, unit))
+}
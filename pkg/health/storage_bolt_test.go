@@ -0,0 +1,97 @@
+package health_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStorageModule(t *testing.T) {
+	var db = setupBoltDB(t)
+	defer os.Remove(db.Path())
+	defer db.Close()
+
+	var (
+		componentName = "elasticsearch-bridge"
+		reports       = json.RawMessage(`[{"name":"ping","duration":"1s","status":"OK","error":""}]`)
+	)
+
+	var m1, err = NewBoltStorageModule(componentName, "000-000-000-01", time.Hour, db)
+	assert.Nil(t, err)
+	var m2, _ = NewBoltStorageModule(componentName, "000-000-000-02", time.Hour, db)
+	var other, _ = NewBoltStorageModule("other-component", "000-000-000-03", time.Hour, db)
+
+	// No reports stored yet.
+	var r StoredReport
+	r, err = m1.Read("influx")
+	assert.Nil(t, err)
+	assert.Zero(t, r.ComponentID)
+
+	assert.Nil(t, m1.Update("influx", time.Hour, reports))
+	assert.Nil(t, m1.Update("redis", -2*time.Hour, reports))
+	assert.Nil(t, m2.Update("influx", time.Hour, reports))
+	assert.Nil(t, m2.Update("redis", -time.Minute, reports))
+	assert.Nil(t, other.Update("influx", -2*time.Hour, reports))
+
+	r, err = m1.Read("influx")
+	assert.Nil(t, err)
+	assert.Equal(t, componentName, r.ComponentName)
+	assert.Equal(t, "000-000-000-01", r.ComponentID)
+	assert.Equal(t, "influx", r.HealthcheckUnit)
+	assert.Equal(t, reports, r.Reports)
+	assert.True(t, r.ValidUntil.After(r.LastUpdated))
+
+	// ReadAll returns the reports of all the instances of the component.
+	var all []StoredReport
+	all, err = m2.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(all))
+
+	// Clean deletes the reports of the component that are no longer valid since the retention.
+	assert.Nil(t, m1.Clean())
+	all, err = m1.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(all))
+	all, err = other.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(all))
+}
+
+func TestBoltStorageModulePersistence(t *testing.T) {
+	var dir, _ = ioutil.TempDir("", "health")
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "health.db")
+
+	var db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	assert.Nil(t, err)
+	var m, _ = NewBoltStorageModule("elasticsearch-bridge", "000-000-000-01", time.Hour, db)
+	assert.Nil(t, m.Update("influx", time.Hour, json.RawMessage(`[]`)))
+	db.Close()
+
+	// The reports survive a restart.
+	db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	assert.Nil(t, err)
+	defer db.Close()
+	m, _ = NewBoltStorageModule("elasticsearch-bridge", "000-000-000-01", time.Hour, db)
+	var r, _ = m.Read("influx")
+	assert.Equal(t, json.RawMessage(`[]`), r.Reports)
+}
+
+func setupBoltDB(t *testing.T) *bolt.DB {
+	var f, err = ioutil.TempFile("", "health")
+	assert.Nil(t, err)
+	f.Close()
+	os.Remove(f.Name())
+
+	var db *bolt.DB
+	db, err = bolt.Open(f.Name(), 0600, &bolt.Options{Timeout: time.Second})
+	assert.Nil(t, err)
+	return db
+}
@@ -36,7 +36,7 @@ func TestIntNewStorageModule(t *testing.T) {
 	_, err := db.Exec("SELECT * from health")
 	assert.NotNil(t, err)
 
-	var _ = NewStorageModule(componentName, componentID, db)
+	var _ = NewStorageModule(componentName, componentID, time.Hour, db)
 
 	// NewStorageModule create table health.
 	_, err = db.Exec("SELECT * from health")
@@ -54,7 +54,7 @@ func TestIntRead(t *testing.T) {
 		reports       = json.RawMessage(`[{"name":"ping", "duration":"1s", "status":"OK", "error":"Error"}]`)
 	)
 
-	var m = NewStorageModule(componentName, componentID, db)
+	var m = NewStorageModule(componentName, componentID, time.Hour, db)
 
 	// Read health checks report for 'influx', it should be empty now.
 	var r, err = m.Read(unit)
@@ -78,6 +78,35 @@ func TestIntRead(t *testing.T) {
 	assert.Equal(t, "Error", aaa[0]["error"])
 }
 
+func TestIntReadAll(t *testing.T) {
+	var db = setupCleanDB(t)
+	rand.Seed(time.Now().UnixNano())
+
+	var (
+		componentName = "elasticsearch-bridge"
+		componentID1  = strconv.FormatUint(rand.Uint64(), 10)
+		componentID2  = strconv.FormatUint(rand.Uint64(), 10)
+		reports       = json.RawMessage(`[{"name":"ping", "duration":"1s", "status":"OK", "error":""}]`)
+	)
+
+	var m1 = NewStorageModule(componentName, componentID1, time.Hour, db)
+	var m2 = NewStorageModule(componentName, componentID2, time.Hour, db)
+
+	var r, err = m1.ReadAll()
+	assert.Nil(t, err)
+	assert.Zero(t, len(r))
+
+	// Each instance stores its reports.
+	assert.Nil(t, m1.Update("influx", 10*time.Second, reports))
+	assert.Nil(t, m1.Update("redis", 10*time.Second, reports))
+	assert.Nil(t, m2.Update("influx", 10*time.Second, reports))
+
+	// Both instances read the reports of the whole fleet.
+	r, err = m2.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(r))
+}
+
 func setupCleanDB(t *testing.T) *sql.DB {
 	var db, err = sql.Open("postgres", fmt.Sprintf("postgresql://%s@%s/%s?sslmode=disable", *user, *hostPort, *db))
 	assert.Nil(t, err)
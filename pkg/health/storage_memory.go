@@ -0,0 +1,82 @@
+package health
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryStorageModule is the module that keeps the health checks results in memory.
+// The results are lost when the bridge stops.
+type MemoryStorageModule struct {
+	componentName string
+	componentID   string
+	retention     time.Duration
+	reports       map[string]StoredReport
+	mutex         *sync.Mutex
+}
+
+// NewMemoryStorageModule returns the in-memory storage module. The reports are kept for
+// 'retention' after they are no longer valid, like in the other storages.
+func NewMemoryStorageModule(componentName, componentID string, retention time.Duration) *MemoryStorageModule {
+	return &MemoryStorageModule{
+		componentName: componentName,
+		componentID:   componentID,
+		retention:     retention,
+		reports:       map[string]StoredReport{},
+		mutex:         &sync.Mutex{},
+	}
+}
+
+// Update updates the health checks reports stored in memory with the values 'jsonReports'.
+func (m *MemoryStorageModule) Update(unit string, validity time.Duration, jsonReports json.RawMessage) error {
+	var now = time.Now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.reports[unit] = StoredReport{
+		ComponentName:   m.componentName,
+		ComponentID:     m.componentID,
+		HealthcheckUnit: unit,
+		Reports:         jsonReports,
+		LastUpdated:     now.UTC(),
+		ValidUntil:      now.Add(validity).UTC(),
+	}
+	return nil
+}
+
+// Read reads the reports in memory.
+func (m *MemoryStorageModule) Read(unit string) (StoredReport, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.reports[unit], nil
+}
+
+// ReadAll reads the reports in memory. There is only the reports of this instance.
+func (m *MemoryStorageModule) ReadAll() ([]StoredReport, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var storedReports = []StoredReport{}
+	for _, r := range m.reports {
+		storedReports = append(storedReports, r)
+	}
+	return storedReports, nil
+}
+
+// Clean deletes the old test reports that are no longer valid since the retention.
+func (m *MemoryStorageModule) Clean() error {
+	var limit = time.Now().Add(-m.retention)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for unit, r := range m.reports {
+		if r.ValidUntil.Before(limit) {
+			delete(m.reports, unit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package health_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStorageModule(t *testing.T) {
+	var (
+		componentName = "elasticsearch-bridge"
+		componentID   = "000-000-000-00"
+		reports       = json.RawMessage(`[{"name":"ping","duration":"1s","status":"OK","error":""}]`)
+	)
+
+	var m = NewMemoryStorageModule(componentName, componentID, 2*time.Hour)
+
+	// No reports stored yet.
+	var r, err = m.Read("influx")
+	assert.Nil(t, err)
+	assert.Zero(t, r.ComponentID)
+
+	assert.Nil(t, m.Update("influx", time.Hour, reports))
+	assert.Nil(t, m.Update("redis", -time.Hour, reports))
+	assert.Nil(t, m.Update("sentry", -3*time.Hour, reports))
+
+	r, err = m.Read("influx")
+	assert.Nil(t, err)
+	assert.Equal(t, componentName, r.ComponentName)
+	assert.Equal(t, componentID, r.ComponentID)
+	assert.Equal(t, "influx", r.HealthcheckUnit)
+	assert.Equal(t, reports, r.Reports)
+	assert.True(t, r.ValidUntil.After(r.LastUpdated))
+
+	var all []StoredReport
+	all, err = m.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(all))
+
+	// Clean deletes the reports that are no longer valid since the retention.
+	assert.Nil(t, m.Clean())
+	all, err = m.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(all))
+	r, err = m.Read("sentry")
+	assert.Nil(t, err)
+	assert.Zero(t, r.ComponentID)
+}
@@ -3,6 +3,7 @@ package health_test
 //go:generate mockgen -destination=./mock/storage.go -package=mock -mock_names=Storage=Storage  github.com/cloudtrust/elasticsearch-bridge/pkg/health Storage
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -35,7 +36,7 @@ const (
 		valid_until)
 		VALUES ($1, $2, $3, $4, $5, $6)`
 	selectHealthStmt = `SELECT * FROM health WHERE (component_name = $1 AND component_id = $2 AND unit = $3)`
-	cleanHealthStmt  = `DELETE from health WHERE (component_name = $1 AND valid_until < $2)`
+	cleanHealthStmt  = `DELETE from health WHERE ((component_name = $1 OR component_name = $2) AND valid_until < $3)`
 )
 
 func TestNewStorageModule(t *testing.T) {
@@ -50,7 +51,7 @@ func TestNewStorageModule(t *testing.T) {
 	)
 
 	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
-	_ = NewStorageModule(componentName, componentID, mockStorage)
+	_ = NewStorageModule(componentName, componentID, time.Hour, mockStorage)
 }
 
 func TestUpdate(t *testing.T) {
@@ -67,7 +68,7 @@ func TestUpdate(t *testing.T) {
 	)
 
 	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
-	var m = NewStorageModule(componentName, componentID, mockStorage)
+	var m = NewStorageModule(componentName, componentID, time.Hour, mockStorage)
 
 	mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
 	var err = m.Update(unit, 0, reports)
@@ -88,9 +89,36 @@ func TestUpdateFail(t *testing.T) {
 	)
 
 	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
-	var m = NewStorageModule(componentName, componentID, mockStorage)
+	var m = NewStorageModule(componentName, componentID, time.Hour, mockStorage)
 
 	mockStorage.EXPECT().Exec(upsertHealthStmt, componentName, componentID, unit, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
 	var err = m.Update(unit, 0, reports)
 	assert.NotNil(t, err)
 }
+
+func TestClean(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStorage(mockCtrl)
+
+	var (
+		componentName = "elasticsearch-bridge"
+		componentID   = strconv.FormatUint(rand.Uint64(), 10)
+	)
+
+	mockStorage.EXPECT().Exec(createHealthTblStmt).Return(nil, nil).Times(1)
+	var m = NewStorageModule(componentName, componentID, time.Hour, mockStorage)
+
+	// The reports are kept for the retention after they are no longer valid. The probe rows
+	// of the cockroach health check are cleaned too.
+	mockStorage.EXPECT().Exec(cleanHealthStmt, componentName, componentName+"-probe", gomock.Any()).DoAndReturn(func(_ string, args ...interface{}) (sql.Result, error) {
+		var limit = args[2].(time.Time)
+		assert.True(t, limit.Before(time.Now().Add(-59*time.Minute)))
+		assert.True(t, limit.After(time.Now().Add(-61*time.Minute)))
+		return nil, nil
+	}).Times(1)
+	assert.Nil(t, m.Clean())
+
+	mockStorage.EXPECT().Exec(cleanHealthStmt, componentName, componentName+"-probe", gomock.Any()).Return(nil, fmt.Errorf("fail")).Times(1)
+	assert.NotNil(t, m.Clean())
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WatchModule is the storage module that notifies the watchers of a unit each time
+// the health reports of the unit are updated.
+type WatchModule struct {
+	storage  StoreModule
+	mutex    sync.Mutex
+	watchers map[string]map[chan<- struct{}]struct{}
+}
+
+// NewWatchModule returns the watch module.
+func NewWatchModule(storage StoreModule) *WatchModule {
+	return &WatchModule{
+		storage:  storage,
+		watchers: map[string]map[chan<- struct{}]struct{}{},
+	}
+}
+
+// Watch registers the channel c to be notified of the updates of the unit's reports.
+// The notifications are not blocking: c should be buffered, and an update is dropped
+// if a previous one is still pending. The returned function unregisters c.
+func (m *WatchModule) Watch(unit string, c chan<- struct{}) func() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.watchers[unit] == nil {
+		m.watchers[unit] = map[chan<- struct{}]struct{}{}
+	}
+	m.watchers[unit][c] = struct{}{}
+
+	return func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		delete(m.watchers[unit], c)
+	}
+}
+
+// Update updates the reports in the storage and notifies the watchers of the unit.
+func (m *WatchModule) Update(unit string, validity time.Duration, jsonReports json.RawMessage) error {
+	var err = m.storage.Update(unit, validity, jsonReports)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for c := range m.watchers[unit] {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Read reads the reports in the storage.
+func (m *WatchModule) Read(unit string) (StoredReport, error) {
+	return m.storage.Read(unit)
+}
+
+// ReadAll reads the reports of all the instances of the component in the storage.
+func (m *WatchModule) ReadAll() ([]StoredReport, error) {
+	return m.storage.ReadAll()
+}
+
+// Clean deletes the old reports from the storage.
+func (m *WatchModule) Clean() error {
+	return m.storage.Clean()
+}
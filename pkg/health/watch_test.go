@@ -0,0 +1,59 @@
+package health_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/health/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockStorage = mock.NewStoreModule(mockCtrl)
+
+	var m = NewWatchModule(mockStorage)
+
+	var redisc = make(chan struct{}, 1)
+	var cancel = m.Watch("redis", redisc)
+	var influxc = make(chan struct{}, 1)
+	m.Watch("influx", influxc)
+
+	// Only the watchers of the updated unit are notified.
+	mockStorage.EXPECT().Update("redis", time.Minute, json.RawMessage(`[]`)).Return(nil).Times(2)
+	assert.Nil(t, m.Update("redis", time.Minute, json.RawMessage(`[]`)))
+	assert.Equal(t, 1, len(redisc))
+	assert.Equal(t, 0, len(influxc))
+
+	// The notification does not block when a previous one is pending.
+	assert.Nil(t, m.Update("redis", time.Minute, json.RawMessage(`[]`)))
+	assert.Equal(t, 1, len(redisc))
+	<-redisc
+
+	// The watchers are not notified when the update fails.
+	mockStorage.EXPECT().Update("redis", time.Minute, json.RawMessage(`[]`)).Return(fmt.Errorf("fail")).Times(1)
+	assert.NotNil(t, m.Update("redis", time.Minute, json.RawMessage(`[]`)))
+	assert.Equal(t, 0, len(redisc))
+
+	// Cancelled watchers are not notified.
+	cancel()
+	mockStorage.EXPECT().Update("redis", time.Minute, json.RawMessage(`[]`)).Return(nil).Times(1)
+	assert.Nil(t, m.Update("redis", time.Minute, json.RawMessage(`[]`)))
+	assert.Equal(t, 0, len(redisc))
+
+	// Reads are forwarded to the storage.
+	mockStorage.EXPECT().Read("redis").Return(StoredReport{ComponentID: "1"}, nil).Times(1)
+	var r, err = m.Read("redis")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", r.ComponentID)
+	mockStorage.EXPECT().ReadAll().Return([]StoredReport{}, nil).Times(1)
+	_, err = m.ReadAll()
+	assert.Nil(t, err)
+	mockStorage.EXPECT().Clean().Return(nil).Times(1)
+	assert.Nil(t, m.Clean())
+}
@@ -1,19 +1,21 @@
 package job
 
 import (
-	"fmt"
 	"context"
-	"time"
+	"fmt"
 	"regexp"
+	"time"
 
 	client "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
+	health "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
 	"github.com/cloudtrust/go-jobs/job"
 	"github.com/pkg/errors"
 )
 
 type ElasticsearchClient interface {
-	ListIndexes() ([]client.IndexRepresentation, error)
-	DeleteIndex(string) error
+	ListIndexes(context.Context) ([]client.IndexRepresentation, error)
+	GetIndexesSetting(context.Context, string) (map[string]client.IndexSettingRepresentation, error)
+	DeleteIndex(context.Context, string) error
 }
 
 // MakeElasticsearchCleanIndexJob creates the job that periodically clean the indexes in ElasticSearch.
@@ -23,7 +25,7 @@ func MakeElasticsearchCleanIndexJob(elasticClient ElasticsearchClient, healthChe
 		var indexes []client.IndexRepresentation
 		var err error
 
-		indexes, err = elasticClient.ListIndexes()
+		indexes, err = elasticClient.ListIndexes(ctx)
 
 		// Chesk response status.
 		if err != nil {
@@ -50,7 +52,7 @@ func MakeElasticsearchCleanIndexJob(elasticClient ElasticsearchClient, healthChe
 		for _, index := range indexes {
 			re := regexp.MustCompile("[0-9]{4}\\.[0-9]{2}\\.[0-9]{2}$")
 
-			if(re.MatchString(index)){
+			if re.MatchString(index) {
 				var dateString = re.FindString(index)
 				var t, err = time.Parse("2006.01.02", dateString)
 
@@ -60,7 +62,7 @@ func MakeElasticsearchCleanIndexJob(elasticClient ElasticsearchClient, healthChe
 					break
 				}
 
-				if t.Before(filterDate){
+				if t.Before(filterDate) {
 					filteredIndexes = append(filteredIndexes, index)
 				}
 			}
@@ -69,13 +71,13 @@ func MakeElasticsearchCleanIndexJob(elasticClient ElasticsearchClient, healthChe
 		return filteredIndexes, nil
 	}
 
-	var deleteIndexes = func(_ context.Context, r interface{}) (interface{}, error) {
+	var deleteIndexes = func(ctx context.Context, r interface{}) (interface{}, error) {
 		// Call API to clean all the index provided in param
 
 		var indexes = r.([]string)
 
 		for _, index := range indexes {
-			var err = elasticClient.DeleteIndex(index)
+			var err = elasticClient.DeleteIndex(ctx, index)
 
 			if err != nil {
 				return nil, errors.Wrap(err, "Cannot delete index")
@@ -85,4 +87,39 @@ func MakeElasticsearchCleanIndexJob(elasticClient ElasticsearchClient, healthChe
 		return "ok", nil
 	}
 	return job.NewJob("elasticsearch-cleaning", job.Steps(listIndexes, filterIndexesToClean, deleteIndexes))
-}
\ No newline at end of file
+}
+
+// MakeElasticsearchCleanProbeIndexJob creates the job that periodically deletes the probe indexes
+// older than probeIndexMaxAge, left in ElasticSearch by the former Index API health check.
+func MakeElasticsearchCleanProbeIndexJob(elasticClient ElasticsearchClient, probeIndexMaxAge time.Duration) (*job.Job, error) {
+	var listProbeIndexes = func(ctx context.Context, r interface{}) (interface{}, error) {
+		var settings, err = elasticClient.GetIndexesSetting(ctx, health.ProbeIndexCreationDateSetting)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot retrieve creation date of indexes from Elasticsearch")
+		}
+
+		return settings, nil
+	}
+
+	var filterStaleProbeIndexes = func(_ context.Context, r interface{}) (interface{}, error) {
+		var settings = r.(map[string]client.IndexSettingRepresentation)
+
+		return health.StaleProbeIndexes(settings, time.Now().Add(-probeIndexMaxAge)), nil
+	}
+
+	var deleteProbeIndexes = func(ctx context.Context, r interface{}) (interface{}, error) {
+		var indexes = r.([]string)
+
+		for _, index := range indexes {
+			var err = elasticClient.DeleteIndex(ctx, index)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "Cannot delete probe index")
+			}
+		}
+
+		return "ok", nil
+	}
+	return job.NewJob("elasticsearch-probe-cleaning", job.Steps(listProbeIndexes, filterStaleProbeIndexes, deleteProbeIndexes))
+}
@@ -7,11 +7,14 @@ import (
 	"testing"
 	"time"
 	"context"
+	"fmt"
+	"strconv"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/golang/mock/gomock"
 	. "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
 	client "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
+	health "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
 	mock "github.com/cloudtrust/elasticsearch-bridge/pkg/job/mock"
 )
 
@@ -32,8 +35,8 @@ func TestIntMakeElasticsearchCleanIndexJob(t *testing.T) {
 		Index: "int-elastic-2018.10.01",
 	}
 	var allIndexes = []client.IndexRepresentation{indexWrongFormat, indexOld, indexNew}
-	mockClient.EXPECT().ListIndexes().Return(allIndexes, nil).Times(1)
-	mockClient.EXPECT().DeleteIndex("int-elastic-1800.10.02").Return(nil).Times(1)
+	mockClient.EXPECT().ListIndexes(gomock.Any()).Return(allIndexes, nil).Times(1)
+	mockClient.EXPECT().DeleteIndex(gomock.Any(), "int-elastic-1800.10.02").Return(nil).Times(1)
 
 	var job, err = MakeElasticsearchCleanIndexJob(mockClient, 24*time.Hour * 365*100)
 	var steps = job.Steps()
@@ -59,3 +62,53 @@ func TestIntMakeElasticsearchCleanIndexJob(t *testing.T) {
 	var result = res3.(string)
 	assert.Equal(t,"ok", result)
 }
+
+func TestMakeElasticsearchCleanProbeIndexJob(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockClient = mock.NewElasticsearchClient(mockCtrl)
+
+	var oldProbe = "b1f1e38a-c5b4-11e8-a355-529269fb1459"
+	var settings = map[string]client.IndexSettingRepresentation{
+		oldProbe:                               {Settings: map[string]string{health.ProbeIndexCreationDateSetting: strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix()*1000, 10)}},
+		"1b8f6b4c-c5b5-11e8-a355-529269fb1459": {Settings: map[string]string{health.ProbeIndexCreationDateSetting: strconv.FormatInt(time.Now().Unix()*1000, 10)}},
+		"int-elastic-1800.10.02":               {Settings: map[string]string{health.ProbeIndexCreationDateSetting: "0"}},
+	}
+	mockClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(settings, nil).Times(1)
+	mockClient.EXPECT().DeleteIndex(gomock.Any(), oldProbe).Return(nil).Times(1)
+
+	var job, err = MakeElasticsearchCleanProbeIndexJob(mockClient, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "elasticsearch-probe-cleaning", job.Name())
+	assert.Equal(t, 3, len(job.Steps()))
+
+	var res1, err1 = job.Steps()[0](context.Background(), nil)
+	assert.Nil(t, err1)
+	assert.Equal(t, settings, res1)
+
+	var res2, err2 = job.Steps()[1](context.Background(), res1)
+	assert.Nil(t, err2)
+	assert.Equal(t, []string{oldProbe}, res2)
+
+	var res3, err3 = job.Steps()[2](context.Background(), res2)
+	assert.Nil(t, err3)
+	assert.Equal(t, "ok", res3)
+}
+
+func TestMakeElasticsearchCleanProbeIndexJobFailure(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockClient = mock.NewElasticsearchClient(mockCtrl)
+
+	var job, _ = MakeElasticsearchCleanProbeIndexJob(mockClient, time.Hour)
+
+	mockClient.EXPECT().GetIndexesSetting(gomock.Any(), "index.creation_date").Return(nil, fmt.Errorf("Fail to get settings")).Times(1)
+	var _, err = job.Steps()[0](context.Background(), nil)
+	assert.NotNil(t, err)
+
+	mockClient.EXPECT().DeleteIndex(gomock.Any(), "b1f1e38a-c5b4-11e8-a355-529269fb1459").Return(fmt.Errorf("Fail to delete")).Times(1)
+	_, err = job.Steps()[2](context.Background(), []string{"b1f1e38a-c5b4-11e8-a355-529269fb1459"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
+	"github.com/cloudtrust/go-jobs/job"
+	sentry "github.com/getsentry/raven-go"
+)
+
+// ErrorTracker is the interface of the Sentry client capturing the errors.
+type ErrorTracker interface {
+	CaptureError(err error, tags map[string]string, interfaces ...sentry.Interface) string
+}
+
+// MakeJobErrorTrackingMW makes an error tracking middleware at job level. It takes the
+// results of the job constructors, e.g. MakeJobErrorTrackingMW(tracker)(MakeInfluxJob(...)).
+// The errors of the steps are captured, tagged with the job name, the step and the
+// execution ID, i.e. the correlation ID of the steps.
+func MakeJobErrorTrackingMW(tracker ErrorTracker) func(*job.Job, error) (*job.Job, error) {
+	return func(j *job.Job, err error) (*job.Job, error) {
+		if err != nil {
+			return nil, err
+		}
+		return job.NewJob(j.Name(), job.Steps(trackedSteps(j.Name(), tracker, j.Steps())...))
+	}
+}
+
+// trackedSteps wraps the steps of the job.
+func trackedSteps(name string, tracker ErrorTracker, steps []job.Step) []job.Step {
+	var tracked = []job.Step{}
+	for i, step := range steps {
+		var i, step = i, step
+		tracked = append(tracked, func(ctx context.Context, r interface{}) (interface{}, error) {
+			var res, err = step(ctx, r)
+			if err != nil {
+				var tags = map[string]string{"job": name, "step": strconv.Itoa(i + 1)}
+				if id, ok := correlation.ID(ctx); ok {
+					tags["correlation_id"] = id
+				}
+				tracker.CaptureError(err, tags)
+			}
+			return res, err
+		})
+	}
+	return tracked
+}
@@ -0,0 +1,46 @@
+package job_test
+
+//go:generate mockgen -destination=./mock/error_tracking.go -package=mock -mock_names=ErrorTracker=ErrorTracker github.com/cloudtrust/elasticsearch-bridge/pkg/job ErrorTracker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
+	"github.com/cloudtrust/elasticsearch-bridge/pkg/job/mock"
+	"github.com/cloudtrust/go-jobs/job"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobErrorTrackingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockTracker = mock.NewErrorTracker(mockCtrl)
+
+	var stepErr = fmt.Errorf("fail")
+	var step1 = func(context.Context, interface{}) (interface{}, error) {
+		return "step1", nil
+	}
+	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+		return r, stepErr
+	}
+
+	var j, err = MakeJobErrorTrackingMW(mockTracker)(job.NewJob("clean", job.Steps(step1, step2)))
+	assert.Nil(t, err)
+	assert.Equal(t, "clean", j.Name())
+
+	var ctx = context.WithValue(context.Background(), "correlation_id", "executionID")
+
+	var res, err1 = j.Steps()[0](ctx, nil)
+	assert.Nil(t, err1)
+
+	mockTracker.EXPECT().CaptureError(stepErr, map[string]string{"job": "clean", "step": "2", "correlation_id": "executionID"}).Return("").Times(1)
+	var _, err2 = j.Steps()[1](ctx, res)
+	assert.Equal(t, stepErr, err2)
+
+	// The errors of the job constructors are returned.
+	_, err = MakeJobErrorTrackingMW(mockTracker)(nil, fmt.Errorf("fail"))
+	assert.NotNil(t, err)
+}
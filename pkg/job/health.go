@@ -9,6 +9,7 @@ import (
 	health "github.com/cloudtrust/elasticsearch-bridge/pkg/health"
 	"github.com/cloudtrust/go-jobs/job"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 )
 
 // Cockroach is the interface of the module that stores the health reports
@@ -53,6 +54,11 @@ type ElasticsearchHealthChecker interface {
 	HealthChecks(context.Context) []health.ElasticsearchReport
 }
 
+// CockroachHealthChecker is the interface of the cockroach health check module.
+type CockroachHealthChecker interface {
+	HealthChecks(context.Context) []health.CockroachReport
+}
+
 // MakeInfluxJob creates the job that periodically exectutes the health checks and save the result in DB.
 func MakeInfluxJob(influx InfluxHealthChecker, healthCheckValidity time.Duration, cockroach Cockroach) (*job.Job, error) {
 	var step1 = func(ctx context.Context, r interface{}) (interface{}, error) {
@@ -141,7 +147,7 @@ func MakeElasticsearchJob(elasticsearch ElasticsearchHealthChecker, healthCheckV
 // MakeCleanCockroachJob creates the job that periodically exectutes the health checks and save the result in DB.
 func MakeCleanCockroachJob(cockroach Cockroach, logger log.Logger) (*job.Job, error) {
 	var clean = func(context.Context, interface{}) (interface{}, error) {
-		logger.Log("step", "clean")
+		level.Debug(logger).Log("step", "clean")
 		return nil, cockroach.Clean()
 	}
 	return job.NewJob("clean", job.Steps(clean))
@@ -154,3 +160,17 @@ func err(err error) string {
 	}
 	return err.Error()
 }
+
+// MakeCockroachJob creates the job that periodically exectutes the health checks and save the result in DB.
+func MakeCockroachJob(cockroach CockroachHealthChecker, healthCheckValidity time.Duration, storage Cockroach) (*job.Job, error) {
+	var step1 = func(ctx context.Context, r interface{}) (interface{}, error) {
+		return cockroach.HealthChecks(ctx), nil
+	}
+	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+		var jsonReports, _ = json.Marshal(r)
+
+		var err = storage.Update("cockroach", healthCheckValidity, jsonReports)
+		return nil, err
+	}
+	return job.NewJob("cockroach", job.Steps(step1, step2))
+}
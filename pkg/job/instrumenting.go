@@ -0,0 +1,53 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudtrust/go-jobs/job"
+	"github.com/go-kit/kit/metrics"
+)
+
+// MakeJobInstrumentingMW makes an instrumenting middleware at job level. It takes the
+// results of the job constructors, e.g. MakeJobInstrumentingMW(c, h)(MakeInfluxJob(...)).
+// The executions are counted by unit, the name of the job, and status, success or failure.
+// An execution fails as soon as one of its steps fails. The durations of the executions are
+// labeled by unit.
+func MakeJobInstrumentingMW(counter metrics.Counter, histogram metrics.Histogram) func(*job.Job, error) (*job.Job, error) {
+	return func(j *job.Job, err error) (*job.Job, error) {
+		if err != nil {
+			return nil, err
+		}
+		return job.NewJob(j.Name(), job.Steps(instrumentedSteps(j.Name(), counter, histogram, j.Steps())...))
+	}
+}
+
+// instrumentedSteps wraps the steps of the job. The controller does not run two executions
+// of the same job at the same time, so the steps can share the beginning of the execution.
+func instrumentedSteps(unit string, counter metrics.Counter, histogram metrics.Histogram, steps []job.Step) []job.Step {
+	var begin time.Time
+	var observe = func(status string) {
+		counter.With("unit", unit, "status", status).Add(1)
+		histogram.With("unit", unit).Observe(time.Since(begin).Seconds())
+	}
+
+	var instrumented = []job.Step{}
+	for i, step := range steps {
+		var i, step = i, step
+		instrumented = append(instrumented, func(ctx context.Context, r interface{}) (interface{}, error) {
+			if i == 0 {
+				begin = time.Now()
+			}
+
+			var res, err = step(ctx, r)
+			switch {
+			case err != nil:
+				observe("failure")
+			case i == len(steps)-1:
+				observe("success")
+			}
+			return res, err
+		})
+	}
+	return instrumented
+}
@@ -0,0 +1,70 @@
+package job_test
+
+//go:generate mockgen -destination=./mock/instrumenting.go -package=mock -mock_names=Counter=Counter,Gauge=Gauge,Histogram=Histogram github.com/go-kit/kit/metrics Counter,Gauge,Histogram
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
+	mock "github.com/cloudtrust/elasticsearch-bridge/pkg/job/mock"
+	"github.com/cloudtrust/go-jobs/job"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobInstrumentingMW(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCounter = mock.NewCounter(mockCtrl)
+	var mockHistogram = mock.NewHistogram(mockCtrl)
+
+	var stepErr error
+	var step1 = func(context.Context, interface{}) (interface{}, error) {
+		return "step1", nil
+	}
+	var step2 = func(_ context.Context, r interface{}) (interface{}, error) {
+		return r, stepErr
+	}
+
+	var j, err = MakeJobInstrumentingMW(mockCounter, mockHistogram)(job.NewJob("influx", job.Steps(step1, step2)))
+	assert.Nil(t, err)
+	assert.Equal(t, "influx", j.Name())
+	assert.Equal(t, 2, len(j.Steps()))
+
+	// Success, recorded after the last step.
+	{
+		var res1, err1 = j.Steps()[0](context.Background(), nil)
+		assert.Equal(t, "step1", res1)
+		assert.Nil(t, err1)
+
+		mockCounter.EXPECT().With("unit", "influx", "status", "success").Return(mockCounter).Times(1)
+		mockCounter.EXPECT().Add(1.0).Times(1)
+		mockHistogram.EXPECT().With("unit", "influx").Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+		var res2, err2 = j.Steps()[1](context.Background(), res1)
+		assert.Equal(t, "step1", res2)
+		assert.Nil(t, err2)
+	}
+
+	// Failure.
+	{
+		stepErr = fmt.Errorf("fail")
+		j.Steps()[0](context.Background(), nil)
+
+		mockCounter.EXPECT().With("unit", "influx", "status", "failure").Return(mockCounter).Times(1)
+		mockCounter.EXPECT().Add(1.0).Times(1)
+		mockHistogram.EXPECT().With("unit", "influx").Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Times(1)
+		var _, err2 = j.Steps()[1](context.Background(), nil)
+		assert.NotNil(t, err2)
+	}
+
+	// Error from the job constructor.
+	{
+		var j, err = MakeJobInstrumentingMW(mockCounter, mockHistogram)(nil, fmt.Errorf("fail"))
+		assert.Nil(t, j)
+		assert.NotNil(t, err)
+	}
+}
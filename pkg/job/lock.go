@@ -0,0 +1,102 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const lockBucket = "jobs_lock"
+
+// lockState is the state of the lock of a job in the embedded store.
+type lockState struct {
+	ComponentID string    `json:"component_id"`
+	JobID       string    `json:"job_id"`
+	Disabled    bool      `json:"disabled"`
+	Locked      bool      `json:"locked"`
+	LockTime    time.Time `json:"lock_time"`
+}
+
+// Lock is the go-jobs lock backed by the embedded store, used instead of the cockroach
+// lock when cockroach is disabled. The embedded store is not shared with other instances,
+// so the lock only guards the executions of this instance. A lock held for longer than
+// the job max duration is abandoned and can be taken over.
+type Lock struct {
+	store Store
+}
+
+// NewLock returns the lock that keeps the jobs locks in the store.
+func NewLock(store Store) *Lock {
+	return &Lock{
+		store: store,
+	}
+}
+
+// Lock takes the lock of the job for the execution jobID of the component instance
+// componentID. It fails if the job is disabled or locked by another execution.
+func (l *Lock) Lock(componentName, componentID, jobName, jobID string, jobMaxDuration time.Duration) error {
+	return l.update(componentName, jobName, func(s *lockState) error {
+		var now = time.Now()
+		switch {
+		case s.Disabled:
+			return fmt.Errorf("job '%s' of component '%s' is disabled", jobName, componentName)
+		case s.Locked && !s.ownedBy(componentID, jobID) && now.Sub(s.LockTime) < jobMaxDuration:
+			return fmt.Errorf("job '%s' of component '%s' is locked by '%s' for execution '%s'", jobName, componentName, s.ComponentID, s.JobID)
+		}
+
+		s.ComponentID = componentID
+		s.JobID = jobID
+		s.Locked = true
+		s.LockTime = now.UTC()
+		return nil
+	})
+}
+
+// Unlock releases the lock of the job held by the execution jobID.
+func (l *Lock) Unlock(componentName, componentID, jobName, jobID string) error {
+	return l.update(componentName, jobName, func(s *lockState) error {
+		if !s.Locked || !s.ownedBy(componentID, jobID) {
+			return fmt.Errorf("job '%s' of component '%s' is not locked by '%s' for execution '%s'", jobName, componentName, componentID, jobID)
+		}
+
+		s.Locked = false
+		return nil
+	})
+}
+
+// Enable enables the job, it can be locked again.
+func (l *Lock) Enable(componentName, jobName string) error {
+	return l.update(componentName, jobName, func(s *lockState) error {
+		s.Disabled = false
+		return nil
+	})
+}
+
+// Disable disables the job, it cannot be locked until it is enabled.
+func (l *Lock) Disable(componentName, jobName string) error {
+	return l.update(componentName, jobName, func(s *lockState) error {
+		s.Disabled = true
+		return nil
+	})
+}
+
+// update applies f to the lock state of the job in a single transaction of the store, so
+// the lock is taken atomically.
+func (l *Lock) update(componentName, jobName string, f func(*lockState) error) error {
+	return l.store.Update(lockBucket, statusKey(componentName, jobName), func(value []byte) ([]byte, error) {
+		var s = lockState{}
+		if value != nil {
+			if err := json.Unmarshal(value, &s); err != nil {
+				return nil, err
+			}
+		}
+		if err := f(&s); err != nil {
+			return nil, err
+		}
+		return json.Marshal(s)
+	})
+}
+
+func (s lockState) ownedBy(componentID, jobID string) bool {
+	return s.ComponentID == componentID && s.JobID == jobID
+}
@@ -0,0 +1,48 @@
+package job_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	var db = setupBoltDB(t)
+	defer os.Remove(db.Path())
+	defer db.Close()
+
+	var stores = map[string]Store{
+		"memory": NewMemoryStore(),
+		"bolt":   NewBoltStore(db),
+	}
+
+	for name, store := range stores {
+		var l = NewLock(store)
+
+		assert.Nil(t, l.Lock("elasticsearch-bridge", "01", "clean-indexes", "1", time.Hour), name)
+		// The owner can lock again, the others cannot.
+		assert.Nil(t, l.Lock("elasticsearch-bridge", "01", "clean-indexes", "1", time.Hour), name)
+		assert.NotNil(t, l.Lock("elasticsearch-bridge", "02", "clean-indexes", "2", time.Hour), name)
+		assert.NotNil(t, l.Unlock("elasticsearch-bridge", "02", "clean-indexes", "2"), name)
+		// The other jobs are not locked.
+		assert.Nil(t, l.Lock("elasticsearch-bridge", "02", "clean-health", "2", time.Hour), name)
+
+		assert.Nil(t, l.Unlock("elasticsearch-bridge", "01", "clean-indexes", "1"), name)
+		assert.NotNil(t, l.Unlock("elasticsearch-bridge", "01", "clean-indexes", "1"), name)
+		assert.Nil(t, l.Lock("elasticsearch-bridge", "02", "clean-indexes", "2", time.Hour), name)
+
+		// A lock older than the job max duration is taken over.
+		assert.Nil(t, l.Lock("elasticsearch-bridge", "01", "clean-indexes", "3", 0), name)
+		assert.NotNil(t, l.Unlock("elasticsearch-bridge", "02", "clean-indexes", "2"), name)
+		assert.Nil(t, l.Unlock("elasticsearch-bridge", "01", "clean-indexes", "3"), name)
+
+		// A disabled job cannot be locked.
+		assert.Nil(t, l.Disable("elasticsearch-bridge", "clean-indexes"), name)
+		assert.NotNil(t, l.Lock("elasticsearch-bridge", "01", "clean-indexes", "4", time.Hour), name)
+		assert.Nil(t, l.Enable("elasticsearch-bridge", "clean-indexes"), name)
+		assert.Nil(t, l.Lock("elasticsearch-bridge", "01", "clean-indexes", "4", time.Hour), name)
+	}
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	elasticsearch_bridge "github.com/cloudtrust/elasticsearch-bridge/internal/elasticsearch_bridge"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -33,27 +34,40 @@ func (m *ElasticsearchClient) EXPECT() *ElasticsearchClientMockRecorder {
 	return m.recorder
 }
 
-// ListIndexes mocks base method
-func (m *ElasticsearchClient) ListIndexes() ([]elasticsearch_bridge.IndexRepresentation, error) {
-	ret := m.ctrl.Call(m, "ListIndexes")
-	ret0, _ := ret[0].([]elasticsearch_bridge.IndexRepresentation)
+// DeleteIndex mocks base method
+func (m *ElasticsearchClient) DeleteIndex(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "DeleteIndex", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIndex indicates an expected call of DeleteIndex
+func (mr *ElasticsearchClientMockRecorder) DeleteIndex(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIndex", reflect.TypeOf((*ElasticsearchClient)(nil).DeleteIndex), arg0, arg1)
+}
+
+// GetIndexesSetting mocks base method
+func (m *ElasticsearchClient) GetIndexesSetting(arg0 context.Context, arg1 string) (map[string]elasticsearch_bridge.IndexSettingRepresentation, error) {
+	ret := m.ctrl.Call(m, "GetIndexesSetting", arg0, arg1)
+	ret0, _ := ret[0].(map[string]elasticsearch_bridge.IndexSettingRepresentation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListIndexes indicates an expected call of ListIndexes
-func (mr *ElasticsearchClientMockRecorder) ListIndexes() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIndexes", reflect.TypeOf((*ElasticsearchClient)(nil).ListIndexes))
+// GetIndexesSetting indicates an expected call of GetIndexesSetting
+func (mr *ElasticsearchClientMockRecorder) GetIndexesSetting(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndexesSetting", reflect.TypeOf((*ElasticsearchClient)(nil).GetIndexesSetting), arg0, arg1)
 }
 
-// DeleteIndex mocks base method
-func (m *ElasticsearchClient) DeleteIndex(arg0 string) error {
-	ret := m.ctrl.Call(m, "DeleteIndex", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+// ListIndexes mocks base method
+func (m *ElasticsearchClient) ListIndexes(arg0 context.Context) ([]elasticsearch_bridge.IndexRepresentation, error) {
+	ret := m.ctrl.Call(m, "ListIndexes", arg0)
+	ret0, _ := ret[0].([]elasticsearch_bridge.IndexRepresentation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteIndex indicates an expected call of DeleteIndex
-func (mr *ElasticsearchClientMockRecorder) DeleteIndex(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIndex", reflect.TypeOf((*ElasticsearchClient)(nil).DeleteIndex), arg0)
+// ListIndexes indicates an expected call of ListIndexes
+func (mr *ElasticsearchClientMockRecorder) ListIndexes(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIndexes", reflect.TypeOf((*ElasticsearchClient)(nil).ListIndexes), arg0)
 }
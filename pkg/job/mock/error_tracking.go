@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cloudtrust/elasticsearch-bridge/pkg/job (interfaces: ErrorTracker)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	raven "github.com/getsentry/raven-go"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// ErrorTracker is a mock of ErrorTracker interface
+type ErrorTracker struct {
+	ctrl     *gomock.Controller
+	recorder *ErrorTrackerMockRecorder
+}
+
+// ErrorTrackerMockRecorder is the mock recorder for ErrorTracker
+type ErrorTrackerMockRecorder struct {
+	mock *ErrorTracker
+}
+
+// NewErrorTracker creates a new mock instance
+func NewErrorTracker(ctrl *gomock.Controller) *ErrorTracker {
+	mock := &ErrorTracker{ctrl: ctrl}
+	mock.recorder = &ErrorTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *ErrorTracker) EXPECT() *ErrorTrackerMockRecorder {
+	return m.recorder
+}
+
+// CaptureError mocks base method
+func (m *ErrorTracker) CaptureError(arg0 error, arg1 map[string]string, arg2 ...raven.Interface) string {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CaptureError", varargs...)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CaptureError indicates an expected call of CaptureError
+func (mr *ErrorTrackerMockRecorder) CaptureError(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureError", reflect.TypeOf((*ErrorTracker)(nil).CaptureError), varargs...)
+}
@@ -0,0 +1,121 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	statusBucket = "jobs_status"
+
+	statusCompleted = "COMPLETED"
+	statusFailed    = "FAILED"
+)
+
+// JobStatus is the status of a job in the embedded store: the current execution and the
+// outcome of the last one.
+type JobStatus struct {
+	ComponentName         string            `json:"component_name"`
+	JobName               string            `json:"job_name"`
+	JobID                 string            `json:"job_id"`
+	StartTime             time.Time         `json:"start_time"`
+	StepInfos             map[string]string `json:"step_infos"`
+	Message               map[string]string `json:"message"`
+	LastExecution         time.Time         `json:"last_execution"`
+	LastExecutionSuccess  time.Time         `json:"last_execution_success"`
+	LastExecutionDuration time.Duration     `json:"last_execution_duration"`
+	LastExecutionStatus   string            `json:"last_execution_status"`
+}
+
+// StatusStorage is the go-jobs status storage backed by the embedded store, used instead
+// of the cockroach status storage when cockroach is disabled.
+type StatusStorage struct {
+	store Store
+}
+
+// NewStatusStorage returns the status storage that keeps the jobs status in the store.
+func NewStatusStorage(store Store) *StatusStorage {
+	return &StatusStorage{
+		store: store,
+	}
+}
+
+// Start records the start of an execution of the job.
+func (s *StatusStorage) Start(componentName, jobName string) error {
+	return s.update(componentName, jobName, func(st *JobStatus) {
+		st.StartTime = time.Now().UTC()
+		st.StepInfos = nil
+		st.Message = nil
+	})
+}
+
+// Update records the steps infos of the running execution of the job.
+func (s *StatusStorage) Update(componentName, jobName string, stepInfos map[string]string) error {
+	return s.update(componentName, jobName, func(st *JobStatus) {
+		st.StepInfos = stepInfos
+	})
+}
+
+// Complete records the success of the execution jobID of the job.
+func (s *StatusStorage) Complete(componentName, jobName, jobID string, stepInfos, message map[string]string) error {
+	return s.end(componentName, jobName, jobID, stepInfos, message, statusCompleted)
+}
+
+// Fail records the failure of the execution jobID of the job.
+func (s *StatusStorage) Fail(componentName, jobName, jobID string, stepInfos, message map[string]string) error {
+	return s.end(componentName, jobName, jobID, stepInfos, message, statusFailed)
+}
+
+// Status returns the status of the job. It is empty if the job never started.
+func (s *StatusStorage) Status(componentName, jobName string) (JobStatus, error) {
+	var st = JobStatus{}
+	var value, err = s.store.Get(statusBucket, statusKey(componentName, jobName))
+	if err == nil && value != nil {
+		err = json.Unmarshal(value, &st)
+	}
+
+	if err != nil {
+		return JobStatus{}, errors.Wrapf(err, "could not read status of job '%s' of component '%s'", jobName, componentName)
+	}
+	return st, nil
+}
+
+func (s *StatusStorage) end(componentName, jobName, jobID string, stepInfos, message map[string]string, status string) error {
+	return s.update(componentName, jobName, func(st *JobStatus) {
+		st.JobID = jobID
+		st.StepInfos = stepInfos
+		st.Message = message
+		st.LastExecution = st.StartTime
+		st.LastExecutionDuration = time.Since(st.StartTime)
+		st.LastExecutionStatus = status
+		if status == statusCompleted {
+			st.LastExecutionSuccess = st.StartTime
+		}
+	})
+}
+
+// update applies f to the status of the job in a single transaction of the store.
+func (s *StatusStorage) update(componentName, jobName string, f func(*JobStatus)) error {
+	var err = s.store.Update(statusBucket, statusKey(componentName, jobName), func(value []byte) ([]byte, error) {
+		var st = JobStatus{ComponentName: componentName, JobName: jobName}
+		if value != nil {
+			if err := json.Unmarshal(value, &st); err != nil {
+				return nil, err
+			}
+		}
+		f(&st)
+		return json.Marshal(st)
+	})
+
+	if err != nil {
+		return errors.Wrapf(err, "could not update status of job '%s' of component '%s'", jobName, componentName)
+	}
+	return nil
+}
+
+func statusKey(componentName, jobName string) string {
+	return fmt.Sprintf("%s/%s", componentName, jobName)
+}
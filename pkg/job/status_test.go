@@ -0,0 +1,86 @@
+package job_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusStorage(t *testing.T) {
+	var db = setupBoltDB(t)
+	defer os.Remove(db.Path())
+	defer db.Close()
+
+	var stores = map[string]Store{
+		"memory": NewMemoryStore(),
+		"bolt":   NewBoltStore(db),
+	}
+
+	for name, store := range stores {
+		var s = NewStatusStorage(store)
+
+		// No status stored yet.
+		var st, err = s.Status("elasticsearch-bridge", "clean-indexes")
+		assert.Nil(t, err, name)
+		assert.Zero(t, st, name)
+
+		// Successful execution.
+		assert.Nil(t, s.Start("elasticsearch-bridge", "clean-indexes"), name)
+		assert.Nil(t, s.Update("elasticsearch-bridge", "clean-indexes", map[string]string{"step": "list"}), name)
+		st, _ = s.Status("elasticsearch-bridge", "clean-indexes")
+		assert.Equal(t, "clean-indexes", st.JobName, name)
+		assert.Equal(t, map[string]string{"step": "list"}, st.StepInfos, name)
+		assert.Zero(t, st.LastExecutionStatus, name)
+		var start = st.StartTime
+
+		assert.Nil(t, s.Complete("elasticsearch-bridge", "clean-indexes", "1", map[string]string{"step": "delete"}, map[string]string{"deleted": "2"}), name)
+		st, _ = s.Status("elasticsearch-bridge", "clean-indexes")
+		assert.Equal(t, "1", st.JobID, name)
+		assert.Equal(t, "COMPLETED", st.LastExecutionStatus, name)
+		assert.Equal(t, map[string]string{"deleted": "2"}, st.Message, name)
+		assert.True(t, start.Equal(st.LastExecution), name)
+		assert.True(t, start.Equal(st.LastExecutionSuccess), name)
+
+		// Failed execution: the last success is kept.
+		assert.Nil(t, s.Start("elasticsearch-bridge", "clean-indexes"), name)
+		st, _ = s.Status("elasticsearch-bridge", "clean-indexes")
+		assert.Nil(t, st.StepInfos, name)
+		assert.Nil(t, s.Fail("elasticsearch-bridge", "clean-indexes", "2", nil, map[string]string{"error": "fail"}), name)
+		st, _ = s.Status("elasticsearch-bridge", "clean-indexes")
+		assert.Equal(t, "FAILED", st.LastExecutionStatus, name)
+		assert.True(t, start.Equal(st.LastExecutionSuccess), name)
+
+		// The jobs are stored separately.
+		st, _ = s.Status("other-component", "clean-indexes")
+		assert.Zero(t, st, name)
+	}
+}
+
+func TestStatusStorageInvalidStatus(t *testing.T) {
+	var store = NewMemoryStore()
+	store.Update("jobs_status", "elasticsearch-bridge/clean-indexes", func([]byte) ([]byte, error) {
+		return []byte("invalid"), nil
+	})
+
+	var s = NewStatusStorage(store)
+	var _, err = s.Status("elasticsearch-bridge", "clean-indexes")
+	assert.NotNil(t, err)
+	assert.NotNil(t, s.Start("elasticsearch-bridge", "clean-indexes"))
+}
+
+func setupBoltDB(t *testing.T) *bolt.DB {
+	var f, err = ioutil.TempFile("", "jobs")
+	assert.Nil(t, err)
+	f.Close()
+	os.Remove(f.Name())
+
+	var db *bolt.DB
+	db, err = bolt.Open(f.Name(), 0600, &bolt.Options{Timeout: time.Second})
+	assert.Nil(t, err)
+	return db
+}
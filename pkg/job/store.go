@@ -0,0 +1,100 @@
+package job
+
+import (
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+// Store is the embedded store of the jobs status and locks, used when cockroach is disabled.
+type Store interface {
+	// Get returns the value of the key in the bucket, nil if there is none.
+	Get(bucket, key string) ([]byte, error)
+	// Update replaces the value of the key in the bucket with the value returned by f, in a
+	// single transaction. f gets the current value, nil if there is none. If f returns an
+	// error, the value is left unchanged.
+	Update(bucket, key string, f func(value []byte) ([]byte, error)) error
+}
+
+// MemoryStore is the store that keeps the values in memory. The values are lost when the
+// bridge stops.
+type MemoryStore struct {
+	values map[string][]byte
+	mutex  *sync.Mutex
+}
+
+// NewMemoryStore returns the in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		values: map[string][]byte{},
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// Get returns the value of the key in the bucket.
+func (s *MemoryStore) Get(bucket, key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.values[bucket+"/"+key], nil
+}
+
+// Update replaces the value of the key in the bucket with the value returned by f.
+func (s *MemoryStore) Update(bucket, key string, f func([]byte) ([]byte, error)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var value, err = f(s.values[bucket+"/"+key])
+	if err != nil {
+		return err
+	}
+	s.values[bucket+"/"+key] = value
+	return nil
+}
+
+// BoltStore is the store that saves the values in an embedded BoltDB file, the one of the
+// BoltDB health storage.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore returns the BoltDB store. The buckets are created on first update.
+func NewBoltStore(db *bolt.DB) *BoltStore {
+	return &BoltStore{
+		db: db,
+	}
+}
+
+// Get returns the value of the key in the bucket.
+func (s *BoltStore) Get(bucket, key string) ([]byte, error) {
+	var value []byte
+	var err = s.db.View(func(tx *bolt.Tx) error {
+		var b = tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		// The value returned by bolt is only valid during the transaction.
+		if v := b.Get([]byte(key)); v != nil {
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// Update replaces the value of the key in the bucket with the value returned by f.
+func (s *BoltStore) Update(bucket, key string, f func([]byte) ([]byte, error)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var b, err = tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		var value []byte
+		value, err = f(b.Get([]byte(key)))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+}
@@ -0,0 +1,84 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudtrust/elasticsearch-bridge/internal/correlation"
+	"github.com/cloudtrust/go-jobs/job"
+	opentracing "github.com/opentracing/opentracing-go"
+	otag "github.com/opentracing/opentracing-go/ext"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+// MakeJobTracingMW makes a tracing middleware at job level. It takes the results of the
+// job constructors, e.g. MakeJobTracingMW(tracer, componentID)(MakeInfluxJob(...)).
+// Each execution is traced in a span carrying the job name, the execution ID and the ID of
+// the component holding the job lock, i.e. the component running the execution. Each step
+// is traced in a child span, which the step receives in its context, so the requests made
+// by the step are traced in its children.
+func MakeJobTracingMW(tracer opentracing.Tracer, componentID string) func(*job.Job, error) (*job.Job, error) {
+	return func(j *job.Job, err error) (*job.Job, error) {
+		if err != nil {
+			return nil, err
+		}
+		return job.NewJob(j.Name(), job.Steps(tracedSteps(j.Name(), tracer, componentID, j.Steps())...))
+	}
+}
+
+// tracedSteps wraps the steps of the job. The controller does not run two executions of
+// the same job at the same time, so the steps can share the span of the execution. The
+// execution ID is the correlation ID that go-jobs puts in the context of the steps.
+func tracedSteps(name string, tracer opentracing.Tracer, componentID string, steps []job.Step) []job.Step {
+	var span opentracing.Span
+
+	var traced = []job.Step{}
+	for i, step := range steps {
+		var i, step = i, step
+		traced = append(traced, func(ctx context.Context, r interface{}) (interface{}, error) {
+			if i == 0 {
+				var opts = []opentracing.StartSpanOption{}
+				if parent := opentracing.SpanFromContext(ctx); parent != nil {
+					opts = append(opts, opentracing.ChildOf(parent.Context()))
+				}
+				span = tracer.StartSpan("job_"+name, opts...)
+				span.SetTag("job.name", name)
+				span.SetTag("job.lock_holder", componentID)
+				if id, ok := correlation.ID(ctx); ok {
+					span.SetTag("job.execution_id", id)
+				}
+			}
+
+			var stepSpan = tracer.StartSpan(fmt.Sprintf("job_%s_step_%d", name, i+1), opentracing.ChildOf(span.Context()))
+			stepSpan.SetTag("job.name", name)
+			stepSpan.SetTag("job.step", i+1)
+
+			var res, err = step(opentracing.ContextWithSpan(ctx, stepSpan), r)
+
+			switch {
+			case err != nil:
+				finishSpan(stepSpan, "job.step.outcome", err)
+				finishSpan(span, "job.outcome", err)
+			case i == len(steps)-1:
+				finishSpan(stepSpan, "job.step.outcome", nil)
+				finishSpan(span, "job.outcome", nil)
+			default:
+				finishSpan(stepSpan, "job.step.outcome", nil)
+			}
+			return res, err
+		})
+	}
+	return traced
+}
+
+// finishSpan sets the outcome tag of the span, success or failure, and finishes it.
+func finishSpan(span opentracing.Span, outcomeTag string, err error) {
+	if err != nil {
+		span.SetTag(outcomeTag, "failure")
+		otag.Error.Set(span, true)
+		span.LogFields(olog.Error(err))
+	} else {
+		span.SetTag(outcomeTag, "success")
+	}
+	span.Finish()
+}
@@ -0,0 +1,88 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/cloudtrust/elasticsearch-bridge/pkg/job"
+	"github.com/cloudtrust/go-jobs/job"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobTracingMW(t *testing.T) {
+	var tracer = mocktracer.New()
+
+	var stepErr error
+	var stepSpan opentracing.Span
+	var step1 = func(context.Context, interface{}) (interface{}, error) {
+		return "step1", nil
+	}
+	var step2 = func(ctx context.Context, r interface{}) (interface{}, error) {
+		stepSpan = opentracing.SpanFromContext(ctx)
+		return r, stepErr
+	}
+
+	var j, err = MakeJobTracingMW(tracer, "componentID")(job.NewJob("elasticsearch-cleaning", job.Steps(step1, step2)))
+	assert.Nil(t, err)
+	assert.Equal(t, "elasticsearch-cleaning", j.Name())
+	assert.Equal(t, 2, len(j.Steps()))
+
+	var ctx = context.WithValue(context.Background(), "correlation_id", "executionID")
+
+	// Success.
+	{
+		var res1, _ = j.Steps()[0](ctx, nil)
+		assert.Equal(t, 1, len(tracer.FinishedSpans()))
+
+		var res2, err2 = j.Steps()[1](ctx, res1)
+		assert.Equal(t, "step1", res2)
+		assert.Nil(t, err2)
+
+		var spans = tracer.FinishedSpans()
+		assert.Equal(t, 3, len(spans))
+		var step1Span, step2Span, jobSpan = spans[0], spans[1], spans[2]
+
+		assert.Equal(t, "job_elasticsearch-cleaning", jobSpan.OperationName)
+		assert.Equal(t, "elasticsearch-cleaning", jobSpan.Tag("job.name"))
+		assert.Equal(t, "executionID", jobSpan.Tag("job.execution_id"))
+		assert.Equal(t, "componentID", jobSpan.Tag("job.lock_holder"))
+		assert.Equal(t, "success", jobSpan.Tag("job.outcome"))
+
+		assert.Equal(t, "job_elasticsearch-cleaning_step_1", step1Span.OperationName)
+		assert.Equal(t, jobSpan.SpanContext.SpanID, step1Span.ParentID)
+		assert.Equal(t, "success", step1Span.Tag("job.step.outcome"))
+		assert.Equal(t, jobSpan.SpanContext.SpanID, step2Span.ParentID)
+		assert.Equal(t, 2, step2Span.Tag("job.step"))
+
+		// The step receives its span in the context.
+		assert.Equal(t, step2Span.SpanContext.SpanID, stepSpan.(*mocktracer.MockSpan).SpanContext.SpanID)
+	}
+
+	// Failure.
+	{
+		tracer.Reset()
+		stepErr = fmt.Errorf("fail")
+
+		var res1, _ = j.Steps()[0](ctx, nil)
+		var _, err2 = j.Steps()[1](ctx, res1)
+		assert.NotNil(t, err2)
+
+		var spans = tracer.FinishedSpans()
+		assert.Equal(t, 3, len(spans))
+		var step2Span, jobSpan = spans[1], spans[2]
+		assert.Equal(t, "failure", step2Span.Tag("job.step.outcome"))
+		assert.Equal(t, true, step2Span.Tag("error"))
+		assert.Equal(t, "failure", jobSpan.Tag("job.outcome"))
+		assert.Equal(t, true, jobSpan.Tag("error"))
+	}
+
+	// Error from the job constructor.
+	{
+		var j, err = MakeJobTracingMW(tracer, "componentID")(nil, fmt.Errorf("fail"))
+		assert.Nil(t, j)
+		assert.NotNil(t, err)
+	}
+}